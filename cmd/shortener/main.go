@@ -14,11 +14,11 @@ import (
 
 	pb "github.com/tank4gun/gourlshortener/internal/pkg/proto"
 
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
 	"github.com/tank4gun/gourlshortener/internal/app/db"
 	"github.com/tank4gun/gourlshortener/internal/app/handlers"
 	"github.com/tank4gun/gourlshortener/internal/app/server"
 	"github.com/tank4gun/gourlshortener/internal/app/storage"
-	"github.com/tank4gun/gourlshortener/internal/app/types"
 	"github.com/tank4gun/gourlshortener/internal/app/varprs"
 	"google.golang.org/grpc"
 )
@@ -43,12 +43,54 @@ func main() {
 	fmt.Printf("Build commit: %s\n", buildCommit)
 
 	varprs.Init()
-	db.RunMigrations(varprs.DatabaseDSN)
+	if err := db.RunMigrations(varprs.DatabaseDSN); err != nil {
+		log.Fatalf("Err while running migrations, %v", err)
+	}
 	internalStorage := map[uint]storage.URL{}
 	nextIndex := uint(1)
-	strg, _ := storage.NewStorage(internalStorage, nextIndex, varprs.FileStoragePath, varprs.DatabaseDSN)
-	deleteChannel := make(chan types.RequestToDelete, 10)
-	currentServer := server.CreateServer(strg, deleteChannel)
+	strg, err := storage.NewStorage(internalStorage, nextIndex, varprs.FileStoragePath, varprs.DatabaseDSN)
+	if err != nil {
+		log.Fatalf("Err while opening storage, %v", err)
+	}
+	if varprs.CacheSize > 0 {
+		strg = storage.NewCachedStorage(strg, varprs.CacheSize, varprs.CacheTTL)
+	}
+	if varprs.DeleteQueuePath == "" && varprs.DatabaseDSN != "" {
+		log.Fatalf("Delete queue path should be set for database storage with -delete_queue_path flag or DELETE_QUEUE_PATH env")
+	}
+	deleteQueue, err := handlers.NewDeleteQueue(strg, varprs.DeleteQueuePath)
+	if err != nil {
+		log.Fatalf("Err while opening delete queue, %v", err)
+	}
+	go deleteQueue.Run()
+	clickRecorder := handlers.NewClickRecorder(strg)
+	go clickRecorder.Run()
+	keyring, err := auth.ParseKeyring(varprs.AuthKeys, varprs.AuthKeyID)
+	if err != nil {
+		log.Fatalf("Err while parsing auth keys, %v", err)
+	}
+	if varprs.AuthKeys == "" {
+		log.Println("WARNING: auth keys aren't set, identity tokens are signed with public default key and can be forged, " +
+			"set -auth_keys flag or AUTH_KEYS env for production")
+	}
+	legacyUntil, err := auth.ParseLegacyCutoff(varprs.AuthLegacyUntil)
+	if err != nil {
+		log.Fatalf("Err while parsing legacy cookies cutoff, %v", err)
+	}
+	keyring.AcceptLegacyUntil(legacyUntil)
+	if time.Now().Before(legacyUntil) {
+		log.Printf("Legacy cookies are accepted and replaced with identity tokens until %s, set -auth_legacy_until flag or AUTH_LEGACY_UNTIL env to change it", legacyUntil.Format(time.RFC3339))
+	} else {
+		log.Printf("WARNING: legacy cookies aren't accepted, users with them get new IDs and lose access to their URLs")
+	}
+	// Password attempts are limited by one limiter for both HTTP and gRPC servers
+	passwordLimiter := handlers.NewPasswordLimiter(handlers.PasswordAttempts, handlers.PasswordAttemptsPerURL, handlers.PasswordAttemptsWindow)
+	currentServer := server.CreateServer(strg, deleteQueue, clickRecorder, keyring, passwordLimiter)
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	go handlers.NewHandlerWithStorage(strg, deleteQueue, nil).ExpiredURLsSweeper(sweeperCtx, varprs.SweepInterval)
+	if varprs.DeletedRetention > 0 {
+		go handlers.NewHandlerWithStorage(strg, deleteQueue, nil).DeletedURLsPurger(sweeperCtx, varprs.SweepInterval, varprs.DeletedRetention)
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	serverStoppedChan := make(chan struct{})
@@ -58,11 +100,13 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(handlers.UserIDInterceptor))
-	pb.RegisterShortenderServer(grpcServer, handlers.NewShortenderServer(strg, deleteChannel))
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(handlers.UserIDInterceptor(keyring, strg)), grpc.StreamInterceptor(handlers.UserIDStreamInterceptor(keyring, strg)))
+	grpcHandlers := handlers.NewShortenderServer(strg, deleteQueue, keyring)
+	grpcHandlers.SetPasswordLimiter(passwordLimiter)
+	pb.RegisterShortenderServer(grpcServer, grpcHandlers)
 	go func() {
 		<-sigChan
-		close(deleteChannel)
+		stopSweeper()
 		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 		if err := currentServer.Shutdown(ctx); err != nil {
 			log.Fatalf("Err while Shutdown, %v", err)
@@ -88,6 +132,13 @@ func main() {
 		}
 	}
 	<-serverStoppedChan
+	// Jobs which aren't processed in time stay in delete queue file until the next start
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), 30*time.Second)
+	if err := deleteQueue.Close(drainCtx); err != nil {
+		log.Printf("Err while closing delete queue, %v", err)
+	}
+	cancelDrain()
+	clickRecorder.Close()
 	if err := strg.Shutdown(); err != nil {
 		log.Fatalf("Err while Storage Shutdown, %v", err)
 	}
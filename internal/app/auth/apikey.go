@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+// APIKeyPrefix - prefix of API keys, it distinguishes them from identity tokens
+const APIKeyPrefix = "sk_"
+
+// apiKeyIDSize - size of API key public ID in bytes
+const apiKeyIDSize = 8
+
+// apiKeySecretSize - size of API key secret part in bytes
+const apiKeySecretSize = 32
+
+// Scopes of API keys
+const (
+	ScopeShorten = "shorten" // ScopeShorten - create and change short URLs
+	ScopeRead    = "read"    // ScopeRead - read short URLs with their stats and history
+	ScopeDelete  = "delete"  // ScopeDelete - delete and restore short URLs
+)
+
+// Scopes - all scopes of API keys
+var Scopes = []string{ScopeShorten, ScopeRead, ScopeDelete}
+
+// IsValidScope - check whether scope is one of Scopes
+func IsValidScope(scope string) bool {
+	for _, valid := range Scopes {
+		if scope == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// randomHex - get hex encoded random bytes
+func randomHex(size int) string {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		panic(err.Error())
+	}
+	return hex.EncodeToString(data)
+}
+
+// NewAPIKey - generate API key of sk_<ID>_<secret> format, returns its public ID and the key itself
+func NewAPIKey() (string, string) {
+	ID := randomHex(apiKeyIDSize)
+	return ID, APIKeyPrefix + ID + "_" + randomHex(apiKeySecretSize)
+}
+
+// ParseAPIKey - check API key format and return its public ID
+func ParseAPIKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return "", false
+	}
+	ID, secret, found := strings.Cut(strings.TrimPrefix(key, APIKeyPrefix), "_")
+	if !found || len(ID) != 2*apiKeyIDSize || len(secret) != 2*apiKeySecretSize {
+		return "", false
+	}
+	if _, err := hex.DecodeString(ID + secret); err != nil {
+		return "", false
+	}
+	return ID, true
+}
+
+// HashAPIKey - get hash of API key for storing, keys are random so a fast hash is enough
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken - get credentials from Authorization header value, "Bearer " prefix is optional
+func BearerToken(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > len("Bearer ") && strings.EqualFold(value[:len("Bearer ")], "Bearer ") {
+		value = strings.TrimSpace(value[len("Bearer "):])
+	}
+	return value
+}
+
+// WithScopes - get context of request authorized with API key limited to scopes
+func WithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, types.ScopesCtxName, scopes)
+}
+
+// IsAPIKeyContext - check whether request is authorized with API key
+func IsAPIKeyContext(ctx context.Context) bool {
+	_, ok := ctx.Value(types.ScopesCtxName).([]string)
+	return ok
+}
+
+// HasScope - check whether request is allowed to perform operation of scope.
+// Requests authorized with identity token are allowed everything.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(types.ScopesCtxName).([]string)
+	if !ok {
+		return true
+	}
+	for _, allowed := range scopes {
+		if allowed == scope {
+			return true
+		}
+	}
+	return false
+}
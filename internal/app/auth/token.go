@@ -0,0 +1,277 @@
+// Package auth contains signed user identity tokens for URLShortener service.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+// TokenVersion - version of identity token payload format
+const TokenVersion = 1
+
+// tokenPayloadSize - size of identity token payload: version, user ID, issued at and expires at
+const tokenPayloadSize = 1 + 8 + 8 + 8
+
+// MinKeySize - min size of signing key in bytes
+const MinKeySize = 16
+
+// DefaultKeyID - ID of signing key used if keyring isn't configured
+const DefaultKeyID = "default"
+
+// DefaultTokenTTL - default time for which identity token is valid
+const DefaultTokenTTL = 30 * 24 * time.Hour
+
+// Errors of identity token verification
+var (
+	ErrMalformedToken     = errors.New("malformed identity token")
+	ErrUnsupportedVersion = errors.New("unsupported identity token version")
+	ErrUnknownKey         = errors.New("identity token is signed with unknown key")
+	ErrBadSignature       = errors.New("identity token signature mismatch")
+	ErrExpiredToken       = errors.New("identity token is expired")
+)
+
+// Identity - verified content of identity token
+type Identity struct {
+	UserID    uint      // UserID - ID of user
+	KeyID     string    // KeyID - ID of key which signed the token
+	IssuedAt  time.Time // IssuedAt - time when token was issued
+	ExpiresAt time.Time // ExpiresAt - time after which token isn't accepted
+}
+
+// Keyring - signing keys by their IDs, tokens are issued with the current key and verified with any of them.
+// Key rotation is done by adding a new current key and keeping the previous one until its tokens are re-issued.
+// Legacy cookies are accepted only until cutoff set with AcceptLegacyUntil, so rotation retires legacy key as well.
+type Keyring struct {
+	// currentID - ID of key for new tokens
+	currentID string
+	// keys - keys by their IDs
+	keys map[string][]byte
+	// legacyUntil - time until which legacy cookies are accepted, they aren't accepted if it's zero
+	legacyUntil time.Time
+}
+
+// NewKeyring creates Keyring with given keys and current key ID.
+func NewKeyring(currentID string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[currentID]; !ok {
+		return nil, fmt.Errorf("current key %q isn't found in keyring", currentID)
+	}
+	for keyID, key := range keys {
+		if keyID == "" || strings.ContainsAny(keyID, ".:,") {
+			return nil, fmt.Errorf("key ID %q shouldn't be empty or contain '.', ':' or ','", keyID)
+		}
+		if len(key) < MinKeySize {
+			return nil, fmt.Errorf("key %q should be at least %d bytes", keyID, MinKeySize)
+		}
+		if hmac.Equal(key, types.CookieKey) {
+			return nil, fmt.Errorf("key %q is public legacy cookie key", keyID)
+		}
+	}
+	return &Keyring{currentID: currentID, keys: keys}, nil
+}
+
+// DefaultKeyring creates Keyring with public legacy cookie key only, it should be used for development only.
+func DefaultKeyring() *Keyring {
+	return &Keyring{currentID: DefaultKeyID, keys: map[string][]byte{DefaultKeyID: types.CookieKey}}
+}
+
+// ParseKeyring creates Keyring from comma separated keyID:key pairs, i.e. 2024-01:secret,2024-06:secret2.
+// Current key is the last one if currentID is empty. DefaultKeyring is returned for empty spec.
+func ParseKeyring(spec string, currentID string) (*Keyring, error) {
+	if spec == "" {
+		if currentID != "" && currentID != DefaultKeyID {
+			return nil, fmt.Errorf("current key %q is set without keys", currentID)
+		}
+		return DefaultKeyring(), nil
+	}
+	keys := make(map[string][]byte)
+	lastID := ""
+	for _, pair := range strings.Split(spec, ",") {
+		keyID, key, found := strings.Cut(strings.TrimSpace(pair), ":")
+		if !found {
+			return nil, fmt.Errorf("key %q should be set as keyID:key", keyID)
+		}
+		if _, ok := keys[keyID]; ok {
+			return nil, fmt.Errorf("key %q is set twice", keyID)
+		}
+		keys[keyID] = []byte(key)
+		lastID = keyID
+	}
+	if currentID == "" {
+		currentID = lastID
+	}
+	return NewKeyring(currentID, keys)
+}
+
+// DefaultLegacyCutoff - time until which legacy cookies are accepted if cutoff isn't configured,
+// so that their users are migrated to identity tokens keeping IDs during bounded period
+var DefaultLegacyCutoff = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+// LegacyCutoffOff - cutoff value which disables legacy cookies
+const LegacyCutoffOff = "off"
+
+// ParseLegacyCutoff parses time until which legacy cookies are accepted, i.e. 2024-06-01 or 2024-06-01T00:00:00Z.
+// DefaultLegacyCutoff is returned for empty value and zero time for LegacyCutoffOff, i.e. legacy cookies aren't accepted.
+func ParseLegacyCutoff(value string) (time.Time, error) {
+	if value == "" {
+		return DefaultLegacyCutoff, nil
+	}
+	if value == LegacyCutoffOff {
+		return time.Time{}, nil
+	}
+	if cutoff, err := time.Parse("2006-01-02", value); err == nil {
+		return cutoff, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// AcceptLegacyUntil allows legacy cookies until cutoff, zero cutoff disables them. Legacy cookie key is public,
+// so cookies of users with IDs below MinUserID can be forged while they are accepted: cutoff should be set
+// for migration period only.
+func (keyring *Keyring) AcceptLegacyUntil(cutoff time.Time) {
+	keyring.legacyUntil = cutoff
+}
+
+// CurrentKeyID - get ID of key for new tokens
+func (keyring *Keyring) CurrentKeyID() string {
+	return keyring.currentID
+}
+
+// KeyIDs - get sorted IDs of all keys in keyring
+func (keyring *Keyring) KeyIDs() []string {
+	keyIDs := make([]string, 0, len(keyring.keys))
+	for keyID := range keyring.keys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+	return keyIDs
+}
+
+// sign - get HMAC of signed part of token with key
+func sign(key []byte, signed string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(signed))
+	return h.Sum(nil)
+}
+
+// Issue creates identity token for user signed with the current key, token is valid for ttl since now.
+// Token is keyID.payload.signature, payload and signature are base64url encoded.
+func (keyring *Keyring) Issue(userID uint, now time.Time, ttl time.Duration) string {
+	payload := make([]byte, tokenPayloadSize)
+	payload[0] = TokenVersion
+	binary.BigEndian.PutUint64(payload[1:9], uint64(userID))
+	binary.BigEndian.PutUint64(payload[9:17], uint64(now.Unix()))
+	binary.BigEndian.PutUint64(payload[17:25], uint64(now.Add(ttl).Unix()))
+	signed := keyring.currentID + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sign(keyring.keys[keyring.currentID], signed))
+}
+
+// Verify checks identity token signature with its key from keyring and its expiration by now.
+func (keyring *Keyring) Verify(token string, now time.Time) (Identity, error) {
+	separator := strings.LastIndexByte(token, '.')
+	if separator < 0 {
+		return Identity{}, ErrMalformedToken
+	}
+	signed := token[:separator]
+	keyID, encodedPayload, found := strings.Cut(signed, ".")
+	if !found {
+		return Identity{}, ErrMalformedToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(token[separator+1:])
+	if err != nil {
+		return Identity{}, ErrMalformedToken
+	}
+	key, ok := keyring.keys[keyID]
+	if !ok {
+		return Identity{}, ErrUnknownKey
+	}
+	if !hmac.Equal(signature, sign(key, signed)) {
+		return Identity{}, ErrBadSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) == 0 {
+		return Identity{}, ErrMalformedToken
+	}
+	if payload[0] != TokenVersion {
+		return Identity{}, ErrUnsupportedVersion
+	}
+	if len(payload) != tokenPayloadSize {
+		return Identity{}, ErrMalformedToken
+	}
+	identity := Identity{
+		UserID:    uint(binary.BigEndian.Uint64(payload[1:9])),
+		KeyID:     keyID,
+		IssuedAt:  time.Unix(int64(binary.BigEndian.Uint64(payload[9:17])), 0),
+		ExpiresAt: time.Unix(int64(binary.BigEndian.Uint64(payload[17:25])), 0),
+	}
+	if !now.Before(identity.ExpiresAt) {
+		return Identity{}, ErrExpiredToken
+	}
+	return identity, nil
+}
+
+// NeedsReissue checks whether token should be replaced with a new one: it is signed with not current key
+// or more than half of its lifetime has passed, so active users never face expiration.
+func (keyring *Keyring) NeedsReissue(identity Identity, now time.Time) bool {
+	if identity.KeyID != keyring.currentID {
+		return true
+	}
+	return now.Sub(identity.IssuedAt) > identity.ExpiresAt.Sub(identity.IssuedAt)/2
+}
+
+// Authenticate verifies identity token or legacy cookie value and returns user ID of it and
+// whether it should be replaced with a new token. Legacy cookie is verified only before cutoff set with AcceptLegacyUntil.
+func (keyring *Keyring) Authenticate(value string, now time.Time) (uint, bool, error) {
+	identity, err := keyring.Verify(value, now)
+	if err == nil {
+		return identity.UserID, keyring.NeedsReissue(identity, now), nil
+	}
+	if now.Before(keyring.legacyUntil) {
+		if userID, ok := VerifyLegacy(value); ok {
+			return userID, true, nil
+		}
+	}
+	return 0, false, err
+}
+
+// VerifyLegacy checks cookie value of the format used before identity tokens: hex encoded 4 random bytes
+// followed by their HMAC with types.CookieKey. User ID of such cookie is built from its first 2 bytes.
+func VerifyLegacy(value string) (uint, bool) {
+	decoded, err := hex.DecodeString(value)
+	if err != nil || len(decoded) != 4+sha256.Size {
+		return 0, false
+	}
+	h := hmac.New(sha256.New, types.CookieKey)
+	h.Write(decoded[:4])
+	if !hmac.Equal(h.Sum(nil), decoded[4:]) {
+		return 0, false
+	}
+	return uint(binary.BigEndian.Uint16(decoded[:4])), true
+}
+
+// MinUserID - min ID of user created with identity tokens, lower IDs belong to users migrated from legacy cookies
+const MinUserID = 1 << 16
+
+// NewUserID generates random 63-bit user ID which doesn't collide with IDs of legacy cookies
+func NewUserID() uint {
+	data := make([]byte, 8)
+	for {
+		if _, err := rand.Read(data); err != nil {
+			panic(err.Error())
+		}
+		userID := binary.BigEndian.Uint64(data) >> 1
+		if userID >= MinUserID {
+			return uint(userID)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+func TestParseKeyring(t *testing.T) {
+	tests := []struct {
+		name              string
+		spec              string
+		currentID         string
+		expectedCurrentID string
+		expectedKeyIDs    []string
+		expectedErr       bool
+	}{
+		{"empty_spec", "", "", DefaultKeyID, []string{DefaultKeyID}, false},
+		{"last_key_is_current", "old:0123456789abcdef, new:fedcba9876543210", "", "new", []string{"new", "old"}, false},
+		{"explicit_current", "old:0123456789abcdef,new:fedcba9876543210", "old", "old", []string{"new", "old"}, false},
+		{"unknown_current", "old:0123456789abcdef", "new", "", nil, true},
+		{"current_without_keys", "", "new", "", nil, true},
+		{"missing_separator", "old", "", "", nil, true},
+		{"short_key", "old:short", "", "", nil, true},
+		{"duplicate_key", "old:0123456789abcdef,old:fedcba9876543210", "", "", nil, true},
+		{"dot_in_key_id", "o.ld:0123456789abcdef", "", "", nil, true},
+		{"legacy_key", "old:" + string(types.CookieKey), "", "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := ParseKeyring(tt.spec, tt.currentID)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCurrentID, keyring.CurrentKeyID())
+			assert.Equal(t, tt.expectedKeyIDs, keyring.KeyIDs())
+		})
+	}
+}
+
+func TestKeyring_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	oldKeyring, err := ParseKeyring("old:0123456789abcdef", "")
+	require.NoError(t, err)
+	keyring, err := ParseKeyring("old:0123456789abcdef,new:fedcba9876543210", "new")
+	require.NoError(t, err)
+	otherKeyring, err := ParseKeyring("old:another-secret-key", "")
+	require.NoError(t, err)
+	token := keyring.Issue(1<<40, now, time.Hour)
+	tests := []struct {
+		name             string
+		token            string
+		now              time.Time
+		expectedIdentity Identity
+		expectedErr      error
+	}{
+		{"current_key", token, now.Add(time.Minute), Identity{UserID: 1 << 40, KeyID: "new", IssuedAt: now, ExpiresAt: now.Add(time.Hour)}, nil},
+		{"previous_key", oldKeyring.Issue(5, now, time.Hour), now, Identity{UserID: 5, KeyID: "old", IssuedAt: now, ExpiresAt: now.Add(time.Hour)}, nil},
+		{"expired", token, now.Add(time.Hour), Identity{}, ErrExpiredToken},
+		{"removed_key", (&Keyring{currentID: "gone", keys: map[string][]byte{"gone": []byte("0123456789abcdef")}}).Issue(5, now, time.Hour), now, Identity{}, ErrUnknownKey},
+		{"other_secret", otherKeyring.Issue(5, now, time.Hour), now, Identity{}, ErrBadSignature},
+		{"tampered_payload", "new.B" + token[len("new.")+1:], now, Identity{}, ErrBadSignature},
+		{"no_separators", "token", now, Identity{}, ErrMalformedToken},
+		{"bad_signature_encoding", "new.AQ.!!", now, Identity{}, ErrMalformedToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := keyring.Verify(tt.token, tt.now)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedIdentity, identity)
+		})
+	}
+}
+
+func TestKeyring_NeedsReissue(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	keyring, err := ParseKeyring("old:0123456789abcdef,new:fedcba9876543210", "new")
+	require.NoError(t, err)
+	identity := Identity{UserID: 1, KeyID: "new", IssuedAt: now, ExpiresAt: now.Add(time.Hour)}
+	assert.False(t, keyring.NeedsReissue(identity, now.Add(time.Minute)))
+	assert.True(t, keyring.NeedsReissue(identity, now.Add(31*time.Minute)))
+	identity.KeyID = "old"
+	assert.True(t, keyring.NeedsReissue(identity, now.Add(time.Minute)))
+}
+
+func TestVerifyLegacy(t *testing.T) {
+	id := []byte{0x01, 0x02, 0x03, 0x04}
+	h := hmac.New(sha256.New, types.CookieKey)
+	h.Write(id)
+	value := hex.EncodeToString(append(id, h.Sum(nil)...))
+
+	userID, ok := VerifyLegacy(value)
+	assert.True(t, ok)
+	assert.Equal(t, uint(0x0102), userID)
+
+	_, ok = VerifyLegacy("ff" + value[2:])
+	assert.False(t, ok)
+	_, ok = VerifyLegacy("0102")
+	assert.False(t, ok)
+	_, ok = VerifyLegacy("not hex")
+	assert.False(t, ok)
+}
+
+func TestKeyring_AuthenticateLegacy(t *testing.T) {
+	id := []byte{0x01, 0x02, 0x03, 0x04}
+	h := hmac.New(sha256.New, types.CookieKey)
+	h.Write(id)
+	value := hex.EncodeToString(append(id, h.Sum(nil)...))
+	now := DefaultLegacyCutoff.Add(-24 * time.Hour)
+	tests := []struct {
+		name           string
+		cutoff         string
+		expectedUserID uint
+		expectedErr    bool
+	}{
+		{"default_cutoff", "", 0x0102, false},
+		{"not_accepted", LegacyCutoffOff, 0, true},
+		{"before_cutoff", now.Add(time.Hour).UTC().Format(time.RFC3339), 0x0102, false},
+		{"after_cutoff", now.Add(-time.Hour).UTC().Format(time.RFC3339), 0, true},
+		{"after_cutoff_date", "2020-01-01", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := ParseKeyring("new:fedcba9876543210", "")
+			require.NoError(t, err)
+			cutoff, err := ParseLegacyCutoff(tt.cutoff)
+			require.NoError(t, err)
+			keyring.AcceptLegacyUntil(cutoff)
+			userID, reissue, err := keyring.Authenticate(value, now)
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedUserID, userID)
+			assert.True(t, reissue)
+		})
+	}
+	_, err := ParseLegacyCutoff("tomorrow")
+	assert.Error(t, err)
+
+	keyring, err := ParseKeyring("new:fedcba9876543210", "")
+	require.NoError(t, err)
+	keyring.AcceptLegacyUntil(DefaultLegacyCutoff)
+	_, _, err = keyring.Authenticate(value, DefaultLegacyCutoff)
+	assert.Error(t, err)
+}
+
+func TestNewUserID(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		userID := NewUserID()
+		assert.GreaterOrEqual(t, userID, uint(MinUserID))
+		assert.Less(t, uint64(userID), uint64(1)<<63)
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	ID, key := NewAPIKey()
+	parsedID, ok := ParseAPIKey(key)
+	assert.True(t, ok)
+	assert.Equal(t, ID, parsedID)
+	assert.NotEqual(t, HashAPIKey(key), HashAPIKey(key+"0"))
+
+	for _, malformed := range []string{"", "sk_", key[len(APIKeyPrefix):], key + "0", "sk_zz" + key[5:], "old.payload.signature"} {
+		_, ok = ParseAPIKey(malformed)
+		assert.False(t, ok, malformed)
+	}
+}
@@ -1,29 +1,76 @@
 package db
 
 import (
-	"log"
+	"database/sql"
+	"embed"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
-// RunMigrations - apply all needed migrations to the db
+// SQLiteScheme - DSN prefix for SQLite database, i.e. sqlite://shortener.db
+const SQLiteScheme = "sqlite://"
+
+// migrations - migrations for PostgreSQL database embedded into binary
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// sqliteMigrations - migrations for SQLite database embedded into binary
+//
+//go:embed sqlite_migrations/*.sql
+var sqliteMigrations embed.FS
+
+// IsSQLiteDSN - check that given DSN points to SQLite database
+func IsSQLiteDSN(dbDSN string) bool {
+	return strings.HasPrefix(dbDSN, SQLiteScheme)
+}
+
+// SQLitePath - get SQLite database path from DSN
+func SQLitePath(dbDSN string) string {
+	return strings.TrimPrefix(dbDSN, SQLiteScheme)
+}
+
+// RunMigrations - apply all embedded migrations to the db
 func RunMigrations(dbDSN string) error {
-	if dbDSN == "" {
+	if dbDSN == "" || IsSQLiteDSN(dbDSN) {
 		return nil
 	}
-	m, err := migrate.New(
-		"file://internal/app/db/migrations",
-		dbDSN)
+	source, err := iofs.New(migrations, "migrations")
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", source, dbDSN)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return nil
+}
+
+// RunSQLiteMigrations - apply all embedded migrations to the SQLite db
+func RunSQLiteMigrations(database *sql.DB) error {
+	source, err := iofs.New(sqliteMigrations, "sqlite_migrations")
+	if err != nil {
+		return err
+	}
+	driver, err := sqlite3.WithInstance(database, &sqlite3.Config{})
+	if err != nil {
+		return err
+	}
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite3", driver)
 	if err != nil {
-		log.Fatal(err)
 		return err
 	}
 	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal(err)
 		return err
 	}
 	return nil
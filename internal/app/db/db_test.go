@@ -1,5 +1,17 @@
 package db
 
+import (
+	"database/sql"
+	"embed"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
 //func TestCreateDB(t *testing.T) {
 //	type args struct {
 //		dbDSN string
@@ -25,3 +37,64 @@ package db
 //		})
 //	}
 //}
+
+func TestRunSQLiteMigrations_URLCreatedAtDefault(t *testing.T) {
+	database, err := sql.Open("sqlite3", t.TempDir()+"/shortener.db")
+	require.NoError(t, err)
+	defer database.Close()
+	database.SetMaxOpenConns(1)
+
+	source, err := iofs.New(sqliteMigrations, "sqlite_migrations")
+	require.NoError(t, err)
+	driver, err := sqlite3.WithInstance(database, &sqlite3.Config{})
+	require.NoError(t, err)
+	m, err := migrate.NewWithInstance("iofs", source, "sqlite3", driver)
+	require.NoError(t, err)
+	require.NoError(t, m.Migrate(11))
+	_, err = database.Exec("INSERT INTO url (value, alias) VALUES ('aaa', 'a'), ('bbb', NULL), ('ccc', NULL)")
+	require.NoError(t, err)
+	_, err = database.Exec("DELETE FROM url WHERE id = 3")
+	require.NoError(t, err)
+	_, err = database.Exec("INSERT INTO user_url (user_id, url_id) VALUES (1, 1), (1, 2)")
+	require.NoError(t, err)
+
+	require.NoError(t, RunSQLiteMigrations(database))
+	_, err = database.Exec("PRAGMA foreign_keys = ON")
+	require.NoError(t, err)
+
+	var createdAt sql.NullTime
+	require.NoError(t, database.QueryRow("SELECT created_at FROM url WHERE id = 1").Scan(&createdAt))
+	assert.False(t, createdAt.Valid)
+	var ID uint
+	require.NoError(t, database.QueryRow("INSERT INTO url (value) VALUES ('ddd') RETURNING id, created_at").Scan(&ID, &createdAt))
+	assert.Equal(t, uint(4), ID)
+	assert.True(t, createdAt.Valid)
+	_, err = database.Exec("INSERT INTO url (value, alias) VALUES ('eee', 'a')")
+	assert.Error(t, err)
+	_, err = database.Exec("DELETE FROM url WHERE id = 1")
+	assert.Error(t, err)
+	rows, err := database.Query("PRAGMA foreign_key_check")
+	require.NoError(t, err)
+	defer rows.Close()
+	assert.False(t, rows.Next())
+}
+
+func TestMigrations_Embedded(t *testing.T) {
+	tests := []struct {
+		name  string
+		files embed.FS
+	}{
+		{"migrations", migrations},
+		{"sqlite_migrations", sqliteMigrations},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source, err := iofs.New(tt.files, tt.name)
+			require.NoError(t, err)
+			defer source.Close()
+			version, err := source.First()
+			require.NoError(t, err)
+			assert.Equal(t, uint(1), version)
+		})
+	}
+}
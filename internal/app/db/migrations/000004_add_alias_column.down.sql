@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS unique_alias;
+ALTER TABLE url DROP COLUMN IF EXISTS alias;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE url ADD expires_at timestamptz;
\ No newline at end of file
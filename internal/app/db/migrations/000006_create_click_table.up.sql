@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS click
+(
+    id serial PRIMARY KEY,
+    url_id int NOT NULL,
+    clicked_at timestamptz NOT NULL,
+    referrer text NOT NULL DEFAULT '',
+    user_agent text NOT NULL DEFAULT '',
+    client_subnet varchar(64) NOT NULL DEFAULT '',
+    FOREIGN KEY (url_id) references url(id)
+);
+CREATE INDEX IF NOT EXISTS click_url_id ON click(url_id, clicked_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS url_deleted_at;
+DROP TABLE IF EXISTS purged_url;
\ No newline at end of file
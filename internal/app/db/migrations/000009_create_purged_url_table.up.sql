@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS purged_url
+(
+    id int PRIMARY KEY,
+    purged_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS url_deleted_at ON url(deleted_at) WHERE deleted = true;
\ No newline at end of file
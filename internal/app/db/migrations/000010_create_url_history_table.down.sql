@@ -0,0 +1 @@
+DROP TABLE IF EXISTS url_history;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS url_history
+(
+    id serial PRIMARY KEY,
+    url_id int NOT NULL,
+    old_value text NOT NULL,
+    new_value text NOT NULL,
+    user_id int NOT NULL,
+    changed_at timestamptz NOT NULL,
+    FOREIGN KEY (url_id) references url(id)
+);
+CREATE INDEX IF NOT EXISTS url_history_url_id ON url_history(url_id, id);
\ No newline at end of file
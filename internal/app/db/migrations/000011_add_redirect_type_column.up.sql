@@ -0,0 +1 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS redirect_type int NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE url DROP COLUMN IF EXISTS created_at;
+ALTER TABLE url DROP COLUMN IF EXISTS require_interstitial;
+ALTER TABLE url DROP COLUMN IF EXISTS title;
\ No newline at end of file
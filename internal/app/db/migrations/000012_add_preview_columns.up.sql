@@ -0,0 +1,4 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS title text NOT NULL DEFAULT '';
+ALTER TABLE url ADD COLUMN IF NOT EXISTS require_interstitial boolean NOT NULL DEFAULT false;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS created_at timestamptz;
+ALTER TABLE url ALTER COLUMN created_at SET DEFAULT now();
\ No newline at end of file
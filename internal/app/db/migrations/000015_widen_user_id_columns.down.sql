@@ -0,0 +1,2 @@
+ALTER TABLE user_url ALTER COLUMN user_id TYPE int;
+ALTER TABLE url_history ALTER COLUMN user_id TYPE int;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE user_url ALTER COLUMN user_id TYPE bigint;
+ALTER TABLE url_history ALTER COLUMN user_id TYPE bigint;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_key
+(
+    id text PRIMARY KEY,
+    user_id bigint NOT NULL,
+    name text NOT NULL DEFAULT '',
+    hash text NOT NULL UNIQUE,
+    scopes text NOT NULL,
+    created_at timestamptz NOT NULL,
+    revoked_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS api_key_user_id ON api_key(user_id, created_at);
\ No newline at end of file
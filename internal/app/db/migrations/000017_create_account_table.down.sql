@@ -0,0 +1 @@
+DROP TABLE IF EXISTS account;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS account
+(
+    id bigint PRIMARY KEY,
+    username text NOT NULL UNIQUE,
+    password_hash text NOT NULL,
+    created_at timestamptz NOT NULL
+);
\ No newline at end of file
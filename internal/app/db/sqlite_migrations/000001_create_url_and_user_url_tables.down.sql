@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_url;
+DROP TABLE IF EXISTS url;
\ No newline at end of file
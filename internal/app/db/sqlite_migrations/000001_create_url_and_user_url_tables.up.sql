@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS url
+(
+    id integer PRIMARY KEY AUTOINCREMENT,
+    value varchar(100)  NOT NULL
+);
+CREATE TABLE IF NOT EXISTS user_url
+(
+    id integer PRIMARY KEY AUTOINCREMENT,
+    user_id int NOT NULL,
+    url_id int  NOT NULL,
+    FOREIGN KEY (url_id) references url(id)
+);
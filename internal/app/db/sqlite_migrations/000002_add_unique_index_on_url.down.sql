@@ -0,0 +1 @@
+DROP INDEX IF EXISTS unique_url;
\ No newline at end of file
@@ -0,0 +1 @@
+CREATE UNIQUE INDEX IF NOT EXISTS unique_url ON url(value);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE url DROP COLUMN deleted;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE url ADD deleted bool not null default false;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE url ADD alias varchar(64);
+CREATE UNIQUE INDEX IF NOT EXISTS unique_alias ON url(alias);
\ No newline at end of file
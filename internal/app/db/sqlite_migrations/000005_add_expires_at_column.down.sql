@@ -0,0 +1 @@
+ALTER TABLE url DROP COLUMN expires_at;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS click;
\ No newline at end of file
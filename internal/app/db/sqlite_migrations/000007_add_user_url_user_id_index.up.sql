@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS user_url_user_id ON user_url(user_id, url_id);
@@ -0,0 +1 @@
+ALTER TABLE url ADD deleted_at TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE url ADD redirect_type int NOT NULL DEFAULT 0;
\ No newline at end of file
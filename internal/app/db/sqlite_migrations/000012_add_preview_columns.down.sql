@@ -0,0 +1,3 @@
+ALTER TABLE url DROP COLUMN created_at;
+ALTER TABLE url DROP COLUMN require_interstitial;
+ALTER TABLE url DROP COLUMN title;
\ No newline at end of file
@@ -0,0 +1,22 @@
+-- SQLite can't add column with CURRENT_TIMESTAMP default to filled table, so url table is rebuilt
+CREATE TABLE url_new
+(
+    id integer PRIMARY KEY AUTOINCREMENT,
+    value varchar(100)  NOT NULL,
+    deleted bool not null default false,
+    alias varchar(64),
+    expires_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    redirect_type int NOT NULL DEFAULT 0,
+    title text NOT NULL DEFAULT '',
+    require_interstitial boolean NOT NULL DEFAULT false,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO url_new (id, value, deleted, alias, expires_at, deleted_at, redirect_type, created_at)
+SELECT id, value, deleted, alias, expires_at, deleted_at, redirect_type, NULL FROM url;
+UPDATE sqlite_sequence SET seq = (SELECT seq FROM sqlite_sequence WHERE name = 'url') WHERE name = 'url_new';
+DROP TABLE url;
+ALTER TABLE url_new RENAME TO url;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_url ON url(value);
+CREATE UNIQUE INDEX IF NOT EXISTS unique_alias ON url(alias);
+CREATE INDEX IF NOT EXISTS url_deleted_at ON url(deleted_at) WHERE deleted = true;
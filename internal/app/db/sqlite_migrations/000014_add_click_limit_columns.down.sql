@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN used_clicks;
+ALTER TABLE url DROP COLUMN max_clicks;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE url ADD max_clicks int NOT NULL DEFAULT 0;
+ALTER TABLE url ADD used_clicks int NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- SQLite INTEGER columns already store 64-bit user IDs.
+SELECT 1;
\ No newline at end of file
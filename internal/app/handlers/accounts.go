@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
+	"github.com/tank4gun/gourlshortener/internal/app/storage"
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+// MinUsernameLength - min length of account username
+const MinUsernameLength = 3
+
+// MaxUsernameLength - max length of account username
+const MaxUsernameLength = 64
+
+// MinAccountPasswordLength - min length of account password in bytes
+const MinAccountPasswordLength = 8
+
+// usernamePattern - allowed chars of normalized username
+var usernamePattern = regexp.MustCompile(`^[a-z0-9_.-]+$`)
+
+var (
+	dummyHash     string    // dummyHash - hash compared with password of unknown username
+	dummyHashOnce sync.Once // dummyHashOnce - guards dummyHash creation
+)
+
+// dummyPasswordHash - get hash for comparison with password of unknown username, it is created on the first use
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = storage.HashPassword("dummy-password")
+	})
+	return dummyHash
+}
+
+// NormalizeUsername - trim spaces and lowercase username, so usernames are case insensitive
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// ValidateAccountRequest checks username format and password length, returns normalized username
+func ValidateAccountRequest(request types.AccountRequest) (username string, errorMessage string, errorCode int) {
+	username = NormalizeUsername(request.Username)
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return "", fmt.Sprintf("Username length should be from %d to %d", MinUsernameLength, MaxUsernameLength), http.StatusBadRequest
+	}
+	if !usernamePattern.MatchString(username) {
+		return "", "Username should contain only latin letters, digits, '_', '.' and '-'", http.StatusBadRequest
+	}
+	if len(request.Password) < MinAccountPasswordLength || len(request.Password) > storage.MaxPasswordLength {
+		return "", fmt.Sprintf("Password length should be from %d to %d", MinAccountPasswordLength, storage.MaxPasswordLength), http.StatusBadRequest
+	}
+	return username, "", 0
+}
+
+// SetIdentityCookie - issue identity token for user and set it as cookie
+func SetIdentityCookie(w http.ResponseWriter, keyring *auth.Keyring, userID uint, now time.Time, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     types.URLShortenderCookieName,
+		Value:    keyring.Issue(userID, now, ttl),
+		Path:     "/",
+		Expires:  now.Add(ttl),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SetKeyring sets keys and lifetime of identity tokens issued on registration and login
+func (strg *HandlerWithStorage) SetKeyring(keyring *auth.Keyring, tokenTTL time.Duration) {
+	strg.keyring = keyring
+	strg.tokenTTL = tokenTTL
+}
+
+// readAccountRequest - read account request from JSON body
+func readAccountRequest(r *http.Request) (types.AccountRequest, error) {
+	var request types.AccountRequest
+	defer r.Body.Close()
+	jsonBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, err
+	}
+	err = json.Unmarshal(jsonBody, &request)
+	return request, err
+}
+
+// writeAccountResponse - set identity cookie of account and write response
+func (strg *HandlerWithStorage) writeAccountResponse(w http.ResponseWriter, response types.AccountResponse, userID uint, status int) {
+	responseMarshalled, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	SetIdentityCookie(w, strg.keyring, userID, time.Now(), strg.tokenTTL)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(responseMarshalled)
+}
+
+// RegisterHandler creates account, moves URLs of anonymous User to it and sets identity cookie of the account
+func (strg *HandlerWithStorage) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	request, err := readAccountRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, accountID, errorMessage, errorCode := CommonServer{}.Register(strg.storage, request, userID)
+	if errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	strg.writeAccountResponse(w, response, accountID, http.StatusCreated)
+}
+
+// LoginHandler verifies account password, moves URLs of anonymous User to the account and sets its identity cookie
+func (strg *HandlerWithStorage) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	request, err := readAccountRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, accountID, retryAfter, errorMessage, errorCode := CommonServer{}.Login(strg.storage, strg.loginLimiter, request, userID, ClientIP(r))
+	if errorCode != 0 {
+		if errorCode == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	strg.writeAccountResponse(w, response, accountID, http.StatusOK)
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
+	"github.com/tank4gun/gourlshortener/internal/app/storage"
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+// ValidateAPIKeyRequest checks API key name length and scopes, returns unique scopes in auth.Scopes order
+func ValidateAPIKeyRequest(request types.APIKeyRequest) (scopes []string, errorMessage string, errorCode int) {
+	if len(request.Name) > storage.MaxAPIKeyNameLength {
+		return nil, fmt.Sprintf("Name length should be at most %d", storage.MaxAPIKeyNameLength), http.StatusBadRequest
+	}
+	if len(request.Scopes) == 0 {
+		return nil, "At least one scope should be set", http.StatusBadRequest
+	}
+	requested := make(map[string]bool)
+	for _, scope := range request.Scopes {
+		if !auth.IsValidScope(scope) {
+			return nil, fmt.Sprintf("Unknown scope %q, should be one of %v", scope, auth.Scopes), http.StatusBadRequest
+		}
+		requested[scope] = true
+	}
+	for _, scope := range auth.Scopes {
+		if requested[scope] {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, "", 0
+}
+
+// newAPIKeyResponse - convert API key into response without the key itself
+func newAPIKeyResponse(key storage.APIKey) types.APIKeyResponse {
+	return types.APIKeyResponse{ID: key.ID, Name: key.Name, Scopes: key.Scopes, CreatedAt: key.CreatedAt, RevokedAt: key.RevokedAt}
+}
+
+// CreateAPIKeyHandler creates API key for given User, the key itself is returned only in this response
+func (strg *HandlerWithStorage) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	defer r.Body.Close()
+	jsonBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var request types.APIKeyRequest
+	if err = json.Unmarshal(jsonBody, &request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response, errorMessage, errorCode := CommonServer{}.CreateAPIKey(strg.storage, request, userID)
+	if errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	responseMarshalled, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(responseMarshalled)
+}
+
+// GetAPIKeysHandler returns API keys of given User including revoked ones
+func (strg *HandlerWithStorage) GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	responseList, errorCode := CommonServer{}.GetAPIKeys(strg.storage, userID)
+	if errorCode == http.StatusNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if errorCode != http.StatusOK {
+		http.Error(w, "Got error while getting API keys for user", errorCode)
+		return
+	}
+	responseMarshalled, err := json.Marshal(responseList)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseMarshalled)
+}
+
+// RevokeAPIKeyHandler revokes API key of given User by its ID, requests with the key are rejected after it
+func (strg *HandlerWithStorage) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	errorCode := CommonServer{}.RevokeAPIKey(strg.storage, chi.URLParam(r, "id"), userID)
+	if errorCode == http.StatusNotFound {
+		http.Error(w, "API key not found", http.StatusNotFound)
+		return
+	}
+	if errorCode != 0 {
+		http.Error(w, "Got error while revoking API key", errorCode)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
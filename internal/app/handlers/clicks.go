@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/storage"
+)
+
+// ClickBufferSize - number of clicks which could wait for recording, clicks above it are dropped
+const ClickBufferSize = 1024
+
+// ClickBatchSize - max number of clicks saved into storage at once
+const ClickBatchSize = 100
+
+// ClickFlushInterval - max time for click to wait for recording
+const ClickFlushInterval = time.Second
+
+// ClickRecorder - buffered asynchronous recorder of clicks, keeps redirects independent of storage latency
+type ClickRecorder struct {
+	// storage - storage.IRepository implementation
+	storage storage.IRepository
+	// clicks - channel with clicks to record
+	clicks chan storage.Click
+	// done - channel which is closed after all clicks are recorded
+	done chan struct{}
+}
+
+// NewClickRecorder creates ClickRecorder for given storage, Run should be called to start recording.
+func NewClickRecorder(storageVal storage.IRepository) *ClickRecorder {
+	return &ClickRecorder{storage: storageVal, clicks: make(chan storage.Click, ClickBufferSize), done: make(chan struct{})}
+}
+
+// Record enqueues click without blocking, click is dropped if buffer is full. Nil ClickRecorder ignores clicks.
+func (recorder *ClickRecorder) Record(click storage.Click) {
+	if recorder == nil {
+		return
+	}
+	select {
+	case recorder.clicks <- click:
+	default:
+		log.Printf("Click buffer is full, drop click for %d", click.URLID)
+	}
+}
+
+// Run saves clicks into storage by batches until Close is called.
+func (recorder *ClickRecorder) Run() {
+	defer close(recorder.done)
+	ticker := time.NewTicker(ClickFlushInterval)
+	defer ticker.Stop()
+	batch := make([]storage.Click, 0, ClickBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := recorder.storage.InsertClicks(batch); err != nil {
+			log.Printf("Couldn't record %d clicks, %s", len(batch), err.Error())
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case click, ok := <-recorder.clicks:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) == ClickBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Close stops accepting clicks and waits until already accepted ones are recorded.
+func (recorder *ClickRecorder) Close() {
+	close(recorder.clicks)
+	<-recorder.done
+}
+
+// ClientSubnet returns subnet of request client IP, /24 for IPv4 and /48 for IPv6, so that full address isn't stored
+func ClientSubnet(r *http.Request) string {
+	ip := net.ParseIP(ClientIP(r))
+	if ip == nil {
+		return ""
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return (&net.IPNet{IP: ipv4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
@@ -1,6 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
 	"github.com/tank4gun/gourlshortener/internal/app/storage"
 	"github.com/tank4gun/gourlshortener/internal/app/types"
 )
@@ -8,47 +15,226 @@ import (
 // ICommonServer interface is used as facade
 type ICommonServer interface {
 	CreateShortURL(storage storage.IRepository, URL string, userID uint, baseURL string) (shortURL string, errorMessage string, errorCode int)                                             // CreateShortURL - converts URL to shorten one and saves into storage
-	GetURLByID(storage storage.IRepository, shortURL string, userID uint) (originalURL string, errorCode int)                                                                              // GetURLByID - returns full URL by its ID if it exists in storage
+	CreateShortURLWithOptions(storage storage.IRepository, URL string, options storage.URLOptions, userID uint, baseURL string) (shortURL string, errorMessage string, errorCode int)      // CreateShortURLWithOptions - converts URL to shorten one with given options and saves into storage
+	GetURLByID(storage storage.IRepository, shortURL string, userID uint) (id uint, url storage.URL, errorCode int)                                                                        // GetURLByID - returns URL and its ID if it exists in storage
+	CheckURLPassword(limiter *PasswordLimiter, id uint, url storage.URL, client string, password string) (retryAfter time.Duration, errorMessage string, errorCode int)                    // CheckURLPassword - verifies password of protected URL with attempts limited per URL and client
+	ConsumeClick(storage storage.IRepository, id uint, url storage.URL) (errorMessage string, errorCode int)                                                                               // ConsumeClick - counts click of URL limited with max clicks in storage
 	CreateShortenURLBatch(storage storage.IRepository, batchRequest []storage.BatchURLRequest, baseURL string) (resultURLs []storage.BatchURLResponse, errorMessage string, errorCode int) // CreateShortenURLBatch - converts URL batch to shorten one and saves into storage
-	GetAllURLs(storage storage.IRepository, userID uint, baseURL string) (responseList []storage.FullInfoURLResponse, errorCode int)                                                       // GetAllURLs - return all URLs for given User from storage
-	DeleteURLs(deleteChannel chan types.RequestToDelete, URLsToDelete []string, userID uint)                                                                                               // DeleteURLs - removes all URLs for given User from storage
+	GetURLsPage(storage storage.IRepository, userID uint, query storage.URLsPageQuery, baseURL string) (responseList []storage.FullInfoURLResponse, nextCursor string, errorCode int)      // GetURLsPage - return page of URLs for given User from storage with cursor for the next page
+	ExportURLs(storage storage.IRepository, userID uint, baseURL string, yield func(url storage.ExportedURL) error) error                                                                  // ExportURLs - pass all URLs for given User from storage to yield one by one
+	ImportURLs(repository storage.IRepository, URLs []string, userID uint, baseURL string) (results []types.ImportRowResult, errorMessage string, errorCode int)                           // ImportURLs - insert URLs chunk for given User into storage, duplicates are reported with existing short URLs
+	DeleteURLs(deleteQueue *DeleteQueue, URLsToDelete []string, userID uint) (job types.DeleteJobResponse, errorMessage string, errorCode int)                                             // DeleteURLs - saves request to remove URLs for given User into deletion queue
+	GetDeleteJob(deleteQueue *DeleteQueue, ID string, userID uint) (job types.DeleteJobResponse, errorCode int)                                                                            // GetDeleteJob - gets status of deletion job by its ID for given User
+	GetDeletedURLs(storage storage.IRepository, userID uint, baseURL string) (responseList []storage.DeletedURLResponse, errorCode int)                                                    // GetDeletedURLs - return deleted URLs for given User from storage
+	RestoreURLs(storage storage.IRepository, URLsToRestore []string, userID uint) (restored int, errorMessage string, errorCode int)                                                       // RestoreURLs - restores deleted URLs for given User in storage
 	Ping(storage storage.IRepository) error                                                                                                                                                // Ping - checks than connection to storage is alive
+	UpdateURL(storage storage.IRepository, shortURL string, URL string, userID uint, baseURL string) (response storage.FullInfoURLResponse, errorMessage string, errorCode int)            // UpdateURL - changes original URL of short URL owned by User in storage
+	GetURLHistory(storage storage.IRepository, shortURL string, userID uint) (history []storage.URLChange, errorCode int)                                                                  // GetURLHistory - return changes of original URL of short URL owned by User from storage
+	GetURLStats(storage storage.IRepository, shortURL string, userID uint) (stats storage.ClickStatsResponse, errorCode int)                                                               // GetURLStats - return click statistics for URL owned by User from storage
 	GetStats(storage storage.IRepository) (stats storage.StatsResponse, errorCode int)                                                                                                     // GetStats - gets statistics, return all URLs and Users number from storage
+	CreateAPIKey(repository storage.IRepository, request types.APIKeyRequest, userID uint) (response types.APIKeyResponse, errorMessage string, errorCode int)                             // CreateAPIKey - generates API key for given User and saves its hash into storage
+	GetAPIKeys(repository storage.IRepository, userID uint) (responseList []types.APIKeyResponse, errorCode int)                                                                           // GetAPIKeys - return API keys of given User from storage
+	RevokeAPIKey(repository storage.IRepository, ID string, userID uint) (errorCode int)                                                                                                   // RevokeAPIKey - revokes API key of given User in storage
+	AuthenticateAPIKey(repository storage.IRepository, key string) (apiKey storage.APIKey, errorCode int)                                                                                  // AuthenticateAPIKey - finds not revoked API key in storage
+
+	Register(repository storage.IRepository, request types.AccountRequest, currentUserID uint) (response types.AccountResponse, userID uint, errorMessage string, errorCode int)                                                                 // Register - creates account and moves URLs of anonymous User to it in storage
+	Login(repository storage.IRepository, limiter *PasswordLimiter, request types.AccountRequest, currentUserID uint, client string) (response types.AccountResponse, userID uint, retryAfter time.Duration, errorMessage string, errorCode int) // Login - verifies account password and moves URLs of anonymous User to it in storage
 }
 
 // CommonServer - implementation for ICommonServer
 type CommonServer struct{}
 
-// CreateShortURL - converts URL to shorten one and saves into storage
+// CreateShortURL - converts URL to shorten one and saves into storage.
+// Empty shortURL is returned with http.StatusConflict if URL is already shortened with password or clicks limit.
 func (server CommonServer) CreateShortURL(storage storage.IRepository, URL string, userID uint, baseURL string) (shortURL string, errorMessage string, errorCode int) {
 	shortURL, errorMessage, errorCode = storage.CreateShortURLByURL(URL, userID)
+	if shortURL == "" {
+		return "", errorMessage, errorCode
+	}
+	return baseURL + shortURL, errorMessage, errorCode
+}
+
+// CreateShortURLWithOptions - converts URL to shorten one with given options and saves into storage.
+// Empty shortURL is returned with http.StatusConflict if custom alias is already taken or URL is already shortened
+// with other password protection or clicks limit.
+func (server CommonServer) CreateShortURLWithOptions(storage storage.IRepository, URL string, options storage.URLOptions, userID uint, baseURL string) (shortURL string, errorMessage string, errorCode int) {
+	if options.Alias != "" {
+		if errorMessage, errorCode = ValidateCustomAlias(options.Alias); errorCode != 0 {
+			return "", errorMessage, errorCode
+		}
+	}
+	if errorMessage, errorCode = ValidateRedirectType(options.RedirectType); errorCode != 0 {
+		return "", errorMessage, errorCode
+	}
+	if errorMessage, errorCode = ValidateTitle(options.Title); errorCode != 0 {
+		return "", errorMessage, errorCode
+	}
+	if errorMessage, errorCode = ValidateMaxClicks(options.MaxClicks); errorCode != 0 {
+		return "", errorMessage, errorCode
+	}
+	shortURL, errorMessage, errorCode = storage.CreateShortURLWithOptions(URL, options, userID)
+	if shortURL == "" {
+		return "", errorMessage, errorCode
+	}
 	return baseURL + shortURL, errorMessage, errorCode
 }
 
-// GetURLByID - returns full URL by its ID if it exists in storage
-func (server CommonServer) GetURLByID(storage storage.IRepository, shortURL string, userID uint) (originalURL string, errorCode int) {
-	id := ConvertShortURLToID(shortURL)
-	originalURL, errorCode = storage.GetValueByKeyAndUserID(id, userID)
-	return originalURL, errorCode
+// GetURLByID - returns URL and its ID by ID based short URL or custom alias if it exists in storage
+func (server CommonServer) GetURLByID(storage storage.IRepository, shortURL string, userID uint) (id uint, url storage.URL, errorCode int) {
+	id = ResolveShortURL(storage, shortURL)
+	url, errorCode = storage.GetURLByKeyAndUserID(id, userID)
+	return id, url, errorCode
+}
+
+// CheckURLPassword - verifies password of protected URL, nothing is checked for not protected one.
+// http.StatusUnauthorized is returned for missing or wrong password and http.StatusTooManyRequests with retryAfter
+// if client has exhausted its attempts for URL. Missing password isn't counted as attempt.
+func (server CommonServer) CheckURLPassword(limiter *PasswordLimiter, id uint, url storage.URL, client string, password string) (retryAfter time.Duration, errorMessage string, errorCode int) {
+	if !url.IsProtected() {
+		return 0, "", 0
+	}
+	if password == "" {
+		return 0, "Password is required", http.StatusUnauthorized
+	}
+	retryAfter, ok := limiter.Attempt(URLPasswordTarget(id), client, time.Now())
+	if !ok {
+		return retryAfter, "Too many password attempts, try again later", http.StatusTooManyRequests
+	}
+	if !url.CheckPassword(password) {
+		return 0, "Wrong password", http.StatusUnauthorized
+	}
+	limiter.Reset(URLPasswordTarget(id), client)
+	return 0, "", 0
+}
+
+// ConsumeClick - counts click of URL limited with max clicks in storage, storage isn't touched for not limited URL.
+// http.StatusGone is returned if URL has no clicks left, i.e. they were consumed by concurrent requests.
+func (server CommonServer) ConsumeClick(storage storage.IRepository, id uint, url storage.URL) (errorMessage string, errorCode int) {
+	if url.MaxClicks == 0 {
+		return "", 0
+	}
+	if errorCode = storage.ConsumeClick(id); errorCode == http.StatusGone {
+		return "URL has no clicks left", errorCode
+	} else if errorCode != 0 {
+		return "Couldn't count click", errorCode
+	}
+	return "", 0
 }
 
 // CreateShortenURLBatch - converts URL batch to shorten one and saves into storage
 func (server CommonServer) CreateShortenURLBatch(storage storage.IRepository, batchRequest []storage.BatchURLRequest, userID uint, baseURL string) (resultURLs []storage.BatchURLResponse, errorMessage string, errorCode int) {
+	for _, URLRequest := range batchRequest {
+		if errorMessage, errorCode = ValidateExpiration(URLRequest.ExpiresAt, URLRequest.TTLSeconds); errorCode != 0 {
+			return nil, errorMessage, errorCode
+		}
+	}
 	resultURLs, errorMessage, errorCode = storage.CreateShortURLBatch(batchRequest, userID, baseURL)
 	return resultURLs, errorMessage, errorCode
 }
 
-// GetAllURLs - return all URLs for given User from storage
-func (server CommonServer) GetAllURLs(storage storage.IRepository, userID uint, baseURL string) (responseList []storage.FullInfoURLResponse, errorCode int) {
-	responseList, errorCode = storage.GetAllURLsByUserID(userID, baseURL)
+// GetURLsPage - return page of URLs for given User from storage with cursor for the next page, empty if it is the last page
+func (server CommonServer) GetURLsPage(storage storage.IRepository, userID uint, query storage.URLsPageQuery, baseURL string) (responseList []storage.FullInfoURLResponse, nextCursor string, errorCode int) {
+	page, errorCode := storage.GetURLsPageByUserID(userID, query, baseURL)
+	if page.NextAfter != 0 {
+		nextCursor = EncodeCursor(page.NextAfter)
+	}
+	return page.URLs, nextCursor, errorCode
+}
+
+// ExportURLs - pass all URLs for given User from storage to yield one by one
+func (server CommonServer) ExportURLs(storage storage.IRepository, userID uint, baseURL string, yield func(url storage.ExportedURL) error) error {
+	return storage.IterateURLsByUserID(userID, baseURL, yield)
+}
+
+// ImportURLs - insert URLs chunk for given User into storage with CreateShortURLBatch, so IDs are allocated by storage itself.
+// URLs which are already stored or repeat earlier chunk items are reported as duplicates. Results are returned in the same order as URLs.
+func (server CommonServer) ImportURLs(repository storage.IRepository, URLs []string, userID uint, baseURL string) (results []types.ImportRowResult, errorMessage string, errorCode int) {
+	batch := make([]storage.BatchURLRequest, 0, len(URLs))
+	for index, URL := range URLs {
+		batch = append(batch, storage.BatchURLRequest{CorrelationID: strconv.Itoa(index), OriginalURL: URL})
+	}
+	response, errorMessage, errorCode := repository.CreateShortURLBatch(batch, userID, baseURL)
+	if errorCode != 0 {
+		return nil, errorMessage, errorCode
+	}
+	results = make([]types.ImportRowResult, 0, len(URLs))
+	for index, item := range response {
+		status := types.ImportStatusCreated
+		if item.Conflict {
+			status = types.ImportStatusDuplicate
+		}
+		results = append(results, types.ImportRowResult{OriginalURL: URLs[index], Status: status, ShortURL: item.ShortURL})
+	}
+	return results, "", 0
+}
+
+// UpdateURL - changes original URL of short URL owned by User in storage, previous original URL is kept in URL history.
+// http.StatusConflict is returned if new URL is already shortened.
+func (server CommonServer) UpdateURL(storage storage.IRepository, shortURL string, URL string, userID uint, baseURL string) (response storage.FullInfoURLResponse, errorMessage string, errorCode int) {
+	if errorMessage, errorCode = ValidateOriginalURL(URL); errorCode != 0 {
+		return response, errorMessage, errorCode
+	}
+	if errorMessage, errorCode = storage.UpdateURLValue(ResolveShortURL(storage, shortURL), URL, userID); errorCode != 0 {
+		return response, errorMessage, errorCode
+	}
+	response.ShortURL = baseURL + shortURL
+	response.OriginalURL = URL
+	return response, "", 0
+}
+
+// GetURLHistory - return changes of original URL of short URL owned by User from storage
+func (server CommonServer) GetURLHistory(storage storage.IRepository, shortURL string, userID uint) (history []storage.URLChange, errorCode int) {
+	history, errorCode = storage.GetURLHistory(ResolveShortURL(storage, shortURL), userID)
+	return history, errorCode
+}
+
+// GetURLStats - return click statistics for URL owned by User from storage
+func (server CommonServer) GetURLStats(storage storage.IRepository, shortURL string, userID uint) (stats storage.ClickStatsResponse, errorCode int) {
+	stats, errorCode = storage.GetClickStats(ResolveShortURL(storage, shortURL), userID)
+	return stats, errorCode
+}
+
+// GetDeletedURLs - return deleted URLs for given User from storage
+func (server CommonServer) GetDeletedURLs(storage storage.IRepository, userID uint, baseURL string) (responseList []storage.DeletedURLResponse, errorCode int) {
+	responseList, errorCode = storage.GetDeletedURLsByUserID(userID, baseURL)
 	return responseList, errorCode
 }
 
-// DeleteURLs - removes all URLs for given User from storage
-func (server CommonServer) DeleteURLs(deleteChannel chan types.RequestToDelete, URLsToDelete []string, userID uint) {
-	go func() {
-		deleteChannel <- types.RequestToDelete{URLs: URLsToDelete, UserID: userID}
-	}()
+// RestoreURLs - restores deleted URLs for given User in storage, returns number of restored URLs
+func (server CommonServer) RestoreURLs(storage storage.IRepository, URLsToRestore []string, userID uint) (restored int, errorMessage string, errorCode int) {
+	URLIDs := make([]uint, 0, len(URLsToRestore))
+	for _, shortURL := range URLsToRestore {
+		URLIDs = append(URLIDs, ResolveShortURL(storage, shortURL))
+	}
+	restored, err := storage.RestoreBatch(URLIDs, userID)
+	if err != nil {
+		log.Printf("Couldn't restore URLs for user %d, %s", userID, err.Error())
+		return 0, "Error while restoring URLs", http.StatusInternalServerError
+	}
+	return restored, "", 0
+}
+
+// DeleteURLs - saves request to remove URLs for given User into deletion queue and returns its job.
+// URLs are marked as deleted in storage asynchronously.
+func (server CommonServer) DeleteURLs(deleteQueue *DeleteQueue, URLsToDelete []string, userID uint) (job types.DeleteJobResponse, errorMessage string, errorCode int) {
+	job, err := deleteQueue.Enqueue(URLsToDelete, userID)
+	if errors.Is(err, ErrDeleteQueueClosed) {
+		return job, "Server is shutting down", http.StatusServiceUnavailable
+	}
+	if err != nil {
+		log.Printf("Couldn't queue deletion for user %d, %s", userID, err.Error())
+		return job, "Error while queueing deletion", http.StatusInternalServerError
+	}
+	return job, "", 0
+}
+
+// GetDeleteJob - gets status of deletion job by its ID, http.StatusNotFound is returned if job doesn't belong to User
+func (server CommonServer) GetDeleteJob(deleteQueue *DeleteQueue, ID string, userID uint) (job types.DeleteJobResponse, errorCode int) {
+	job, ok := deleteQueue.Job(ID, userID)
+	if !ok {
+		return job, http.StatusNotFound
+	}
+	return job, 0
 }
 
 // Ping - checks than connection to storage is alive
@@ -61,3 +247,131 @@ func (server CommonServer) GetStats(storage storage.IRepository) (stats storage.
 	stats, errorCode = storage.GetStats()
 	return stats, errorCode
 }
+
+// CreateAPIKey - generates API key with given name and scopes for User and saves its hash into storage.
+// The key itself is returned only here, it couldn't be restored later.
+func (server CommonServer) CreateAPIKey(repository storage.IRepository, request types.APIKeyRequest, userID uint) (response types.APIKeyResponse, errorMessage string, errorCode int) {
+	scopes, errorMessage, errorCode := ValidateAPIKeyRequest(request)
+	if errorCode != 0 {
+		return response, errorMessage, errorCode
+	}
+	ID, key := auth.NewAPIKey()
+	apiKey := storage.APIKey{
+		ID: ID, UserID: userID, Name: request.Name, Hash: auth.HashAPIKey(key), Scopes: scopes, CreatedAt: time.Now().UTC(),
+	}
+	if errorCode = repository.CreateAPIKey(apiKey); errorCode != 0 {
+		log.Printf("Couldn't create API key for user %d, code %d", userID, errorCode)
+		return response, "Error while creating API key", http.StatusInternalServerError
+	}
+	response = newAPIKeyResponse(apiKey)
+	response.Key = key
+	return response, "", 0
+}
+
+// GetAPIKeys - return API keys of given User including revoked ones from storage, the keys themselves aren't returned
+func (server CommonServer) GetAPIKeys(repository storage.IRepository, userID uint) (responseList []types.APIKeyResponse, errorCode int) {
+	keys, errorCode := repository.GetAPIKeysByUserID(userID)
+	if errorCode != http.StatusOK {
+		return nil, errorCode
+	}
+	responseList = make([]types.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		responseList = append(responseList, newAPIKeyResponse(key))
+	}
+	return responseList, errorCode
+}
+
+// RevokeAPIKey - revokes API key by its ID if it belongs to given User in storage
+func (server CommonServer) RevokeAPIKey(repository storage.IRepository, ID string, userID uint) (errorCode int) {
+	return repository.RevokeAPIKey(ID, userID)
+}
+
+// AuthenticateAPIKey - finds not revoked API key in storage, http.StatusUnauthorized is returned for malformed or unknown key
+func (server CommonServer) AuthenticateAPIKey(repository storage.IRepository, key string) (apiKey storage.APIKey, errorCode int) {
+	if _, ok := auth.ParseAPIKey(key); !ok {
+		return apiKey, http.StatusUnauthorized
+	}
+	apiKey, errorCode = repository.GetAPIKeyByHash(auth.HashAPIKey(key))
+	if errorCode == http.StatusNotFound {
+		return apiKey, http.StatusUnauthorized
+	}
+	return apiKey, errorCode
+}
+
+// mergeAnonymousUser - moves URLs and API keys of anonymous User to account in storage.
+// Nothing is moved if User is account itself, so login into another account doesn't take its URLs.
+func mergeAnonymousUser(repository storage.IRepository, fromUserID uint, toUserID uint) (merged int, errorMessage string, errorCode int) {
+	if fromUserID == 0 || fromUserID == toUserID {
+		return 0, "", 0
+	}
+	_, errorCode = repository.GetAccountByUserID(fromUserID)
+	if errorCode == 0 {
+		return 0, "", 0
+	}
+	if errorCode != http.StatusNotFound {
+		return 0, "Error while merging user", http.StatusInternalServerError
+	}
+	merged, err := repository.MergeUser(fromUserID, toUserID)
+	if err != nil {
+		log.Printf("Couldn't merge user %d into account %d, %s", fromUserID, toUserID, err.Error())
+		return 0, "Error while merging user", http.StatusInternalServerError
+	}
+	return merged, "", 0
+}
+
+// Register - creates account with new UserID and moves URLs and API keys of anonymous User of request to it in storage.
+// http.StatusConflict is returned if username is already taken.
+func (server CommonServer) Register(repository storage.IRepository, request types.AccountRequest, currentUserID uint) (response types.AccountResponse, userID uint, errorMessage string, errorCode int) {
+	username, errorMessage, errorCode := ValidateAccountRequest(request)
+	if errorCode != 0 {
+		return response, 0, errorMessage, errorCode
+	}
+	passwordHash, err := storage.HashPassword(request.Password)
+	if err != nil {
+		return response, 0, "Couldn't hash password", http.StatusInternalServerError
+	}
+	account := storage.Account{ID: auth.NewUserID(), Username: username, PasswordHash: passwordHash, CreatedAt: time.Now().UTC()}
+	if errorCode = repository.CreateAccount(account); errorCode == http.StatusConflict {
+		return response, 0, "Username is already taken", http.StatusConflict
+	} else if errorCode != 0 {
+		return response, 0, "Error while creating account", http.StatusInternalServerError
+	}
+	merged, errorMessage, errorCode := mergeAnonymousUser(repository, currentUserID, account.ID)
+	if errorCode != 0 {
+		return response, 0, errorMessage, errorCode
+	}
+	return types.AccountResponse{Username: username, Merged: merged}, account.ID, "", 0
+}
+
+// Login - verifies account password with attempts limited per username and client with ceiling per username
+// and moves URLs and API keys of anonymous User of request to account in storage.
+// Unknown username and wrong password aren't distinguished.
+func (server CommonServer) Login(repository storage.IRepository, limiter *PasswordLimiter, request types.AccountRequest, currentUserID uint, client string) (response types.AccountResponse, userID uint, retryAfter time.Duration, errorMessage string, errorCode int) {
+	username := NormalizeUsername(request.Username)
+	if username == "" || request.Password == "" {
+		return response, 0, 0, "Username and password are required", http.StatusUnauthorized
+	}
+	account, errorCode := repository.GetAccountByUsername(username)
+	if errorCode != 0 && errorCode != http.StatusNotFound {
+		return response, 0, 0, "Error while getting account", http.StatusInternalServerError
+	}
+	// Attempts are limited by username, so unknown usernames are limited the same way as existing ones
+	retryAfter, ok := limiter.Attempt(username, client, time.Now())
+	if !ok {
+		return response, 0, retryAfter, "Too many login attempts, try again later", http.StatusTooManyRequests
+	}
+	if errorCode == http.StatusNotFound {
+		// Compare with dummy hash so that unknown usernames can't be found by response time
+		storage.CheckPasswordHash(dummyPasswordHash(), request.Password)
+		return response, 0, 0, "Wrong username or password", http.StatusUnauthorized
+	}
+	if !account.CheckPassword(request.Password) {
+		return response, 0, 0, "Wrong username or password", http.StatusUnauthorized
+	}
+	limiter.Reset(username, client)
+	merged, errorMessage, errorCode := mergeAnonymousUser(repository, currentUserID, account.ID)
+	if errorCode != 0 {
+		return response, 0, 0, errorMessage, errorCode
+	}
+	return types.AccountResponse{Username: username, Merged: merged}, account.ID, 0, "", 0
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/storage"
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+// DeleteJobMaxAttempts - number of attempts to mark URLs as deleted before job fails
+const DeleteJobMaxAttempts = 5
+
+// DeleteRetryDelay - delay before the first retry of failed deletion, it is doubled for every next retry
+const DeleteRetryDelay = 100 * time.Millisecond
+
+// DeleteMaxRetryDelay - max delay between deletion retries
+const DeleteMaxRetryDelay = 10 * time.Second
+
+// DeleteJobRetention - time for which finished jobs are kept for status requests
+const DeleteJobRetention = 24 * time.Hour
+
+// DeleteQueueCompactionRecords - min number of records in queue file after which it is rewritten with jobs kept in memory only
+const DeleteQueueCompactionRecords = 1000
+
+// ErrDeleteQueueClosed - error for deletion requests received after queue was closed
+var ErrDeleteQueueClosed = errors.New("delete queue is closed")
+
+// deleteJob - URLs deletion request with its processing status
+type deleteJob struct {
+	ID         string    `json:"id"`                 // ID - job ID
+	Status     string    `json:"status"`             // Status - one of types.DeleteJobQueued, types.DeleteJobDone, types.DeleteJobFailed
+	UserID     uint      `json:"user_id,omitempty"`  // UserID - user ID for URLs to delete, absent in status records
+	URLs       []string  `json:"urls,omitempty"`     // URLs - short URLs to delete, absent in status records
+	Attempts   int       `json:"attempts,omitempty"` // Attempts - number of made attempts
+	Error      string    `json:"error,omitempty"`    // Error - error of the last failed attempt
+	CreatedAt  time.Time `json:"created_at"`         // CreatedAt - time when job was queued
+	FinishedAt time.Time `json:"finished_at"`        // FinishedAt - time when job was done or failed, zero for queued job
+}
+
+// response - get job status for client
+func (job *deleteJob) response() types.DeleteJobResponse {
+	response := types.DeleteJobResponse{
+		ID: job.ID, Status: job.Status, URLs: len(job.URLs), Attempts: job.Attempts, Error: job.Error, CreatedAt: job.CreatedAt,
+	}
+	if !job.FinishedAt.IsZero() {
+		finishedAt := job.FinishedAt
+		response.FinishedAt = &finishedAt
+	}
+	return response
+}
+
+// DeleteQueue - durable queue of URLs deletion requests.
+// Every job is written into append-only file before it is acknowledged, so jobs not finished before
+// shutdown are processed again after restart. Jobs are processed one by one with retries and backoff.
+type DeleteQueue struct {
+	// storage - storage.IRepository implementation
+	storage storage.IRepository
+	// file - queue file, nil for in-memory queue
+	file *os.File
+	// encoder - object to encode job records into file
+	encoder *json.Encoder
+	// mutex - guards all fields below
+	mutex sync.Mutex
+	// wakeup - signals worker about new jobs and closing
+	wakeup *sync.Cond
+	// jobs - job ID map to job
+	jobs map[string]*deleteJob
+	// pending - IDs of jobs waiting for processing in queue order
+	pending []string
+	// finished - IDs of finished jobs in finish order, the oldest ones are dropped after DeleteJobRetention
+	finished []string
+	// closed - true after Close is called, new jobs aren't accepted
+	closed bool
+	// abort - channel which is closed when Close context is done, worker stops without waiting for retries
+	abort chan struct{}
+	// done - channel which is closed after worker stops
+	done chan struct{}
+	// retryDelay - delay before the first retry
+	retryDelay time.Duration
+	// records - number of records in queue file
+	records int
+	// compactionRecords - number of records in queue file which triggers its rewrite if they are at least twice as many as jobs
+	compactionRecords int
+}
+
+// NewDeleteQueue creates DeleteQueue for given storage with jobs file by filename, empty filename means in-memory queue.
+// Unfinished jobs from file are queued again, Run should be called to start processing.
+func NewDeleteQueue(storageVal storage.IRepository, filename string) (*DeleteQueue, error) {
+	queue := &DeleteQueue{
+		storage:    storageVal,
+		jobs:       make(map[string]*deleteJob),
+		abort:      make(chan struct{}),
+		done:       make(chan struct{}),
+		retryDelay: DeleteRetryDelay,
+
+		compactionRecords: DeleteQueueCompactionRecords,
+	}
+	queue.wakeup = sync.NewCond(&queue.mutex)
+	if filename == "" {
+		return queue, nil
+	}
+	if err := queue.load(filename); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	queue.file = file
+	queue.encoder = json.NewEncoder(file)
+	return queue, nil
+}
+
+// load - read jobs from file and rewrite it with unfinished jobs and jobs finished within DeleteJobRetention only
+func (queue *DeleteQueue) load(filename string) error {
+	file, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(file)
+	for {
+		var record deleteJob
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Partially written last record is dropped together with the rest of file by rewrite below
+			log.Printf("Got broken record in %s at offset %d, drop it: %s", filename, decoder.InputOffset(), err.Error())
+			break
+		}
+		if job, ok := queue.jobs[record.ID]; ok {
+			job.Status, job.Attempts, job.Error, job.FinishedAt = record.Status, record.Attempts, record.Error, record.FinishedAt
+		} else {
+			job := record
+			queue.jobs[record.ID] = &job
+		}
+	}
+	file.Close()
+	jobs := make([]*deleteJob, 0, len(queue.jobs))
+	for ID, job := range queue.jobs {
+		if job.Status != types.DeleteJobQueued && time.Since(job.FinishedAt) > DeleteJobRetention {
+			delete(queue.jobs, ID)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	for _, job := range jobs {
+		if job.Status == types.DeleteJobQueued {
+			queue.pending = append(queue.pending, job.ID)
+		} else {
+			queue.finished = append(queue.finished, job.ID)
+		}
+	}
+	sort.Slice(queue.finished, func(i, j int) bool {
+		return queue.jobs[queue.finished[i]].FinishedAt.Before(queue.jobs[queue.finished[j]].FinishedAt)
+	})
+	queue.records = len(jobs)
+	return writeJobsFile(filename, jobs)
+}
+
+// writeJobsFile - atomically replace file by filename with records of given jobs
+func writeJobsFile(filename string, jobs []*deleteJob) error {
+	tmpName := filename + ".tmp"
+	tmpFile, err := os.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(tmpFile)
+	for _, job := range jobs {
+		if err := encoder.Encode(job); err != nil {
+			tmpFile.Close()
+			return err
+		}
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, filename)
+}
+
+// compact - rewrite queue file with jobs kept in memory only, mutex should be held
+func (queue *DeleteQueue) compact() error {
+	jobs := make([]*deleteJob, 0, len(queue.jobs))
+	for _, job := range queue.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	filename := queue.file.Name()
+	if err := writeJobsFile(filename, jobs); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	queue.file.Close()
+	queue.file = file
+	queue.encoder = json.NewEncoder(file)
+	queue.records = len(jobs)
+	return nil
+}
+
+// writeRecord - append job record to queue file if it is used, mutex should be held
+func (queue *DeleteQueue) writeRecord(job *deleteJob) error {
+	if queue.encoder == nil {
+		return nil
+	}
+	if err := queue.encoder.Encode(job); err != nil {
+		return err
+	}
+	queue.records++
+	return queue.file.Sync()
+}
+
+// newDeleteJobID - generate random job ID
+func newDeleteJobID() (string, error) {
+	ID := make([]byte, 16)
+	if _, err := rand.Read(ID); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(ID), nil
+}
+
+// Enqueue saves deletion request for userID and returns its job, ErrDeleteQueueClosed is returned after Close is called
+func (queue *DeleteQueue) Enqueue(URLs []string, userID uint) (types.DeleteJobResponse, error) {
+	ID, err := newDeleteJobID()
+	if err != nil {
+		return types.DeleteJobResponse{}, err
+	}
+	job := &deleteJob{ID: ID, Status: types.DeleteJobQueued, UserID: userID, URLs: URLs, CreatedAt: time.Now().UTC()}
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	if queue.closed {
+		return types.DeleteJobResponse{}, ErrDeleteQueueClosed
+	}
+	if err := queue.writeRecord(job); err != nil {
+		return types.DeleteJobResponse{}, err
+	}
+	queue.jobs[ID] = job
+	queue.pending = append(queue.pending, ID)
+	queue.wakeup.Signal()
+	return job.response(), nil
+}
+
+// Job returns status of job by its ID if it belongs to userID
+func (queue *DeleteQueue) Job(ID string, userID uint) (types.DeleteJobResponse, bool) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	job, ok := queue.jobs[ID]
+	if !ok || job.UserID != userID {
+		return types.DeleteJobResponse{}, false
+	}
+	return job.response(), true
+}
+
+// next - wait for the next pending job, false is returned when queue is closed and all jobs are processed or aborted
+func (queue *DeleteQueue) next() (*deleteJob, bool) {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+	for len(queue.pending) == 0 && !queue.closed {
+		queue.wakeup.Wait()
+	}
+	if len(queue.pending) == 0 || queue.aborted() {
+		return nil, false
+	}
+	job := queue.jobs[queue.pending[0]]
+	queue.pending = queue.pending[1:]
+	return job, true
+}
+
+// aborted - check that Close context is done
+func (queue *DeleteQueue) aborted() bool {
+	select {
+	case <-queue.abort:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run processes jobs one by one until Close is called and queue is drained.
+func (queue *DeleteQueue) Run() {
+	defer close(queue.done)
+	for {
+		job, ok := queue.next()
+		if !ok {
+			return
+		}
+		queue.process(job)
+	}
+}
+
+// process - mark job URLs as deleted, failed attempts are retried with exponential backoff up to DeleteJobMaxAttempts
+func (queue *DeleteQueue) process(job *deleteJob) {
+	delay := queue.retryDelay
+	for {
+		URLIDs := make([]uint, 0, len(job.URLs))
+		for _, shortURL := range job.URLs {
+			URLIDs = append(URLIDs, ResolveShortURL(queue.storage, shortURL))
+		}
+		err := queue.storage.MarkBatchAsDeleted(URLIDs, job.UserID)
+		queue.mutex.Lock()
+		job.Attempts++
+		if err == nil {
+			queue.finish(job, types.DeleteJobDone)
+			queue.mutex.Unlock()
+			return
+		}
+		job.Error = err.Error()
+		if job.Attempts >= DeleteJobMaxAttempts {
+			log.Printf("Couldn't delete urls for user %d after %d attempts, %s", job.UserID, job.Attempts, job.Error)
+			queue.finish(job, types.DeleteJobFailed)
+			queue.mutex.Unlock()
+			return
+		}
+		queue.mutex.Unlock()
+		select {
+		case <-time.After(delay):
+		case <-queue.abort:
+			// Job stays queued in file and is processed again after restart
+			return
+		}
+		if delay *= 2; delay > DeleteMaxRetryDelay {
+			delay = DeleteMaxRetryDelay
+		}
+	}
+}
+
+// finish - set final job status, write it into file and drop jobs finished more than DeleteJobRetention ago, mutex should be held
+func (queue *DeleteQueue) finish(job *deleteJob, status string) {
+	job.Status = status
+	job.FinishedAt = time.Now().UTC()
+	if status == types.DeleteJobDone {
+		job.Error = ""
+	}
+	if err := queue.writeRecord(&deleteJob{
+		ID: job.ID, Status: job.Status, Attempts: job.Attempts, Error: job.Error, CreatedAt: job.CreatedAt, FinishedAt: job.FinishedAt,
+	}); err != nil {
+		log.Printf("Couldn't save status of delete job %s, %s", job.ID, err.Error())
+	}
+	queue.finished = append(queue.finished, job.ID)
+	for len(queue.finished) > 0 && time.Since(queue.jobs[queue.finished[0]].FinishedAt) > DeleteJobRetention {
+		delete(queue.jobs, queue.finished[0])
+		queue.finished = queue.finished[1:]
+	}
+	if queue.file != nil && queue.records >= queue.compactionRecords && queue.records >= 2*len(queue.jobs) {
+		if err := queue.compact(); err != nil {
+			log.Printf("Couldn't compact delete queue file, %s", err.Error())
+		}
+	}
+}
+
+// Close stops accepting jobs and waits until already accepted ones are processed.
+// If ctx is done earlier, worker stops after the current attempt and unfinished jobs are left in file for the next start.
+func (queue *DeleteQueue) Close(ctx context.Context) error {
+	queue.mutex.Lock()
+	queue.closed = true
+	queue.wakeup.Broadcast()
+	queue.mutex.Unlock()
+	select {
+	case <-queue.done:
+	case <-ctx.Done():
+		close(queue.abort)
+		<-queue.done
+	}
+	if queue.file == nil {
+		return nil
+	}
+	return queue.file.Close()
+}
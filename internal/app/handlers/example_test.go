@@ -19,10 +19,7 @@ func ExampleHandlerWithStorage_CreateShortenURLFromBodyHandler() {
 	w := httptest.NewRecorder()
 	ctx := context.WithValue(request.Context(), types.UserIDCtxName, uint(1))
 	request = request.WithContext(ctx)
-	handler := http.HandlerFunc(NewHandlerWithStorage(&storage.Storage{
-		InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{},
-		NextIndex: 1, Encoder: nil, Decoder: nil,
-	}, make(chan types.RequestToDelete, 10)).CreateShortenURLFromBodyHandler)
+	handler := http.HandlerFunc(NewHandlerWithStorage(storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1), nil, nil).CreateShortenURLFromBodyHandler)
 	handler.ServeHTTP(w, request)
 	result := w.Result()
 	fmt.Println(result.StatusCode)
@@ -42,9 +39,7 @@ func ExampleHandlerWithStorage_CreateShortURLHandler() {
 	w := httptest.NewRecorder()
 	ctx := context.WithValue(request.Context(), types.UserIDCtxName, uint(1))
 	request = request.WithContext(ctx)
-	handler := http.HandlerFunc(NewHandlerWithStorage(&storage.Storage{
-		InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-	}, make(chan types.RequestToDelete, 10)).CreateShortURLHandler)
+	handler := http.HandlerFunc(NewHandlerWithStorage(storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1), nil, nil).CreateShortURLHandler)
 	handler.ServeHTTP(w, request)
 	result := w.Result()
 	fmt.Println(result.StatusCode)
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/storage"
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+// Formats of user URLs export and import
+const (
+	FormatNDJSON = "ndjson" // FormatNDJSON - one JSON object per line
+	FormatCSV    = "csv"    // FormatCSV - CSV with header row
+)
+
+// ExportFlushRows - number of exported rows after which response is flushed to client
+const ExportFlushRows = 100
+
+// exportContentTypes - content types of export formats
+var exportContentTypes = map[string]string{
+	FormatNDJSON: "application/x-ndjson",
+	FormatCSV:    "text/csv; charset=utf-8",
+}
+
+// NegotiateExportFormat - choose export format by format query param or Accept header, NDJSON is used by default.
+// Empty format is returned if neither of them allows supported formats.
+func NegotiateExportFormat(format string, accept string) string {
+	if format != "" {
+		if _, ok := exportContentTypes[format]; ok {
+			return format
+		}
+		return ""
+	}
+	if accept == "" {
+		return FormatNDJSON
+	}
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "application/x-ndjson", "application/json", "application/*", "*/*":
+			return FormatNDJSON
+		case "text/csv", "text/*":
+			return FormatCSV
+		}
+	}
+	return ""
+}
+
+// urlsEncoder - encoder of exported URLs in one of export formats
+type urlsEncoder interface {
+	Encode(url storage.ExportedURL) error // Encode - write URL into underlying writer
+	Flush() error                         // Flush - flush buffered URLs into underlying writer
+}
+
+// ndjsonEncoder - urlsEncoder for NDJSON format
+type ndjsonEncoder struct {
+	buffer  *bufio.Writer // buffer - buffered writer for response
+	encoder *json.Encoder // encoder - encoder of URLs into buffer
+}
+
+// Encode - write URL as JSON line
+func (e *ndjsonEncoder) Encode(url storage.ExportedURL) error {
+	return e.encoder.Encode(url)
+}
+
+// Flush - flush buffered lines into underlying writer
+func (e *ndjsonEncoder) Flush() error {
+	return e.buffer.Flush()
+}
+
+// csvEncoder - urlsEncoder for CSV format
+type csvEncoder struct {
+	writer *csv.Writer // writer - CSV writer for response
+}
+
+// Encode - write URL as CSV row
+func (e *csvEncoder) Encode(url storage.ExportedURL) error {
+	expiresAt := ""
+	if url.ExpiresAt != nil {
+		expiresAt = url.ExpiresAt.Format(time.RFC3339)
+	}
+	return e.writer.Write([]string{url.ShortURL, url.OriginalURL, strconv.FormatBool(url.Deleted), expiresAt})
+}
+
+// Flush - flush buffered rows into underlying writer
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// trackingWriter - io.Writer which remembers that something was written into it
+type trackingWriter struct {
+	io.Writer
+	written bool // written - true after the first Write call
+}
+
+// Write - write bytes into underlying writer
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.Writer.Write(b)
+}
+
+// newURLsEncoder - create urlsEncoder for given format, CSV header row is written at once
+func newURLsEncoder(format string, w io.Writer) (urlsEncoder, error) {
+	if format == FormatCSV {
+		encoder := &csvEncoder{writer: csv.NewWriter(w)}
+		return encoder, encoder.writer.Write([]string{"short_url", "original_url", "deleted", "expires_at"})
+	}
+	buffer := bufio.NewWriter(w)
+	return &ndjsonEncoder{buffer: buffer, encoder: json.NewEncoder(buffer)}, nil
+}
+
+// ExportURLsHandler streams all URLs for given User in NDJSON or CSV format without building whole response in memory
+func (strg *HandlerWithStorage) ExportURLsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	format := NegotiateExportFormat(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if format == "" {
+		if r.URL.Query().Get("format") != "" {
+			http.Error(w, "format should be ndjson or csv", http.StatusBadRequest)
+		} else {
+			http.Error(w, "Only application/x-ndjson and text/csv are supported", http.StatusNotAcceptable)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", "attachment; filename=urls."+format)
+	response := &trackingWriter{Writer: w}
+	encoder, err := newURLsEncoder(format, response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	flusher, canFlush := w.(http.Flusher)
+	rows := 0
+	err = CommonServer{}.ExportURLs(strg.storage, userID, strg.baseURL, func(url storage.ExportedURL) error {
+		if err := encoder.Encode(url); err != nil {
+			return err
+		}
+		rows++
+		if rows%ExportFlushRows != 0 {
+			return nil
+		}
+		if err := encoder.Flush(); err != nil {
+			return err
+		}
+		if canFlush {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && !response.written {
+		w.Header().Del("Content-Disposition")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		log.Printf("Export of URLs for user %d was interrupted after %d rows, %v", userID, rows, err)
+		return
+	}
+	if err = encoder.Flush(); err != nil {
+		log.Printf("Couldn't flush export of URLs for user %d, %v", userID, err)
+	}
+}
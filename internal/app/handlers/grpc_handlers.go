@@ -5,16 +5,19 @@ import (
 	"google.golang.org/grpc"
 	"net"
 	"net/http"
-	"strconv"
+	"time"
 
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
 	"github.com/tank4gun/gourlshortener/internal/app/storage"
 	"github.com/tank4gun/gourlshortener/internal/app/types"
 	"github.com/tank4gun/gourlshortener/internal/app/varprs"
 	pb "github.com/tank4gun/gourlshortener/internal/pkg/proto"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // ShortenderServer - grpc server struct
@@ -24,48 +27,232 @@ type ShortenderServer struct {
 	storage storage.IRepository
 	// baseURL - base URL for shorten URLs, i.e. http://localhost:8080
 	baseURL string
-	// deleteChannel - channel for RequestToDelete object to process
-	deleteChannel chan types.RequestToDelete
+	// deleteQueue - queue for URLs deletion requests
+	deleteQueue *DeleteQueue
+	// passwordLimiter - limiter of password attempts for protected URLs
+	passwordLimiter *PasswordLimiter
+	// keyring - keys for issuing identity tokens
+	keyring *auth.Keyring
+	// tokenTTL - time for which issued identity token is valid
+	tokenTTL time.Duration
 }
 
 // NewShortenderServer - creates new grpc server instance
-func NewShortenderServer(storage storage.IRepository, deleteChannel chan types.RequestToDelete) *ShortenderServer {
-	return &ShortenderServer{storage: storage, baseURL: varprs.BaseURL, deleteChannel: deleteChannel}
+func NewShortenderServer(storage storage.IRepository, deleteQueue *DeleteQueue, keyring *auth.Keyring) *ShortenderServer {
+	tokenTTL := varprs.AuthTokenTTL
+	if tokenTTL <= 0 {
+		tokenTTL = auth.DefaultTokenTTL
+	}
+	return &ShortenderServer{
+		storage:         storage,
+		baseURL:         varprs.BaseURL,
+		deleteQueue:     deleteQueue,
+		passwordLimiter: NewPasswordLimiter(PasswordAttempts, PasswordAttemptsPerURL, PasswordAttemptsWindow),
+		keyring:         keyring,
+		tokenTTL:        tokenTTL,
+	}
+}
+
+// SetPasswordLimiter - sets limiter of password attempts for protected URLs, i.e. the one shared with HTTP server
+func (s *ShortenderServer) SetPasswordLimiter(limiter *PasswordLimiter) {
+	s.passwordLimiter = limiter
+}
+
+// newDeleteJobResponse - convert deletion job status into grpc response
+func newDeleteJobResponse(job types.DeleteJobResponse) *pb.DeleteJobResponse {
+	response := pb.DeleteJobResponse{
+		Id: job.ID, Status: job.Status, Urls: int32(job.URLs), Attempts: int32(job.Attempts), Error: job.Error, CreatedAt: timestamppb.New(job.CreatedAt),
+	}
+	if job.FinishedAt != nil {
+		response.FinishedAt = timestamppb.New(*job.FinishedAt)
+	}
+	return &response
 }
 
-// GetUserIDFromContext - returns UserID from request context
+// GetUserIDFromContext - returns UserID verified by UserIDInterceptor from request context
 func GetUserIDFromContext(ctx context.Context) uint {
-	md, _ := metadata.FromIncomingContext(ctx)
-	values := md.Get("UserID")
-	userID, _ := strconv.Atoi(values[0])
-	return uint(userID)
+	userID, _ := ctx.Value(types.UserIDCtxName).(uint)
+	return userID
+}
+
+// TimestampToTime - converts optional protobuf timestamp to time pointer, nil is returned for unset timestamp
+func TimestampToTime(timestamp *timestamppb.Timestamp) *time.Time {
+	if timestamp == nil {
+		return nil
+	}
+	value := timestamp.AsTime()
+	return &value
+}
+
+// GetClientIPFromContext - gets client IP from peer address, X-Real-IP metadata is used only if peer is trusted proxy
+func GetClientIPFromContext(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	peerIP, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		peerIP = p.Addr.String()
+	}
+	realIP := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("X-Real-IP"); len(values) > 0 {
+			realIP = values[0]
+		}
+	}
+	return clientIPFromProxy(peerIP, realIP)
+}
+
+// AuthorizationMetadata - metadata key with identity token of user, i.e. "Bearer <token>"
+const AuthorizationMetadata = "authorization"
+
+// publicMethods - grpc methods available without identity token
+var publicMethods = map[string]bool{
+	pb.Shortender_IssueToken_FullMethodName: true,
+	pb.Shortender_Ping_FullMethodName:       true,
+	pb.Shortender_GetStats_FullMethodName:   true,
+}
+
+// methodScopes - scopes of API keys required for grpc methods, methods without scope are allowed for any API key
+var methodScopes = map[string]string{
+	pb.Shortender_CreateShortURL_FullMethodName:        auth.ScopeShorten,
+	pb.Shortender_CreateShortenURLBatch_FullMethodName: auth.ScopeShorten,
+	pb.Shortender_UpdateURL_FullMethodName:             auth.ScopeShorten,
+	pb.Shortender_GetURLByID_FullMethodName:            auth.ScopeRead,
+	pb.Shortender_GetAllURLs_FullMethodName:            auth.ScopeRead,
+	pb.Shortender_ExportURLs_FullMethodName:            auth.ScopeRead,
+	pb.Shortender_GetURLHistory_FullMethodName:         auth.ScopeRead,
+	pb.Shortender_GetDeletedURLs_FullMethodName:        auth.ScopeRead,
+	pb.Shortender_DeleteURLs_FullMethodName:            auth.ScopeDelete,
+	pb.Shortender_GetDeleteJob_FullMethodName:          auth.ScopeDelete,
+	pb.Shortender_RestoreURLs_FullMethodName:           auth.ScopeDelete,
 }
 
-// UserIDInterceptor - middleware, checks whether context contains UserID
-func UserIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+// GetTokenFromContext - gets identity token from authorization metadata, "Bearer " prefix is optional
+func GetTokenFromContext(ctx context.Context) string {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, status.Error(codes.Internal, "Couldn't get data from context")
+		return ""
 	}
-	values := md.Get("UserID")
+	values := md.Get(AuthorizationMetadata)
 	if len(values) == 0 {
-		return nil, status.Error(codes.Internal, "Couldn't get UserID from context")
+		return ""
+	}
+	return auth.BearerToken(values[0])
+}
+
+// authenticate - verifies identity token or API key from metadata and returns context with verified UserID.
+// API key should have scope required for method.
+func authenticate(ctx context.Context, method string, keyring *auth.Keyring, repository storage.IRepository) (context.Context, error) {
+	token := GetTokenFromContext(ctx)
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "Couldn't get authorization token from metadata")
+	}
+	if _, ok := auth.ParseAPIKey(token); ok {
+		apiKey, errorCode := CommonServer{}.AuthenticateAPIKey(repository, token)
+		if errorCode == http.StatusUnauthorized {
+			return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+		}
+		if errorCode != 0 {
+			return nil, status.Error(codes.Internal, "Couldn't check API key")
+		}
+		ctx = auth.WithScopes(context.WithValue(ctx, types.UserIDCtxName, apiKey.UserID), apiKey.Scopes)
+		if scope, ok := methodScopes[method]; ok && !auth.HasScope(ctx, scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "API key doesn't have %s scope", scope)
+		}
+		return ctx, nil
 	}
-	_, err = strconv.Atoi(values[0])
+	identity, err := keyring.Verify(token, time.Now())
 	if err != nil {
-		return nil, status.Error(codes.PermissionDenied, "Couldn't convert UserID to int")
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return context.WithValue(ctx, types.UserIDCtxName, identity.UserID), nil
+}
+
+// UserIDInterceptor - middleware, verifies identity token or API key from authorization metadata and puts UserID into context
+func UserIDInterceptor(keyring *auth.Keyring, repository storage.IRepository) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+		ctx, err := authenticate(ctx, info.FullMethod, keyring, repository)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticatedStream - grpc.ServerStream with context containing verified UserID
+type authenticatedStream struct {
+	grpc.ServerStream
+	// ctx - stream context with UserID
+	ctx context.Context
+}
+
+// Context - returns stream context with verified UserID
+func (s authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// UserIDStreamInterceptor - middleware for streaming RPCs, verifies identity token or API key from authorization metadata
+// and puts UserID into stream context
+func UserIDStreamInterceptor(keyring *auth.Keyring, repository storage.IRepository) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if publicMethods[info.FullMethod] {
+			return handler(srv, stream)
+		}
+		ctx, err := authenticate(stream.Context(), info.FullMethod, keyring, repository)
+		if err != nil {
+			return err
+		}
+		return handler(srv, authenticatedStream{ServerStream: stream, ctx: ctx})
 	}
-	return handler(ctx, req)
+}
+
+// IssueToken - grpc handler, issues identity token for new user. Given token or HTTP cookie value is re-issued
+// keeping its UserID, so the same user could be used in HTTP and gRPC. Only verified identity tokens are re-issued,
+// legacy cookies are replaced by HTTP server only.
+func (s *ShortenderServer) IssueToken(ctx context.Context, in *pb.IssueTokenRequest) (*pb.IssueTokenResponse, error) {
+	now := time.Now()
+	var userID uint
+	if in.Token != "" {
+		identity, err := s.keyring.Verify(in.Token, now)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		userID = identity.UserID
+	} else {
+		userID = auth.NewUserID()
+	}
+	return &pb.IssueTokenResponse{
+		Token:     s.keyring.Issue(userID, now, s.tokenTTL),
+		ExpiresAt: timestamppb.New(now.Add(s.tokenTTL)),
+	}, nil
 }
 
 // CreateShortURL - grpc handler, converts URL from request body to shorten one and saves into db
 func (s *ShortenderServer) CreateShortURL(ctx context.Context, in *pb.UrlToShortenRequest) (*pb.ShortenUrlResponse, error) {
 	var response pb.ShortenUrlResponse
 
-	md, _ := metadata.FromIncomingContext(ctx)
-	values := md.Get("UserID")
-	userID, _ := strconv.Atoi(values[0])
-	shortURL, errorMessage, errorCode := CommonServer{}.CreateShortURL(s.storage, in.Url, uint(userID), s.baseURL)
+	expiresAt := TimestampToTime(in.ExpiresAt)
+	if errorMessage, errorCode := ValidateExpiration(expiresAt, in.TtlSeconds); errorCode != 0 {
+		return &response, status.Error(codes.InvalidArgument, errorMessage)
+	}
+	passwordHash, errorMessage, errorCode := HashURLPassword(in.Password)
+	if errorCode != 0 {
+		return &response, status.Error(codes.InvalidArgument, errorMessage)
+	}
+	options := storage.URLOptions{
+		Alias:               in.CustomAlias,
+		ExpiresAt:           storage.ExpirationTime(expiresAt, in.TtlSeconds, time.Now()),
+		RedirectType:        int(in.RedirectType),
+		Title:               in.Title,
+		RequireInterstitial: in.RequireInterstitial,
+		PasswordHash:        passwordHash,
+		MaxClicks:           int(in.MaxClicks),
+	}
+	shortURL, errorMessage, errorCode := CommonServer{}.CreateShortURLWithOptions(s.storage, in.Url, options, GetUserIDFromContext(ctx), s.baseURL)
 	if errorCode != 0 && errorCode != http.StatusConflict {
 		return &response, status.Error(codes.InvalidArgument, errorMessage)
 	}
@@ -81,11 +268,33 @@ func (s *ShortenderServer) CreateShortURL(ctx context.Context, in *pb.UrlToShort
 func (s *ShortenderServer) GetURLByID(ctx context.Context, in *pb.UrlByIdRequest) (*pb.UrlByIdResponse, error) {
 	var response pb.UrlByIdResponse
 	shortURL := in.ShortUrl
-	originalURL, errorCode := CommonServer{}.GetURLByID(s.storage, shortURL, GetUserIDFromContext(ctx))
+	id, url, errorCode := CommonServer{}.GetURLByID(s.storage, shortURL, GetUserIDFromContext(ctx))
 	if errorCode != 0 {
 		return &response, status.Errorf(codes.NotFound, "Couldn't find url for id %s", shortURL)
 	}
-	response.OriginalUrl = originalURL
+	_, errorMessage, errorCode := CommonServer{}.CheckURLPassword(s.passwordLimiter, id, url, GetClientIPFromContext(ctx), in.Password)
+	switch errorCode {
+	case 0:
+	case http.StatusTooManyRequests:
+		return &response, status.Error(codes.ResourceExhausted, errorMessage)
+	default:
+		return &response, status.Error(codes.PermissionDenied, errorMessage)
+	}
+	errorMessage, errorCode = CommonServer{}.ConsumeClick(s.storage, id, url)
+	switch errorCode {
+	case 0:
+	case http.StatusGone:
+		return &response, status.Error(codes.FailedPrecondition, errorMessage)
+	default:
+		return &response, status.Error(codes.Internal, errorMessage)
+	}
+	response.OriginalUrl = url.Value
+	response.RedirectType = int32(RedirectStatus(url))
+	response.Title = url.Title
+	response.RequireInterstitial = url.RequireInterstitial
+	if !url.CreatedAt.IsZero() {
+		response.CreatedAt = timestamppb.New(url.CreatedAt)
+	}
 	return &response, nil
 }
 
@@ -95,41 +304,159 @@ func (s *ShortenderServer) CreateShortenURLBatch(ctx context.Context, in *pb.Bat
 
 	var batchRequest []storage.BatchURLRequest
 	for _, URL := range in.Request {
-		batchRequest = append(batchRequest, storage.BatchURLRequest{CorrelationID: URL.CorrelationId, OriginalURL: URL.OriginalUrl})
+		batchRequest = append(batchRequest, storage.BatchURLRequest{
+			CorrelationID: URL.CorrelationId,
+			OriginalURL:   URL.OriginalUrl,
+			ExpiresAt:     TimestampToTime(URL.ExpiresAt),
+			TTLSeconds:    URL.TtlSeconds,
+		})
 	}
 	resultURLs, errorMessage, errorCode := CommonServer{}.CreateShortenURLBatch(s.storage, batchRequest, GetUserIDFromContext(ctx), s.baseURL)
 
+	if errorCode == http.StatusBadRequest {
+		return &response, status.Error(codes.InvalidArgument, errorMessage)
+	}
 	if errorCode != 0 {
 		return &response, status.Error(codes.Internal, errorMessage)
 	}
 	for _, resultURL := range resultURLs {
-		response.Response = append(response.Response, &pb.CorrelationUrlResponse{CorrelationId: resultURL.CorrelationID, ShortUrl: resultURL.ShortURL})
+		response.Response = append(response.Response, &pb.CorrelationUrlResponse{CorrelationId: resultURL.CorrelationID, ShortUrl: resultURL.ShortURL, Conflict: resultURL.Conflict})
 	}
 	return &response, nil
 }
 
-// GetAllURLs - grpc handler, return all URLs for given User
-func (s *ShortenderServer) GetAllURLs(ctx context.Context, in *emptypb.Empty) (*pb.FullInfoUrlBatchResponse, error) {
+// GetAllURLs - grpc handler, return page of URLs for given User
+func (s *ShortenderServer) GetAllURLs(ctx context.Context, in *pb.GetAllUrlsRequest) (*pb.FullInfoUrlBatchResponse, error) {
 	var response pb.FullInfoUrlBatchResponse
-	responseList, errorCode := CommonServer{}.GetAllURLs(s.storage, GetUserIDFromContext(ctx), s.baseURL)
-	if errorCode != http.StatusOK {
+	query, errorMessage, errorCode := ParseURLsPageQuery(int(in.Limit), in.Cursor, in.Order, in.Filter)
+	if errorCode != 0 {
+		return &response, status.Error(codes.InvalidArgument, errorMessage)
+	}
+	responseList, nextCursor, errorCode := CommonServer{}.GetURLsPage(s.storage, GetUserIDFromContext(ctx), query, s.baseURL)
+	if errorCode != http.StatusOK && errorCode != http.StatusNoContent {
 		return &response, status.Error(codes.Internal, "Got error while getting all URLs for user")
 	}
+	response.NextCursor = nextCursor
 	for _, responseItem := range responseList {
-		response.Response = append(response.Response, &pb.FullInfoUrlBatchResponse_FullInfoUrl{ShortUrl: responseItem.ShortURL, OriginalUrl: responseItem.OriginalURL})
+		URL := pb.FullInfoUrlBatchResponse_FullInfoUrl{ShortUrl: responseItem.ShortURL, OriginalUrl: responseItem.OriginalURL}
+		if responseItem.RemainingClicks != nil {
+			remainingClicks := int32(*responseItem.RemainingClicks)
+			URL.RemainingClicks = &remainingClicks
+		}
+		response.Response = append(response.Response, &URL)
 	}
 	return &response, nil
 }
 
-// DeleteURLs - grpc handler, removes all URLs for given User
-func (s *ShortenderServer) DeleteURLs(ctx context.Context, in *pb.DeleteUrlsRequest) (*emptypb.Empty, error) {
+// ExportURLs - grpc handler, streams all URLs for given User one by one
+func (s *ShortenderServer) ExportURLs(in *emptypb.Empty, stream pb.Shortender_ExportURLsServer) error {
+	err := CommonServer{}.ExportURLs(s.storage, GetUserIDFromContext(stream.Context()), s.baseURL, func(url storage.ExportedURL) error {
+		response := pb.ExportUrlResponse{ShortUrl: url.ShortURL, OriginalUrl: url.OriginalURL, Deleted: url.Deleted}
+		if url.ExpiresAt != nil {
+			response.ExpiresAt = timestamppb.New(*url.ExpiresAt)
+		}
+		return stream.Send(&response)
+	})
+	if err != nil {
+		return status.Error(codes.Internal, "Got error while exporting URLs for user")
+	}
+	return nil
+}
+
+// DeleteURLs - grpc handler, queues removal of URLs for given User and returns deletion job
+func (s *ShortenderServer) DeleteURLs(ctx context.Context, in *pb.DeleteUrlsRequest) (*pb.DeleteJobResponse, error) {
 	userID := GetUserIDFromContext(ctx)
 	URLsToDelete := make([]string, 0)
 	for _, URL := range in.UrlsToDelete {
 		URLsToDelete = append(URLsToDelete, URL.ShortUrl)
 	}
-	CommonServer{}.DeleteURLs(s.deleteChannel, URLsToDelete, userID)
-	return &emptypb.Empty{}, nil
+	job, errorMessage, errorCode := CommonServer{}.DeleteURLs(s.deleteQueue, URLsToDelete, userID)
+	if errorCode == http.StatusServiceUnavailable {
+		return nil, status.Error(codes.Unavailable, errorMessage)
+	}
+	if errorCode != 0 {
+		return nil, status.Error(codes.Internal, errorMessage)
+	}
+	return newDeleteJobResponse(job), nil
+}
+
+// GetDeleteJob - grpc handler, return status of URLs deletion job for given User
+func (s *ShortenderServer) GetDeleteJob(ctx context.Context, in *pb.DeleteJobRequest) (*pb.DeleteJobResponse, error) {
+	job, errorCode := CommonServer{}.GetDeleteJob(s.deleteQueue, in.Id, GetUserIDFromContext(ctx))
+	if errorCode != 0 {
+		return nil, status.Error(codes.NotFound, "Delete job not found")
+	}
+	return newDeleteJobResponse(job), nil
+}
+
+// UpdateURL - grpc handler, changes original URL of short URL owned by given User
+func (s *ShortenderServer) UpdateURL(ctx context.Context, in *pb.UpdateUrlRequest) (*pb.UpdateUrlResponse, error) {
+	var response pb.UpdateUrlResponse
+	updated, errorMessage, errorCode := CommonServer{}.UpdateURL(s.storage, in.ShortUrl, in.OriginalUrl, GetUserIDFromContext(ctx), s.baseURL)
+	switch errorCode {
+	case 0:
+	case http.StatusBadRequest:
+		return &response, status.Error(codes.InvalidArgument, errorMessage)
+	case http.StatusNotFound:
+		return &response, status.Errorf(codes.NotFound, "Couldn't find url for id %s", in.ShortUrl)
+	case http.StatusGone:
+		return &response, status.Error(codes.FailedPrecondition, errorMessage)
+	case http.StatusConflict:
+		return &response, status.Error(codes.AlreadyExists, errorMessage)
+	default:
+		return &response, status.Error(codes.Internal, errorMessage)
+	}
+	response.ShortUrl = updated.ShortURL
+	response.OriginalUrl = updated.OriginalURL
+	return &response, nil
+}
+
+// GetURLHistory - grpc handler, returns changes of original URL of short URL owned by given User
+func (s *ShortenderServer) GetURLHistory(ctx context.Context, in *pb.UrlByIdRequest) (*pb.UrlHistoryResponse, error) {
+	var response pb.UrlHistoryResponse
+	history, errorCode := CommonServer{}.GetURLHistory(s.storage, in.ShortUrl, GetUserIDFromContext(ctx))
+	if errorCode == http.StatusNotFound {
+		return &response, status.Errorf(codes.NotFound, "Couldn't find url for id %s", in.ShortUrl)
+	}
+	if errorCode != http.StatusOK && errorCode != http.StatusNoContent {
+		return &response, status.Error(codes.Internal, "Got error while getting URL history")
+	}
+	for _, change := range history {
+		response.Changes = append(response.Changes, &pb.UrlHistoryResponse_UrlChange{
+			OldValue: change.OldValue, NewValue: change.NewValue, ChangedAt: timestamppb.New(change.ChangedAt),
+		})
+	}
+	return &response, nil
+}
+
+// GetDeletedURLs - grpc handler, return deleted URLs for given User
+func (s *ShortenderServer) GetDeletedURLs(ctx context.Context, in *emptypb.Empty) (*pb.DeletedUrlBatchResponse, error) {
+	var response pb.DeletedUrlBatchResponse
+	responseList, errorCode := CommonServer{}.GetDeletedURLs(s.storage, GetUserIDFromContext(ctx), s.baseURL)
+	if errorCode != http.StatusOK && errorCode != http.StatusNoContent {
+		return &response, status.Error(codes.Internal, "Got error while getting deleted URLs for user")
+	}
+	for _, responseItem := range responseList {
+		deletedURL := pb.DeletedUrlBatchResponse_DeletedUrl{ShortUrl: responseItem.ShortURL, OriginalUrl: responseItem.OriginalURL}
+		if responseItem.DeletedAt != nil {
+			deletedURL.DeletedAt = timestamppb.New(*responseItem.DeletedAt)
+		}
+		response.Response = append(response.Response, &deletedURL)
+	}
+	return &response, nil
+}
+
+// RestoreURLs - grpc handler, restores deleted URLs for given User
+func (s *ShortenderServer) RestoreURLs(ctx context.Context, in *pb.RestoreUrlsRequest) (*pb.RestoreUrlsResponse, error) {
+	URLsToRestore := make([]string, 0, len(in.UrlsToRestore))
+	for _, URL := range in.UrlsToRestore {
+		URLsToRestore = append(URLsToRestore, URL.ShortUrl)
+	}
+	restored, errorMessage, errorCode := CommonServer{}.RestoreURLs(s.storage, URLsToRestore, GetUserIDFromContext(ctx))
+	if errorCode != 0 {
+		return &pb.RestoreUrlsResponse{}, status.Error(codes.Internal, errorMessage)
+	}
+	return &pb.RestoreUrlsResponse{Restored: int32(restored)}, nil
 }
 
 // Ping - grpc handler, checks than connection to storage is alive
@@ -141,19 +468,11 @@ func (s *ShortenderServer) Ping(ctx context.Context, in *emptypb.Empty) (*emptyp
 	return &emptypb.Empty{}, nil
 }
 
-// GetStats - grpc handler for statistics, return all URLs and Users number
+// GetStats - grpc handler for statistics, return all URLs and Users number.
+// Client IP is checked against trusted subnet, X-Real-IP metadata is trusted from TrustedProxies only.
 func (s *ShortenderServer) GetStats(ctx context.Context, in *emptypb.Empty) (*pb.StatsResponse, error) {
 	var response pb.StatsResponse
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Error(codes.Internal, "Couldn't get data from context")
-	}
-	values := md.Get("X-Real-IP")
-	if len(values) == 0 {
-		return nil, status.Error(codes.Internal, "Couldn't get X-Real-IP from context")
-	}
-	ipStr := values[0]
-	requestIP := net.ParseIP(ipStr)
+	requestIP := net.ParseIP(GetClientIPFromContext(ctx))
 	if requestIP == nil {
 		return nil, status.Error(codes.Aborted, "Got bad IP address")
 	}
@@ -171,5 +490,10 @@ func (s *ShortenderServer) GetStats(ctx context.Context, in *emptypb.Empty) (*pb
 	}
 	response.Urls = int32(stats.URLs)
 	response.Users = int32(stats.Users)
+	response.Purged = int32(stats.Purged)
+	if stats.Cache != nil {
+		response.CacheHits = stats.Cache.Hits
+		response.CacheMisses = stats.Cache.Misses
+	}
 	return &response, nil
 }
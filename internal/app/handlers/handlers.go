@@ -2,14 +2,22 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"io"
 	"log"
 	"math"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
+	"github.com/tank4gun/gourlshortener/internal/app/render"
 	"github.com/tank4gun/gourlshortener/internal/app/storage"
 	"github.com/tank4gun/gourlshortener/internal/app/types"
 	"github.com/tank4gun/gourlshortener/internal/app/varprs"
@@ -21,13 +29,37 @@ type HandlerWithStorage struct {
 	storage storage.IRepository
 	// baseURL - base URL for shorten URLs, i.e. http://localhost:8080
 	baseURL string
-	// deleteChannel - channel for RequestToDelete object to process
-	deleteChannel chan types.RequestToDelete
+	// deleteQueue - queue for URLs deletion requests
+	deleteQueue *DeleteQueue
+	// clickRecorder - recorder for redirect clicks, clicks aren't recorded if nil
+	clickRecorder *ClickRecorder
+	// passwordLimiter - limiter of password attempts for protected URLs
+	passwordLimiter *PasswordLimiter
+	// loginLimiter - limiter of account login attempts
+	loginLimiter *PasswordLimiter
+	// keyring - keys for identity tokens issued on registration and login
+	keyring *auth.Keyring
+	// tokenTTL - time for which identity token is valid
+	tokenTTL time.Duration
 }
 
 // NewHandlerWithStorage creates HandlerWithStorage object with given storage.
-func NewHandlerWithStorage(storageVal storage.IRepository, deleteChannel chan types.RequestToDelete) *HandlerWithStorage {
-	return &HandlerWithStorage{storage: storageVal, baseURL: varprs.BaseURL, deleteChannel: deleteChannel}
+func NewHandlerWithStorage(storageVal storage.IRepository, deleteQueue *DeleteQueue, clickRecorder *ClickRecorder) *HandlerWithStorage {
+	return &HandlerWithStorage{
+		storage:         storageVal,
+		baseURL:         varprs.BaseURL,
+		deleteQueue:     deleteQueue,
+		clickRecorder:   clickRecorder,
+		passwordLimiter: NewPasswordLimiter(PasswordAttempts, PasswordAttemptsPerURL, PasswordAttemptsWindow),
+		loginLimiter:    NewPasswordLimiter(PasswordAttempts, LoginAttemptsPerAccount, PasswordAttemptsWindow),
+		keyring:         auth.DefaultKeyring(),
+		tokenTTL:        auth.DefaultTokenTTL,
+	}
+}
+
+// SetPasswordLimiter sets limiter of password attempts for protected URLs, i.e. the one shared with gRPC server
+func (strg *HandlerWithStorage) SetPasswordLimiter(limiter *PasswordLimiter) {
+	strg.passwordLimiter = limiter
 }
 
 // ConvertShortURLBatchToIDs converts shorten URLs to list with IDs
@@ -41,63 +73,259 @@ func ConvertShortURLBatchToIDs(shortURLBatch []string) []uint {
 
 // ConvertShortURLToID converts shorten URLs to its ID
 func ConvertShortURLToID(shortURL string) uint {
-	var id uint = 0
-	var charToIndex = make(map[int32]uint)
-	for index, val := range storage.AllPossibleChars {
-		charToIndex[val] = uint(index)
-	}
-	for index, value := range shortURL {
-		id += charToIndex[value] * uint(math.Pow(62, float64(len(shortURL)-index-1)))
-	}
-	return id
-}
-
-// DeleteURLsDaemon runs daemon for urls deletion.
-func (strg *HandlerWithStorage) DeleteURLsDaemon() {
-	for reqToDelete := range strg.deleteChannel {
-		log.Printf("Got request to delete %d", reqToDelete.UserID)
-		URLIDs := ConvertShortURLBatchToIDs(reqToDelete.URLs)
-		log.Printf("Got URLIDs %v", URLIDs)
-		_ = strg.storage.MarkBatchAsDeleted(URLIDs, reqToDelete.UserID)
-	}
-}
-
-//
-//// CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage.
-//func (strg *HandlerWithStorage) CreateShortURLBatch(batchURLs []storage.BatchURLRequest, userID uint) ([]storage.BatchURLResponse, string, int) {
-//	currInd, indErr := strg.storage.GetNextIndex()
-//	if indErr != nil {
-//		return make([]storage.BatchURLResponse, 0), "Bad next index", http.StatusInternalServerError
-//	}
-//	var resultURLs []storage.BatchURLResponse
-//	var insertURLs []string
-//	for index, URLrequest := range batchURLs {
-//		shortURL := storage.CreateShortURL(currInd + uint(index))
-//		insertURLs = append(insertURLs, URLrequest.OriginalURL)
-//		resultURL := storage.BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: strg.baseURL + shortURL}
-//		resultURLs = append(resultURLs, resultURL)
-//	}
-//	err := strg.storage.InsertBatchValues(insertURLs, currInd, userID)
-//	if err != nil {
-//		return make([]storage.BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
-//	}
-//	return resultURLs, "", 0
-//}
-
-// GetURLByIDHandler returns full URL by its ID if it exists
+	return storage.ConvertShortURLToID(shortURL)
+}
+
+// ResolveShortURL converts shorten URL to its ID, custom alias is resolved before ID based short URL
+func ResolveShortURL(strg storage.IRepository, shortURL string) uint {
+	if id, errCode := strg.GetIDByAlias(shortURL); errCode == 0 {
+		return id
+	}
+	return ConvertShortURLToID(shortURL)
+}
+
+// ValidateCustomAlias checks that custom alias consists of allowed chars and has allowed length
+func ValidateCustomAlias(alias string) (errorMessage string, errorCode int) {
+	if len(alias) < storage.MinAliasLength || len(alias) > storage.MaxAliasLength {
+		return fmt.Sprintf("Alias length should be from %d to %d", storage.MinAliasLength, storage.MaxAliasLength), http.StatusBadRequest
+	}
+	for _, char := range alias {
+		if !strings.ContainsRune(storage.AllPossibleChars, char) && char != '-' && char != '_' {
+			return fmt.Sprintf("Alias contains not allowed char %q", char), http.StatusBadRequest
+		}
+	}
+	return "", 0
+}
+
+// ValidateExpiration checks that only one of expires_at and ttl_seconds is set and it points to the future
+func ValidateExpiration(expiresAt *time.Time, ttlSeconds int64) (errorMessage string, errorCode int) {
+	if expiresAt != nil && ttlSeconds != 0 {
+		return "Only one of expires_at and ttl_seconds should be set", http.StatusBadRequest
+	}
+	if ttlSeconds < 0 {
+		return "ttl_seconds should be positive", http.StatusBadRequest
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "expires_at should be in the future", http.StatusBadRequest
+	}
+	return "", 0
+}
+
+// ValidateRedirectType checks that redirect type is one of supported redirect status codes, 0 stands for default one
+func ValidateRedirectType(redirectType int) (errorMessage string, errorCode int) {
+	switch redirectType {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return "", 0
+	}
+	return "redirect_type should be one of 301, 302, 307 or 308", http.StatusBadRequest
+}
+
+// ValidateTitle checks that URL title isn't longer than storage.MaxTitleLength
+func ValidateTitle(title string) (errorMessage string, errorCode int) {
+	if len(title) > storage.MaxTitleLength {
+		return fmt.Sprintf("Title length should be at most %d", storage.MaxTitleLength), http.StatusBadRequest
+	}
+	return "", 0
+}
+
+// ValidateMaxClicks checks that max clicks isn't negative, 0 stands for not limited URL
+func ValidateMaxClicks(maxClicks int) (errorMessage string, errorCode int) {
+	if maxClicks < 0 {
+		return "max_clicks should be positive", http.StatusBadRequest
+	}
+	return "", 0
+}
+
+// HashURLPassword checks URL password length and returns its salted hash, empty hash is returned for empty password
+func HashURLPassword(password string) (hash string, errorMessage string, errorCode int) {
+	if password == "" {
+		return "", "", 0
+	}
+	if len(password) > storage.MaxPasswordLength {
+		return "", fmt.Sprintf("Password length should be at most %d", storage.MaxPasswordLength), http.StatusBadRequest
+	}
+	hash, err := storage.HashPassword(password)
+	if err != nil {
+		return "", "Couldn't hash password", http.StatusInternalServerError
+	}
+	return hash, "", 0
+}
+
+// PreviewSuffix - suffix of short URL which shows preview page instead of redirect, i.e. /abc+
+const PreviewSuffix = "+"
+
+// IsPreviewRequest checks whether preview page should be shown instead of redirect.
+// Preview is requested with PreviewSuffix or preview=1 query param, URLs with RequireInterstitial
+// are always previewed until continue button adds confirmed=1 query param or password form is passed.
+func IsPreviewRequest(r *http.Request, shortURL string, url storage.URL, passwordVerified bool) bool {
+	if strings.HasSuffix(shortURL, PreviewSuffix) || r.URL.Query().Get("preview") == "1" {
+		return true
+	}
+	return url.RequireInterstitial && r.URL.Query().Get("confirmed") != "1" && !passwordVerified
+}
+
+// RedirectStatus returns redirect status code for URL, default one from varprs is used if URL has no redirect type
+func RedirectStatus(url storage.URL) int {
+	if url.RedirectType != 0 {
+		return url.RedirectType
+	}
+	if varprs.RedirectType != 0 {
+		return varprs.RedirectType
+	}
+	return http.StatusTemporaryRedirect
+}
+
+// SetRedirectCacheHeaders sets Cache-Control and Expires headers for redirect with given status.
+// Only permanent redirects of never expiring URLs are cached for varprs.RedirectMaxAge, temporary redirects
+// are expected to be retargeted by URL owner and expiring URLs stop working at some point, so they aren't stored at all.
+// Redirects of password protected URLs and URLs limited with max clicks aren't stored too, otherwise they would be checked only once.
+func SetRedirectCacheHeaders(header http.Header, url storage.URL, redirectStatus int, now time.Time) {
+	permanent := redirectStatus == http.StatusMovedPermanently || redirectStatus == http.StatusPermanentRedirect
+	if !permanent || !url.ExpiresAt.IsZero() || url.IsProtected() || url.MaxClicks > 0 {
+		header.Set("Cache-Control", "no-store")
+		header.Set("Expires", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+		return
+	}
+	maxAge := varprs.RedirectMaxAge
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+	header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int64(maxAge.Seconds())))
+	header.Set("Expires", now.Add(maxAge).UTC().Format(http.TimeFormat))
+}
+
+// ExpiredURLsSweeper runs daemon which marks expired urls as deleted every interval until ctx is done.
+func (strg *HandlerWithStorage) ExpiredURLsSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			count, err := strg.storage.MarkExpiredAsDeleted(now)
+			if err != nil {
+				log.Printf("Couldn't mark expired urls as deleted, %s", err.Error())
+				continue
+			}
+			if count > 0 {
+				log.Printf("Marked %d expired urls as deleted", count)
+			}
+		}
+	}
+}
+
+// DeletedURLsPurger runs daemon which purges URLs deleted more than retention ago every interval until ctx is done.
+func (strg *HandlerWithStorage) DeletedURLsPurger(ctx context.Context, interval time.Duration, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			count, err := strg.storage.PurgeDeleted(now.Add(-retention))
+			if err != nil {
+				log.Printf("Couldn't purge deleted urls, %s", err.Error())
+				continue
+			}
+			if count > 0 {
+				log.Printf("Purged %d deleted urls", count)
+			}
+		}
+	}
+}
+
+// GetURLByIDHandler redirects to full URL by its ID if it exists with URL redirect status and caching headers.
+// HEAD request gets the same headers, but neither click is recorded nor clicks limit is consumed for it.
 func (strg *HandlerWithStorage) GetURLByIDHandler(w http.ResponseWriter, r *http.Request) {
 	shortURL := chi.URLParam(r, "id")
-	originalURL, errorCode := CommonServer{}.GetURLByID(strg.storage, shortURL, r.Context().Value(types.UserIDCtxName).(uint))
+	id := ResolveShortURL(strg.storage, strings.TrimSuffix(shortURL, PreviewSuffix))
+	url, errorCode := strg.storage.GetURLByKeyAndUserID(id, r.Context().Value(types.UserIDCtxName).(uint))
 	if errorCode != 0 {
 		http.Error(w, "Couldn't find url for id "+shortURL, errorCode)
 		return
 	}
-	w.Header().Set("Location", originalURL)
-	w.WriteHeader(http.StatusTemporaryRedirect)
+	passwordVerified := false
+	if url.IsProtected() {
+		password := r.Header.Get(LinkPasswordHeader)
+		fromHeader := password != ""
+		if !fromHeader {
+			password = r.PostFormValue("password")
+		}
+		retryAfter, errorMessage, errorCode := CommonServer{}.CheckURLPassword(strg.passwordLimiter, id, url, ClientIP(r), password)
+		if errorCode == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		}
+		if errorCode != 0 && fromHeader {
+			http.Error(w, errorMessage, errorCode)
+			return
+		}
+		if errorCode != 0 {
+			if password == "" {
+				errorMessage = ""
+			}
+			strg.renderPasswordForm(w, errorCode, shortURL, errorMessage)
+			return
+		}
+		passwordVerified = true
+	}
+	if IsPreviewRequest(r, shortURL, url, passwordVerified) {
+		strg.renderPreview(w, strings.TrimSuffix(shortURL, PreviewSuffix), url)
+		return
+	}
+	now := time.Now()
+	if r.Method != http.MethodHead {
+		if errorMessage, errorCode := (CommonServer{}).ConsumeClick(strg.storage, id, url); errorCode != 0 {
+			http.Error(w, errorMessage, errorCode)
+			return
+		}
+		strg.clickRecorder.Record(storage.Click{
+			URLID:        id,
+			Time:         now,
+			Referrer:     r.Referer(),
+			UserAgent:    r.UserAgent(),
+			ClientSubnet: ClientSubnet(r),
+		})
+	}
+	redirectStatus := RedirectStatus(url)
+	if r.Method == http.MethodPost {
+		// redirect after password form should be followed with GET, 307 and 308 would send password to original URL
+		redirectStatus = http.StatusSeeOther
+	}
+	SetRedirectCacheHeaders(w.Header(), url, redirectStatus, now)
+	w.Header().Set("Location", url.Value)
+	w.WriteHeader(redirectStatus)
 	var empty []byte
 	w.Write(empty)
 }
 
+// renderPreview renders preview page for URL, preview page isn't counted as click and isn't cached
+func (strg *HandlerWithStorage) renderPreview(w http.ResponseWriter, shortURL string, url storage.URL) {
+	page := render.PreviewPage{
+		ShortURL:    strg.baseURL + shortURL,
+		OriginalURL: url.Value,
+		Title:       url.Title,
+		CreatedAt:   url.CreatedAt,
+		ContinueURL: strg.baseURL + shortURL,
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	if err := render.Render(w, http.StatusOK, render.PreviewTemplate, page); err != nil {
+		log.Printf("Couldn't render preview for %s: %s", shortURL, err)
+		http.Error(w, "Couldn't render preview page", http.StatusInternalServerError)
+	}
+}
+
+// renderPasswordForm renders password form for protected URL with given status and error message
+func (strg *HandlerWithStorage) renderPasswordForm(w http.ResponseWriter, status int, shortURL string, errorMessage string) {
+	page := render.PasswordPage{ShortURL: strg.baseURL + shortURL, Error: errorMessage}
+	w.Header().Set("Cache-Control", "no-store")
+	if err := render.Render(w, status, render.PasswordTemplate, page); err != nil {
+		log.Printf("Couldn't render password form for %s: %s", shortURL, err)
+		http.Error(w, "Couldn't render password form", http.StatusInternalServerError)
+	}
+}
+
 // CreateShortURLHandler converts URL from request body to shorten one and saves into db
 func (strg *HandlerWithStorage) CreateShortURLHandler(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
@@ -107,7 +335,7 @@ func (strg *HandlerWithStorage) CreateShortURLHandler(w http.ResponseWriter, r *
 		return
 	}
 	shortURL, errorMessage, errorCode := CommonServer{}.CreateShortURL(strg.storage, string(url), r.Context().Value(types.UserIDCtxName).(uint), strg.baseURL)
-	if errorCode != 0 && errorCode != http.StatusConflict {
+	if errorCode != 0 && (errorCode != http.StatusConflict || shortURL == "") {
 		http.Error(w, errorMessage, errorCode)
 		return
 	}
@@ -140,12 +368,30 @@ func (strg *HandlerWithStorage) CreateShortenURLFromBodyHandler(w http.ResponseW
 		http.Error(w, "Got empty url in Body", http.StatusUnprocessableEntity)
 		return
 	}
-	shortURL, errorMessage, errorCode := strg.storage.CreateShortURLByURL(requestURL.URL, r.Context().Value(types.UserIDCtxName).(uint))
-	if errorCode != 0 && errorCode != http.StatusConflict {
+	if errorMessage, errorCode := ValidateExpiration(requestURL.ExpiresAt, requestURL.TTLSeconds); errorCode != 0 {
 		http.Error(w, errorMessage, errorCode)
 		return
 	}
-	resultResponse := types.ShortenURLResponse{URL: strg.baseURL + shortURL}
+	passwordHash, errorMessage, errorCode := HashURLPassword(requestURL.Password)
+	if errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	options := storage.URLOptions{
+		Alias:               requestURL.CustomAlias,
+		ExpiresAt:           storage.ExpirationTime(requestURL.ExpiresAt, requestURL.TTLSeconds, time.Now()),
+		RedirectType:        requestURL.RedirectType,
+		Title:               requestURL.Title,
+		RequireInterstitial: requestURL.RequireInterstitial,
+		PasswordHash:        passwordHash,
+		MaxClicks:           requestURL.MaxClicks,
+	}
+	shortURL, errorMessage, errorCode := CommonServer{}.CreateShortURLWithOptions(strg.storage, requestURL.URL, options, r.Context().Value(types.UserIDCtxName).(uint), strg.baseURL)
+	if errorCode != 0 && (errorCode != http.StatusConflict || shortURL == "") {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	resultResponse := types.ShortenURLResponse{URL: shortURL}
 	w.Header().Set("Content-Type", "application/json")
 	if errorCode == http.StatusConflict {
 		w.WriteHeader(http.StatusConflict)
@@ -197,14 +443,33 @@ func (strg *HandlerWithStorage) CreateShortenURLBatchHandler(w http.ResponseWrit
 	}
 }
 
-// GetAllURLsHandler return all URLs for given User
+// GetAllURLsHandler return page of URLs for given User.
+// Page is set by limit, cursor, order (asc or desc by creation time) and filter (original URL substring) query params,
+// cursor for the next page is returned in X-Next-Cursor header.
 func (strg *HandlerWithStorage) GetAllURLsHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(types.UserIDCtxName).(uint)
-	responseList, errorCode := CommonServer{}.GetAllURLs(strg.storage, userID, strg.baseURL)
+	params := r.URL.Query()
+	limit := 0
+	if params.Get("limit") != "" {
+		var err error
+		if limit, err = strconv.Atoi(params.Get("limit")); err != nil || limit <= 0 {
+			http.Error(w, "limit should be positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	query, errorMessage, errorCode := ParseURLsPageQuery(limit, params.Get("cursor"), params.Get("order"), params.Get("filter"))
+	if errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	responseList, nextCursor, errorCode := CommonServer{}.GetURLsPage(strg.storage, userID, query, strg.baseURL)
 	if errorCode != http.StatusOK {
 		w.WriteHeader(errorCode)
 		return
 	}
+	if nextCursor != "" {
+		w.Header().Set(NextCursorHeader, nextCursor)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if responseMarshalled, err := json.Marshal(responseList); err == nil {
@@ -219,6 +484,94 @@ func (strg *HandlerWithStorage) GetAllURLsHandler(w http.ResponseWriter, r *http
 	}
 }
 
+// GetURLStatsHandler returns total and per-day clicks for URL owned by User
+func (strg *HandlerWithStorage) GetURLStatsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	shortURL := chi.URLParam(r, "id")
+	stats, errorCode := CommonServer{}.GetURLStats(strg.storage, shortURL, userID)
+	if errorCode != http.StatusOK {
+		http.Error(w, "Couldn't find url for id "+shortURL, errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if statsMarshalled, err := json.Marshal(stats); err == nil {
+		_, err = w.Write(statsMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// UpdateURLHandler changes original URL of short URL owned by given User
+func (strg *HandlerWithStorage) UpdateURLHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	defer r.Body.Close()
+	jsonBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var requestURL types.UpdateURLRequest
+	err = json.Unmarshal(jsonBody, &requestURL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if requestURL.URL == "" {
+		http.Error(w, "Got empty url in Body", http.StatusUnprocessableEntity)
+		return
+	}
+	response, errorMessage, errorCode := CommonServer{}.UpdateURL(strg.storage, chi.URLParam(r, "id"), requestURL.URL, userID, strg.baseURL)
+	if errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if responseMarshalled, err := json.Marshal(response); err == nil {
+		_, err = w.Write(responseMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetURLHistoryHandler returns changes of original URL of short URL owned by given User, the oldest go first
+func (strg *HandlerWithStorage) GetURLHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	shortURL := chi.URLParam(r, "id")
+	history, errorCode := CommonServer{}.GetURLHistory(strg.storage, shortURL, userID)
+	if errorCode == http.StatusNoContent {
+		w.WriteHeader(errorCode)
+		return
+	}
+	if errorCode != http.StatusOK {
+		http.Error(w, "Couldn't find url for id "+shortURL, errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if historyMarshalled, err := json.Marshal(history); err == nil {
+		_, err = w.Write(historyMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 // DeleteURLsHandler removes all URLs for given User
 func (strg *HandlerWithStorage) DeleteURLsHandler(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value(types.UserIDCtxName).(uint)
@@ -235,10 +588,103 @@ func (strg *HandlerWithStorage) DeleteURLsHandler(w http.ResponseWriter, r *http
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	CommonServer{}.DeleteURLs(strg.deleteChannel, URLsToDelete, userID)
+	job, errorMessage, errorCode := CommonServer{}.DeleteURLs(strg.deleteQueue, URLsToDelete, userID)
+	if errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/api/user/urls/delete-jobs/"+job.ID)
 	w.WriteHeader(http.StatusAccepted)
-	var empty []byte
-	w.Write(empty)
+	if responseMarshalled, err := json.Marshal(job); err == nil {
+		_, err = w.Write(responseMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetDeleteJobHandler returns status of URLs deletion job which belongs to given User
+func (strg *HandlerWithStorage) GetDeleteJobHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	job, errorCode := CommonServer{}.GetDeleteJob(strg.deleteQueue, chi.URLParam(r, "id"), userID)
+	if errorCode != 0 {
+		http.Error(w, "Delete job not found", errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if responseMarshalled, err := json.Marshal(job); err == nil {
+		_, err = w.Write(responseMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetDeletedURLsHandler return deleted URLs for given User with their deletion time
+func (strg *HandlerWithStorage) GetDeletedURLsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	responseList, errorCode := CommonServer{}.GetDeletedURLs(strg.storage, userID, strg.baseURL)
+	if errorCode != http.StatusOK {
+		w.WriteHeader(errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if responseMarshalled, err := json.Marshal(responseList); err == nil {
+		_, err = w.Write(responseMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// RestoreURLsHandler restores deleted URLs from request body which belong to given User
+func (strg *HandlerWithStorage) RestoreURLsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	defer r.Body.Close()
+	jsonBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// URLsToRestore - list with URLs to restore
+	var URLsToRestore []string
+	err = json.Unmarshal(jsonBody, &URLsToRestore)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	restored, errorMessage, errorCode := CommonServer{}.RestoreURLs(strg.storage, URLsToRestore, userID)
+	if errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if responseMarshalled, err := json.Marshal(types.RestoreURLsResponse{Restored: restored}); err == nil {
+		_, err = w.Write(responseMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 }
 
 // PingHandler checks than connection to storage is alive
@@ -253,21 +699,26 @@ func (strg *HandlerWithStorage) PingHandler(w http.ResponseWriter, r *http.Reque
 	w.Write(empty)
 }
 
-// GetStatsHandler return all URLs and Users number
-func (strg *HandlerWithStorage) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
-	ipStr := r.Header.Get("X-Real-IP")
+// CheckTrustedIP checks that request IP belongs to trusted subnet
+func CheckTrustedIP(ipStr string) (errorMessage string, errorCode int) {
 	requestIP := net.ParseIP(ipStr)
 	if requestIP == nil {
-		http.Error(w, "Got bad IP address", http.StatusForbidden)
-		return
+		return "Got bad IP address", http.StatusForbidden
 	}
 	_, ipNet, err := net.ParseCIDR(varprs.TrustedSubnet)
 	if err != nil {
-		http.Error(w, "Couldn't parse ipMask", http.StatusInternalServerError)
-		return
+		return "Couldn't parse ipMask", http.StatusInternalServerError
 	}
 	if !ipNet.Contains(requestIP) {
-		http.Error(w, "Got bad IP address", http.StatusForbidden)
+		return "Got bad IP address", http.StatusForbidden
+	}
+	return "", 0
+}
+
+// GetStatsHandler return all URLs and Users number
+func (strg *HandlerWithStorage) GetStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if errorMessage, errorCode := CheckTrustedIP(r.Header.Get("X-Real-IP")); errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
 		return
 	}
 	stats, errCode := CommonServer{}.GetStats(strg.storage)
@@ -288,3 +739,28 @@ func (strg *HandlerWithStorage) GetStatsHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 }
+
+// CompactStorageHandler runs storage compaction on demand, X-Real-IP header is trusted from TrustedProxies only
+func (strg *HandlerWithStorage) CompactStorageHandler(w http.ResponseWriter, r *http.Request) {
+	if errorMessage, errorCode := CheckTrustedIP(ClientIP(r)); errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	compactor, ok := strg.storage.(storage.ICompactor)
+	if !ok {
+		http.Error(w, "Storage doesn't support compaction", http.StatusNotImplemented)
+		return
+	}
+	err := compactor.Compact()
+	if errors.Is(err, storage.ErrCompactionNotSupported) {
+		http.Error(w, "Storage doesn't support compaction", http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	var empty []byte
+	w.Write(empty)
+}
@@ -3,22 +3,41 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
 	"github.com/tank4gun/gourlshortener/internal/app/mocks"
 	"github.com/tank4gun/gourlshortener/internal/app/storage"
 	"github.com/tank4gun/gourlshortener/internal/app/types"
 	"github.com/tank4gun/gourlshortener/internal/app/varprs"
+	pb "github.com/tank4gun/gourlshortener/internal/pkg/proto"
 )
 
 type wantResponse struct {
@@ -31,8 +50,9 @@ func TestGetURLByIDHandler(t *testing.T) {
 	tests := []struct {
 		name           string
 		want           wantResponse
-		currentStorage storage.Storage
+		currentStorage *storage.Storage
 		url            string
+		cacheControl   string
 	}{
 		{
 			name: "short_url_exists",
@@ -41,8 +61,9 @@ func TestGetURLByIDHandler(t *testing.T) {
 				"http://ya.ru",
 				"",
 			},
-			currentStorage: storage.Storage{InternalStorage: map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2},
+			currentStorage: storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}}, map[uint][]uint{1: {1}}, 2),
 			url:            "/b",
+			cacheControl:   "no-store",
 		},
 		{
 			name: "short_url_does_not_exists",
@@ -51,26 +72,356 @@ func TestGetURLByIDHandler(t *testing.T) {
 				"",
 				"",
 			},
-			currentStorage: storage.Storage{InternalStorage: map[uint]storage.URL{2: {Value: "http://ya.ru", Deleted: false}}, NextIndex: 3},
+			currentStorage: storage.NewMemoryStorage(map[uint]storage.URL{2: {Value: "http://ya.ru", Deleted: false}}, map[uint][]uint{}, 3),
 			url:            "/b",
 		},
+		{
+			name: "custom_alias_exists",
+			want: wantResponse{
+				http.StatusTemporaryRedirect,
+				"http://ya.ru",
+				"",
+			},
+			currentStorage: storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", Alias: "spring-sale"}}, map[uint][]uint{1: {1}}, 2),
+			url:            "/spring-sale",
+			cacheControl:   "no-store",
+		},
+		{
+			name: "short_url_expired",
+			want: wantResponse{
+				http.StatusGone,
+				"",
+				"",
+			},
+			currentStorage: storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", ExpiresAt: time.Now().Add(-time.Second)}}, map[uint][]uint{1: {1}}, 2),
+			url:            "/b",
+		},
+		{
+			name: "permanent_redirect",
+			want: wantResponse{
+				http.StatusMovedPermanently,
+				"http://ya.ru",
+				"",
+			},
+			currentStorage: storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", RedirectType: http.StatusMovedPermanently}}, map[uint][]uint{1: {1}}, 2),
+			url:            "/b",
+			cacheControl:   "public, max-age=86400",
+		},
+		{
+			name: "permanent_redirect_of_expiring_url",
+			want: wantResponse{
+				http.StatusPermanentRedirect,
+				"http://ya.ru",
+				"",
+			},
+			currentStorage: storage.NewMemoryStorage(
+				map[uint]storage.URL{1: {Value: "http://ya.ru", RedirectType: http.StatusPermanentRedirect, ExpiresAt: time.Now().Add(time.Hour)}},
+				map[uint][]uint{1: {1}},
+				2,
+			),
+			url:          "/b",
+			cacheControl: "no-store",
+		},
+		{
+			name: "found_redirect",
+			want: wantResponse{
+				http.StatusFound,
+				"http://ya.ru",
+				"",
+			},
+			currentStorage: storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", RedirectType: http.StatusFound}}, map[uint][]uint{1: {1}}, 2),
+			url:            "/b",
+			cacheControl:   "no-store",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, method := range []string{http.MethodGet, http.MethodHead} {
+				clickRecorder := NewClickRecorder(tt.currentStorage)
+				request := httptest.NewRequest(method, tt.url, nil)
+				rctx := chi.NewRouteContext()
+				rctx.URLParams.Add("id", tt.url[1:])
+				request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+				ctx := context.WithValue(request.Context(), types.UserIDCtxName, uint(1))
+				request = request.WithContext(ctx)
+				w := httptest.NewRecorder()
+				handler := http.HandlerFunc(NewHandlerWithStorage(tt.currentStorage, nil, clickRecorder).GetURLByIDHandler)
+				handler.ServeHTTP(w, request)
+				result := w.Result()
+				result.Body.Close()
+				assert.Equal(t, tt.want.code, result.StatusCode)
+				value := result.Header.Get("Location")
+				assert.Equal(t, tt.want.headerContent, value)
+				if tt.cacheControl == "" {
+					continue
+				}
+				assert.Equal(t, tt.cacheControl, result.Header.Get("Cache-Control"))
+				_, err := http.ParseTime(result.Header.Get("Expires"))
+				assert.Nil(t, err)
+				go clickRecorder.Run()
+				clickRecorder.Close()
+				// GET request goes first and its click is kept in storage, HEAD request doesn't add one
+				stats, _ := tt.currentStorage.GetClickStats(1, 1)
+				assert.Equal(t, 1, stats.Total)
+			}
+		})
+	}
+}
+
+func TestGetURLByIDHandler_Preview(t *testing.T) {
+	createdAt := time.Date(2026, time.March, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		url          storage.URL
+		id           string
+		query        string
+		wantCode     int
+		wantContains []string
+	}{
+		{
+			name:         "preview_suffix",
+			url:          storage.URL{Value: "http://ya.ru", Title: "Spring sale", CreatedAt: createdAt},
+			id:           "b+",
+			wantCode:     http.StatusOK,
+			wantContains: []string{"<title>Spring sale</title>", "<code>http://ya.ru</code>", "Created 2026-03-01 10:30 UTC", `action="http://localhost:8080/b"`},
+		},
+		{
+			name:         "preview_query",
+			url:          storage.URL{Value: "http://ya.ru", Alias: "spring-sale"},
+			id:           "spring-sale",
+			query:        "?preview=1",
+			wantCode:     http.StatusOK,
+			wantContains: []string{"<title>Link preview</title>", "<code>http://localhost:8080/spring-sale</code>", `action="http://localhost:8080/spring-sale"`},
+		},
+		{
+			name:         "require_interstitial",
+			url:          storage.URL{Value: "http://ya.ru", RequireInterstitial: true},
+			id:           "b",
+			wantCode:     http.StatusOK,
+			wantContains: []string{"<code>http://ya.ru</code>", `name="confirmed" value="1"`},
+		},
+		{
+			name:     "require_interstitial_confirmed",
+			url:      storage.URL{Value: "http://ya.ru", RequireInterstitial: true},
+			id:       "b",
+			query:    "?confirmed=1",
+			wantCode: http.StatusTemporaryRedirect,
+		},
+		{
+			name:         "title_is_escaped",
+			url:          storage.URL{Value: "http://ya.ru/?q=<b>", Title: "<script>alert(1)</script>"},
+			id:           "b+",
+			wantCode:     http.StatusOK,
+			wantContains: []string{"&lt;script&gt;alert(1)&lt;/script&gt;", "http://ya.ru/?q=&lt;b&gt;"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			request := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			strg := storage.NewMemoryStorage(map[uint]storage.URL{1: tt.url}, map[uint][]uint{1: {1}}, 2)
+			clickRecorder := NewClickRecorder(strg)
+			request := httptest.NewRequest(http.MethodGet, "/"+tt.id+tt.query, nil)
 			rctx := chi.NewRouteContext()
-			rctx.URLParams.Add("id", tt.url[1:])
+			rctx.URLParams.Add("id", tt.id)
 			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
-			ctx := context.WithValue(request.Context(), types.UserIDCtxName, uint(1))
-			request = request.WithContext(ctx)
+			request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(1)))
 			w := httptest.NewRecorder()
-			handler := http.HandlerFunc(NewHandlerWithStorage(&tt.currentStorage, make(chan types.RequestToDelete, 10)).GetURLByIDHandler)
-			handler.ServeHTTP(w, request)
+			handlerWithStorage := NewHandlerWithStorage(strg, nil, clickRecorder)
+			handlerWithStorage.baseURL = "http://localhost:8080/"
+			http.HandlerFunc(handlerWithStorage.GetURLByIDHandler).ServeHTTP(w, request)
 			result := w.Result()
 			defer result.Body.Close()
-			assert.Equal(t, tt.want.code, result.StatusCode)
-			value := result.Header.Get("Location")
-			assert.Equal(t, tt.want.headerContent, value)
+			assert.Equal(t, tt.wantCode, result.StatusCode)
+			body, err := io.ReadAll(result.Body)
+			require.NoError(t, err)
+			for _, content := range tt.wantContains {
+				assert.Contains(t, string(body), content)
+			}
+			go clickRecorder.Run()
+			clickRecorder.Close()
+			stats, _ := strg.GetClickStats(1, 1)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, "text/html; charset=utf-8", result.Header.Get("Content-Type"))
+				assert.Equal(t, "no-store", result.Header.Get("Cache-Control"))
+				assert.NotContains(t, string(body), "<script>")
+				assert.Equal(t, 0, stats.Total)
+			} else {
+				assert.Equal(t, tt.url.Value, result.Header.Get("Location"))
+				assert.Equal(t, 1, stats.Total)
+			}
+		})
+	}
+}
+
+func TestGetURLByIDHandler_Password(t *testing.T) {
+	passwordHash, err := storage.HashPassword("secret")
+	require.NoError(t, err)
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", PasswordHash: passwordHash}}, map[uint][]uint{1: {1}}, 2)
+	handlerWithStorage := NewHandlerWithStorage(strg, nil, nil)
+	handlerWithStorage.baseURL = "http://localhost:8080/"
+	send := func(method string, header string, form string, remoteAddr string) *http.Response {
+		var body io.Reader
+		if form != "" {
+			body = strings.NewReader(url.Values{"password": {form}}.Encode())
+		}
+		request := httptest.NewRequest(method, "/b", body)
+		request.RemoteAddr = remoteAddr
+		if form != "" {
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if header != "" {
+			request.Header.Set(LinkPasswordHeader, header)
+		}
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "b")
+		request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+		request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(1)))
+		w := httptest.NewRecorder()
+		http.HandlerFunc(handlerWithStorage.GetURLByIDHandler).ServeHTTP(w, request)
+		return w.Result()
+	}
+
+	result := send(http.MethodGet, "", "", "10.0.0.1:1234")
+	body, _ := io.ReadAll(result.Body)
+	result.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.Contains(t, string(body), `<form method="post" action="http://localhost:8080/b">`)
+	assert.NotContains(t, string(body), "http://ya.ru")
+	assert.NotContains(t, string(body), "Wrong password")
+
+	result = send(http.MethodGet, "secret", "", "10.0.0.1:1234")
+	result.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, result.StatusCode)
+	assert.Equal(t, "http://ya.ru", result.Header.Get("Location"))
+	assert.Equal(t, "no-store", result.Header.Get("Cache-Control"))
+
+	result = send(http.MethodPost, "", "secret", "10.0.0.1:1234")
+	result.Body.Close()
+	assert.Equal(t, http.StatusSeeOther, result.StatusCode)
+	assert.Equal(t, "http://ya.ru", result.Header.Get("Location"))
+
+	result = send(http.MethodPost, "", "wrong", "10.0.0.1:1234")
+	body, _ = io.ReadAll(result.Body)
+	result.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.Contains(t, string(body), "Wrong password")
+
+	for i := 1; i < PasswordAttempts; i++ {
+		result = send(http.MethodGet, "wrong", "", "10.0.0.1:1234")
+		result.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	}
+	result = send(http.MethodGet, "secret", "", "10.0.0.1:1234")
+	result.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, result.StatusCode)
+	assert.NotEmpty(t, result.Header.Get("Retry-After"))
+
+	result = send(http.MethodGet, "secret", "", "10.0.0.2:1234")
+	result.Body.Close()
+	assert.Equal(t, http.StatusTemporaryRedirect, result.StatusCode)
+}
+
+func TestGetURLByIDHandler_MaxClicks(t *testing.T) {
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", MaxClicks: 1, RedirectType: http.StatusMovedPermanently}}, map[uint][]uint{1: {1}}, 2)
+	handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).GetURLByIDHandler)
+	for _, tt := range []struct {
+		method string
+		code   int
+	}{
+		{http.MethodHead, http.StatusMovedPermanently},
+		{http.MethodGet, http.StatusMovedPermanently},
+		{http.MethodGet, http.StatusGone},
+		{http.MethodHead, http.StatusGone},
+	} {
+		request := httptest.NewRequest(tt.method, "/b", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "b")
+		request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+		request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(1)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		result := w.Result()
+		result.Body.Close()
+		assert.Equal(t, tt.code, result.StatusCode, tt.method)
+		if tt.code == http.StatusMovedPermanently {
+			assert.Equal(t, "no-store", result.Header.Get("Cache-Control"))
+		}
+	}
+}
+
+func TestPasswordLimiter(t *testing.T) {
+	limiter := NewPasswordLimiter(2, 10, time.Minute)
+	now := time.Now()
+	for i := 0; i < 2; i++ {
+		_, ok := limiter.Attempt("1", "client", now)
+		assert.True(t, ok)
+	}
+	retryAfter, ok := limiter.Attempt("1", "client", now.Add(10*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 50*time.Second, retryAfter)
+
+	_, ok = limiter.Attempt("2", "client", now)
+	assert.True(t, ok)
+	_, ok = limiter.Attempt("1", "other", now)
+	assert.True(t, ok)
+	_, ok = limiter.Attempt("1", "client", now.Add(time.Minute))
+	assert.True(t, ok)
+
+	limiter.Reset("2", "client")
+	for i := 0; i < 2; i++ {
+		_, ok = limiter.Attempt("2", "client", now)
+		assert.True(t, ok)
+	}
+}
+
+func TestPasswordLimiter_URLCeiling(t *testing.T) {
+	limiter := NewPasswordLimiter(2, 3, time.Minute)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		_, ok := limiter.Attempt("1", fmt.Sprintf("10.0.0.%d", i), now)
+		assert.True(t, ok)
+	}
+	retryAfter, ok := limiter.Attempt("1", "10.0.0.100", now.Add(20*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 40*time.Second, retryAfter)
+
+	limiter.Reset("1", "10.0.0.0")
+	_, ok = limiter.Attempt("1", "10.0.0.0", now)
+	assert.False(t, ok)
+	_, ok = limiter.Attempt("2", "10.0.0.100", now)
+	assert.True(t, ok)
+	_, ok = limiter.Attempt("1", "10.0.0.100", now.Add(time.Minute))
+	assert.True(t, ok)
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies string
+		realIP         string
+		remoteAddr     string
+		want           string
+	}{
+		{"no_header", "10.0.0.0/8", "", "10.0.0.1:1234", "10.0.0.1"},
+		{"trusted_proxy", "127.0.0.1/32, 10.0.0.0/8", "192.168.1.15", "10.0.0.1:1234", "192.168.1.15"},
+		{"untrusted_proxy", "10.0.0.0/8", "192.168.1.15", "172.16.0.1:1234", "172.16.0.1"},
+		{"no_trusted_proxies", "", "192.168.1.15", "10.0.0.1:1234", "10.0.0.1"},
+	}
+	defer func() { varprs.TrustedProxies = "" }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			varprs.TrustedProxies = tt.trustedProxies
+			request := httptest.NewRequest(http.MethodGet, "/b", nil)
+			request.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				request.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, ClientIP(request))
+
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(strings.Split(tt.remoteAddr, ":")[0]), Port: 1234}})
+			if tt.realIP != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("X-Real-IP", tt.realIP))
+			}
+			assert.Equal(t, tt.want, GetClientIPFromContext(ctx))
 		})
 	}
 }
@@ -79,8 +430,8 @@ func TestCreateShortURLHandler(t *testing.T) {
 	tests := []struct {
 		name            string
 		want            wantResponse
-		previousStorage storage.Storage
-		resultStorage   storage.Storage
+		previousStorage *storage.Storage
+		resultStorage   *storage.Storage
 		url             string
 	}{
 		{
@@ -90,13 +441,9 @@ func TestCreateShortURLHandler(t *testing.T) {
 				"",
 				"http://localhost:8080/b",
 			},
-			previousStorage: storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			resultStorage: storage.Storage{
-				InternalStorage: map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
-			url: "http://ya.ru",
+			previousStorage: storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			resultStorage:   storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}}, map[uint][]uint{1: {1}}, 2),
+			url:             "http://ya.ru",
 		},
 	}
 	for _, tt := range tests {
@@ -108,7 +455,7 @@ func TestCreateShortURLHandler(t *testing.T) {
 			w := httptest.NewRecorder()
 			ctx := context.WithValue(request.Context(), types.UserIDCtxName, uint(1))
 			request = request.WithContext(ctx)
-			handler := http.HandlerFunc(NewHandlerWithStorage(&tt.previousStorage, make(chan types.RequestToDelete, 10)).CreateShortURLHandler)
+			handler := http.HandlerFunc(NewHandlerWithStorage(tt.previousStorage, nil, nil).CreateShortURLHandler)
 			handler.ServeHTTP(w, request)
 			result := w.Result()
 			assert.Equal(t, tt.want.code, result.StatusCode)
@@ -116,8 +463,14 @@ func TestCreateShortURLHandler(t *testing.T) {
 			responseBody, err := io.ReadAll(result.Body)
 			assert.Nil(t, err)
 			assert.Equal(t, tt.want.responseContent, string(responseBody))
-			assert.Equal(t, tt.previousStorage.InternalStorage, tt.resultStorage.InternalStorage)
-			assert.Equal(t, tt.previousStorage.NextIndex, tt.resultStorage.NextIndex)
+			URLs := tt.previousStorage.Snapshot().URLs
+			for key, url := range URLs {
+				assert.False(t, url.CreatedAt.IsZero())
+				url.CreatedAt = time.Time{}
+				URLs[key] = url
+			}
+			assert.Equal(t, tt.resultStorage.Snapshot().URLs, URLs)
+			assert.Equal(t, tt.previousStorage.Snapshot().NextIndex, tt.resultStorage.Snapshot().NextIndex)
 		})
 	}
 }
@@ -139,10 +492,25 @@ func TestConvertShortURLToID(t *testing.T) {
 			2,
 		},
 		{
-			"cb_to_125",
+			"cb_to_64",
 			"cb",
+			64,
+		},
+		{
+			"bc_to_125",
+			"bc",
 			125,
 		},
+		{
+			"y_to_22",
+			"y",
+			22,
+		},
+		{
+			"w_to_24",
+			"w",
+			24,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -173,6 +541,11 @@ func TestCreateShortURL(t *testing.T) {
 			125,
 			"bc",
 		},
+		{
+			"22_to_y",
+			22,
+			"y",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -186,8 +559,8 @@ func TestCreateShortenURLFromBodyHandler(t *testing.T) {
 	tests := []struct {
 		name            string
 		want            wantResponse
-		previousStorage storage.Storage
-		resultStorage   storage.Storage
+		previousStorage *storage.Storage
+		resultStorage   *storage.Storage
 		requestBody     string
 	}{
 		{
@@ -197,12 +570,8 @@ func TestCreateShortenURLFromBodyHandler(t *testing.T) {
 				"text/plain; charset=utf-8",
 				"",
 			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
 			"some_bad_input",
 		},
 		{
@@ -212,12 +581,8 @@ func TestCreateShortenURLFromBodyHandler(t *testing.T) {
 				"text/plain; charset=utf-8",
 				"",
 			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
 			`{"ur1": "some_bad_input"}`,
 		},
 		{
@@ -227,13 +592,108 @@ func TestCreateShortenURLFromBodyHandler(t *testing.T) {
 				"application/json",
 				`{"result":"http://localhost:8080/b"}`,
 			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}}, map[uint][]uint{1: {1}}, 2),
+			`{"url": "http://ya.ru"}`,
+		},
+		{
+			"success_with_redirect_type",
+			wantResponse{
+				http.StatusCreated,
+				"application/json",
+				`{"result":"http://localhost:8080/b"}`,
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", RedirectType: http.StatusMovedPermanently}}, map[uint][]uint{1: {1}}, 2),
+			`{"url": "http://ya.ru", "redirect_type": 301}`,
+		},
+		{
+			"bad_redirect_type",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"redirect_type should be one of 301, 302, 307 or 308\n",
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			`{"url": "http://ya.ru", "redirect_type": 303}`,
+		},
+		{
+			"too_long_title",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"Title length should be at most 256\n",
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			`{"url": "http://ya.ru", "title": "` + strings.Repeat("a", storage.MaxTitleLength+1) + `"}`,
+		},
+		{
+			"custom_alias",
+			wantResponse{
+				http.StatusCreated,
+				"application/json",
+				`{"result":"http://localhost:8080/spring-sale"}`,
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", Alias: "spring-sale"}}, map[uint][]uint{1: {1}}, 2),
+			`{"url": "http://ya.ru", "custom_alias": "spring-sale"}`,
+		},
+		{
+			"bad_custom_alias",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"",
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			`{"url": "http://ya.ru", "custom_alias": "spring/sale"}`,
+		},
+		{
+			"ttl_seconds",
+			wantResponse{
+				http.StatusCreated,
+				"application/json",
+				`{"result":"http://localhost:8080/b"}`,
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}}, map[uint][]uint{1: {1}}, 2),
+			`{"url": "http://ya.ru", "ttl_seconds": 3600}`,
+		},
+		{
+			"expires_at_in_past",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"",
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			`{"url": "http://ya.ru", "expires_at": "2020-01-01T00:00:00Z"}`,
+		},
+		{
+			"both_expires_at_and_ttl_seconds",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"",
 			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			`{"url": "http://ya.ru", "expires_at": "2100-01-01T00:00:00Z", "ttl_seconds": 3600}`,
+		},
+		{
+			"custom_alias_taken",
+			wantResponse{
+				http.StatusConflict,
+				"text/plain; charset=utf-8",
+				"",
 			},
-			`{"url": "http://ya.ru"}`,
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://google.com", Alias: "spring-sale"}}, map[uint][]uint{1: {1}}, 2),
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://google.com", Alias: "spring-sale"}}, map[uint][]uint{1: {1}}, 2),
+			`{"url": "http://ya.ru", "custom_alias": "spring-sale"}`,
 		},
 	}
 	for _, tt := range tests {
@@ -243,7 +703,7 @@ func TestCreateShortenURLFromBodyHandler(t *testing.T) {
 			w := httptest.NewRecorder()
 			ctx := context.WithValue(request.Context(), types.UserIDCtxName, uint(1))
 			request = request.WithContext(ctx)
-			handler := http.HandlerFunc(NewHandlerWithStorage(&tt.previousStorage, make(chan types.RequestToDelete, 10)).CreateShortenURLFromBodyHandler)
+			handler := http.HandlerFunc(NewHandlerWithStorage(tt.previousStorage, nil, nil).CreateShortenURLFromBodyHandler)
 			handler.ServeHTTP(w, request)
 			result := w.Result()
 			assert.Equal(t, tt.want.code, result.StatusCode)
@@ -266,8 +726,8 @@ func TestCreateShortenURLBatchHandler(t *testing.T) {
 	tests := []struct {
 		name            string
 		want            wantResponse
-		previousStorage storage.Storage
-		resultStorage   storage.Storage
+		previousStorage *storage.Storage
+		resultStorage   *storage.Storage
 		requestBody     string
 	}{
 		{
@@ -277,12 +737,8 @@ func TestCreateShortenURLBatchHandler(t *testing.T) {
 				"text/plain; charset=utf-8",
 				"",
 			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
 			"some_bad_input",
 		},
 		{
@@ -292,14 +748,21 @@ func TestCreateShortenURLBatchHandler(t *testing.T) {
 				"application/json",
 				`[{"correlation_id":"123","short_url":"http://localhost:8080/b"},{"correlation_id":"256","short_url":"http://localhost:8080/c"}]`,
 			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			storage.Storage{
-				InternalStorage: map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}, 2: {Value: "http://ya1.ru", Deleted: false}}, UserIDToURLID: map[uint][]uint{1: {1, 2}}, NextIndex: 3, Encoder: nil, Decoder: nil,
-			},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: false}, 2: {Value: "http://ya1.ru", Deleted: false}}, map[uint][]uint{1: {1, 2}}, 3),
 			`[{"correlation_id": "123", "original_url": "http://ya.ru"}, {"correlation_id": "256", "original_url": "http://ya1.ru"}]`,
 		},
+		{
+			"conflicts",
+			wantResponse{
+				http.StatusCreated,
+				"application/json",
+				`[{"correlation_id":"1","short_url":"http://localhost:8080/b","conflict":true},{"correlation_id":"2","short_url":"http://localhost:8080/c"},{"correlation_id":"3","short_url":"http://localhost:8080/c","conflict":true}]`,
+			},
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}}, map[uint][]uint{2: {1}}, 2),
+			storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}, 2: {Value: "http://ya1.ru"}}, map[uint][]uint{1: {2}, 2: {1}}, 3),
+			`[{"correlation_id": "1", "original_url": "http://ya.ru"}, {"correlation_id": "2", "original_url": "http://ya1.ru"}, {"correlation_id": "3", "original_url": "http://ya1.ru"}]`,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -308,7 +771,7 @@ func TestCreateShortenURLBatchHandler(t *testing.T) {
 			w := httptest.NewRecorder()
 			ctx := context.WithValue(request.Context(), types.UserIDCtxName, uint(1))
 			request = request.WithContext(ctx)
-			handler := http.HandlerFunc(NewHandlerWithStorage(&tt.previousStorage, make(chan types.RequestToDelete, 10)).CreateShortenURLBatchHandler)
+			handler := http.HandlerFunc(NewHandlerWithStorage(tt.previousStorage, nil, nil).CreateShortenURLBatchHandler)
 			handler.ServeHTTP(w, request)
 			result := w.Result()
 			assert.Equal(t, tt.want.code, result.StatusCode)
@@ -354,7 +817,7 @@ func TestPingHandler(t *testing.T) {
 			defer ctrl.Finish()
 			repo := mocks.NewMockIRepository(ctrl)
 			repo.EXPECT().Ping().Return(tc.pingResponse)
-			handler := http.HandlerFunc(NewHandlerWithStorage(repo, make(chan types.RequestToDelete, 10)).PingHandler)
+			handler := http.HandlerFunc(NewHandlerWithStorage(repo, nil, nil).PingHandler)
 			handler.ServeHTTP(w, request)
 			result := w.Result()
 			defer result.Body.Close()
@@ -366,11 +829,14 @@ func TestPingHandler(t *testing.T) {
 
 func TestGetAllURLsHandler(t *testing.T) {
 	tt := []struct {
-		name         string
-		want         wantResponse
-		userID       uint
-		mockResponse []storage.FullInfoURLResponse
-		mockError    int
+		name           string
+		want           wantResponse
+		target         string
+		userID         uint
+		query          *storage.URLsPageQuery
+		mockResponse   storage.URLsPage
+		mockError      int
+		wantNextCursor string
 	}{
 		{
 			"success_urls",
@@ -379,9 +845,40 @@ func TestGetAllURLsHandler(t *testing.T) {
 				"application/json",
 				`[{"short_url":"http://localhost:8080/b","original_url":"http://ya.ru"}]`,
 			},
+			"/api/user/urls",
+			1,
+			&storage.URLsPageQuery{},
+			storage.URLsPage{URLs: []storage.FullInfoURLResponse{{ShortURL: "http://localhost:8080/b", OriginalURL: "http://ya.ru"}}},
+			200,
+			"",
+		},
+		{
+			"success_page_with_next_cursor",
+			wantResponse{
+				http.StatusOK,
+				"application/json",
+				`[{"short_url":"http://localhost:8080/d","original_url":"http://yandex.ru"}]`,
+			},
+			"/api/user/urls?limit=1&cursor=" + EncodeCursor(5) + "&order=desc&filter=ya",
 			1,
-			[]storage.FullInfoURLResponse{{ShortURL: "http://localhost:8080/b", OriginalURL: "http://ya.ru"}},
+			&storage.URLsPageQuery{Limit: 1, After: 5, Desc: true, Filter: "ya"},
+			storage.URLsPage{URLs: []storage.FullInfoURLResponse{{ShortURL: "http://localhost:8080/d", OriginalURL: "http://yandex.ru"}}, NextAfter: 3},
 			200,
+			EncodeCursor(3),
+		},
+		{
+			"no_urls",
+			wantResponse{
+				http.StatusNoContent,
+				"",
+				"",
+			},
+			"/api/user/urls",
+			1,
+			&storage.URLsPageQuery{},
+			storage.URLsPage{},
+			204,
+			"",
 		},
 		{
 			"error_urls",
@@ -390,22 +887,83 @@ func TestGetAllURLsHandler(t *testing.T) {
 				"",
 				"",
 			},
+			"/api/user/urls",
 			1,
-			nil,
+			&storage.URLsPageQuery{},
+			storage.URLsPage{},
 			500,
+			"",
+		},
+		{
+			"bad_limit",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"limit should be positive integer\n",
+			},
+			"/api/user/urls?limit=-1",
+			1,
+			nil,
+			storage.URLsPage{},
+			0,
+			"",
+		},
+		{
+			"too_big_limit",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"limit should be between 1 and 1000\n",
+			},
+			"/api/user/urls?limit=1001",
+			1,
+			nil,
+			storage.URLsPage{},
+			0,
+			"",
+		},
+		{
+			"bad_cursor",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"Invalid cursor\n",
+			},
+			"/api/user/urls?cursor=abc",
+			1,
+			nil,
+			storage.URLsPage{},
+			0,
+			"",
+		},
+		{
+			"bad_order",
+			wantResponse{
+				http.StatusBadRequest,
+				"text/plain; charset=utf-8",
+				"order should be asc or desc\n",
+			},
+			"/api/user/urls?order=random",
+			1,
+			nil,
+			storage.URLsPage{},
+			0,
+			"",
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			request := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+			request := httptest.NewRequest(http.MethodGet, tc.target, nil)
 			w := httptest.NewRecorder()
 			ctx := context.WithValue(request.Context(), types.UserIDCtxName, tc.userID)
 			request = request.WithContext(ctx)
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			repo := mocks.NewMockIRepository(ctrl)
-			repo.EXPECT().GetAllURLsByUserID(tc.userID, "http://localhost:8080/").Return(tc.mockResponse, tc.mockError)
-			handler := http.HandlerFunc(NewHandlerWithStorage(repo, make(chan types.RequestToDelete, 10)).GetAllURLsHandler)
+			if tc.query != nil {
+				repo.EXPECT().GetURLsPageByUserID(tc.userID, *tc.query, "http://localhost:8080/").Return(tc.mockResponse, tc.mockError)
+			}
+			handler := http.HandlerFunc(NewHandlerWithStorage(repo, nil, nil).GetAllURLsHandler)
 			handler.ServeHTTP(w, request)
 			result := w.Result()
 			defer result.Body.Close()
@@ -414,39 +972,302 @@ func TestGetAllURLsHandler(t *testing.T) {
 			assert.Equal(t, tc.want.code, result.StatusCode)
 			assert.Equal(t, tc.want.headerContent, result.Header.Get("Content-Type"))
 			assert.Equal(t, tc.want.responseContent, string(responseBody))
+			assert.Equal(t, tc.wantNextCursor, result.Header.Get(NextCursorHeader))
 		})
 	}
 }
 
 func TestDeleteURLsHandler(t *testing.T) {
-	tt := []struct {
-		name         string
-		want         wantResponse
-		userID       uint
-		mockResponse []storage.FullInfoURLResponse
-		requestBody  string
-	}{
-		{
-			"success_urls",
-			wantResponse{
-				http.StatusAccepted,
-				"",
-				``,
-			},
-			1,
-			[]storage.FullInfoURLResponse{{ShortURL: "http://localhost:8080/b", OriginalURL: "http://ya.ru"}},
-			`["http://ya.ru"]`,
-		},
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}, 2: {Value: "http://google.com"}}, map[uint][]uint{1: {1}, 2: {2}}, 3)
+	deleteQueue, err := NewDeleteQueue(strg, filepath.Join(t.TempDir(), "deletions.txt"))
+	require.NoError(t, err)
+	handlerWithStorage := NewHandlerWithStorage(strg, deleteQueue, nil)
+	sendRequest := func(request *http.Request, userID uint, handler http.HandlerFunc) (*http.Response, []byte) {
+		request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, userID))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		result := w.Result()
+		defer result.Body.Close()
+		responseBody, err := io.ReadAll(result.Body)
+		assert.Nil(t, err)
+		return result, responseBody
 	}
-	for _, tc := range tt {
-		t.Run(tc.name, func(t *testing.T) {
-			request := httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewReader([]byte(tc.requestBody)))
-			w := httptest.NewRecorder()
-			ctx := context.WithValue(request.Context(), types.UserIDCtxName, tc.userID)
-			request = request.WithContext(ctx)
-			handler := http.HandlerFunc(NewHandlerWithStorage(&storage.Storage{
-				InternalStorage: map[uint]storage.URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			}, make(chan types.RequestToDelete, 10)).DeleteURLsHandler)
+	getJob := func(ID string, userID uint) (*http.Response, types.DeleteJobResponse) {
+		request := httptest.NewRequest(http.MethodGet, "/api/user/urls/delete-jobs/"+ID, nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("id", ID)
+		request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, routeCtx))
+		result, responseBody := sendRequest(request, userID, handlerWithStorage.GetDeleteJobHandler)
+		var job types.DeleteJobResponse
+		if result.StatusCode == http.StatusOK {
+			assert.Nil(t, json.Unmarshal(responseBody, &job))
+		}
+		return result, job
+	}
+
+	result, responseBody := sendRequest(httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewReader([]byte(`["b", "c"]`))), 1, handlerWithStorage.DeleteURLsHandler)
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+	var job types.DeleteJobResponse
+	require.Nil(t, json.Unmarshal(responseBody, &job))
+	assert.Equal(t, types.DeleteJobQueued, job.Status)
+	assert.Equal(t, 2, job.URLs)
+	assert.Equal(t, "/api/user/urls/delete-jobs/"+job.ID, result.Header.Get("Location"))
+	result, queuedJob := getJob(job.ID, 1)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, types.DeleteJobQueued, queuedJob.Status)
+
+	go deleteQueue.Run()
+	assert.Eventually(t, func() bool {
+		_, job := getJob(job.ID, 1)
+		return job.Status == types.DeleteJobDone
+	}, time.Second, time.Millisecond)
+	_, doneJob := getJob(job.ID, 1)
+	assert.Equal(t, 1, doneJob.Attempts)
+	assert.NotNil(t, doneJob.FinishedAt)
+	assert.True(t, strg.Snapshot().URLs[1].Deleted)
+	assert.False(t, strg.Snapshot().URLs[2].Deleted)
+	result, _ = getJob(job.ID, 2)
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+	result, _ = getJob("unknown", 1)
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+
+	require.Nil(t, deleteQueue.Close(context.Background()))
+	result, _ = sendRequest(httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewReader([]byte(`["c"]`))), 2, handlerWithStorage.DeleteURLsHandler)
+	assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+}
+
+func TestDeleteQueue_RetryAndRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "deletions.txt")
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIRepository(ctrl)
+	repo.EXPECT().GetIDByAlias(gomock.Any()).Return(uint(0), http.StatusNotFound).AnyTimes()
+	repo.EXPECT().MarkBatchAsDeleted([]uint{1}, uint(1)).Return(errors.New("storage is unavailable")).Times(2)
+	repo.EXPECT().MarkBatchAsDeleted([]uint{1}, uint(1)).Return(nil)
+	repo.EXPECT().MarkBatchAsDeleted([]uint{2}, uint(1)).Return(errors.New("storage is unavailable")).AnyTimes()
+
+	deleteQueue, err := NewDeleteQueue(repo, filename)
+	require.NoError(t, err)
+	deleteQueue.retryDelay = time.Millisecond
+	retried, err := deleteQueue.Enqueue([]string{"b"}, 1)
+	require.NoError(t, err)
+	go deleteQueue.Run()
+	assert.Eventually(t, func() bool {
+		job, _ := deleteQueue.Job(retried.ID, 1)
+		return job.Status == types.DeleteJobDone
+	}, time.Second, time.Millisecond)
+	job, _ := deleteQueue.Job(retried.ID, 1)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Equal(t, "", job.Error)
+
+	// Job which is still retried on shutdown is left in file and processed after restart
+	deleteQueue.retryDelay = time.Hour
+	aborted, err := deleteQueue.Enqueue([]string{"c"}, 1)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		job, _ := deleteQueue.Job(aborted.ID, 1)
+		return job.Attempts == 1
+	}, time.Second, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Nil(t, deleteQueue.Close(ctx))
+
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{2: {Value: "http://ya.ru"}}, map[uint][]uint{1: {2}}, 3)
+	deleteQueue, err = NewDeleteQueue(strg, filename)
+	require.NoError(t, err)
+	job, ok := deleteQueue.Job(retried.ID, 1)
+	assert.True(t, ok)
+	assert.Equal(t, types.DeleteJobDone, job.Status)
+	go deleteQueue.Run()
+	require.Nil(t, deleteQueue.Close(context.Background()))
+	job, _ = deleteQueue.Job(aborted.ID, 1)
+	assert.Equal(t, types.DeleteJobDone, job.Status)
+	assert.True(t, strg.Snapshot().URLs[2].Deleted)
+}
+
+func TestDeleteQueue_CompactOnFinish(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "deletions.txt")
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1)
+	deleteQueue, err := NewDeleteQueue(strg, filename)
+	require.NoError(t, err)
+	deleteQueue.compactionRecords = 10
+	go deleteQueue.Run()
+	IDs := make([]string, 0)
+	for i := 0; i < 20; i++ {
+		job, err := deleteQueue.Enqueue([]string{"b"}, 1)
+		require.NoError(t, err)
+		IDs = append(IDs, job.ID)
+		assert.Eventually(t, func() bool {
+			job, _ := deleteQueue.Job(job.ID, 1)
+			return job.Status == types.DeleteJobDone
+		}, time.Second, time.Millisecond)
+	}
+	require.Nil(t, deleteQueue.Close(context.Background()))
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	// Every job has creation and finish records until file is rewritten with single record per job
+	assert.Less(t, bytes.Count(data, []byte("\n")), 2*len(IDs))
+
+	deleteQueue, err = NewDeleteQueue(strg, filename)
+	require.NoError(t, err)
+	for _, ID := range IDs {
+		job, ok := deleteQueue.Job(ID, 1)
+		assert.True(t, ok)
+		assert.Equal(t, types.DeleteJobDone, job.Status)
+		assert.Equal(t, 1, job.URLs)
+	}
+}
+
+func TestRestoreURLsHandler(t *testing.T) {
+	tt := []struct {
+		name        string
+		want        wantResponse
+		userID      uint
+		requestBody string
+	}{
+		{
+			"success_restore",
+			wantResponse{http.StatusOK, "application/json", `{"restored":1}`},
+			1,
+			`["b", "c"]`,
+		},
+		{
+			"foreign_urls",
+			wantResponse{http.StatusOK, "application/json", `{"restored":0}`},
+			2,
+			`["b"]`,
+		},
+		{
+			"bad_body",
+			wantResponse{http.StatusBadRequest, "text/plain; charset=utf-8", "invalid character 'b' looking for beginning of value\n"},
+			1,
+			`bad`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			strg := storage.NewMemoryStorage(
+				map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: true}, 2: {Value: "http://google.com"}},
+				map[uint][]uint{1: {1, 2}},
+				3,
+			)
+			request := httptest.NewRequest(http.MethodPost, "/api/user/urls/restore", bytes.NewReader([]byte(tc.requestBody)))
+			w := httptest.NewRecorder()
+			ctx := context.WithValue(request.Context(), types.UserIDCtxName, tc.userID)
+			request = request.WithContext(ctx)
+			handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).RestoreURLsHandler)
+			handler.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+			responseBody, err := io.ReadAll(result.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want.code, result.StatusCode)
+			assert.Equal(t, tc.want.headerContent, result.Header.Get("Content-Type"))
+			assert.Equal(t, tc.want.responseContent, string(responseBody))
+		})
+	}
+}
+
+func TestGetDeletedURLsHandler(t *testing.T) {
+	deletedAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	tt := []struct {
+		name   string
+		want   wantResponse
+		userID uint
+	}{
+		{
+			"deleted_urls",
+			wantResponse{http.StatusOK, "application/json", `[{"short_url":"http://localhost:8080/b","original_url":"http://ya.ru","deleted_at":"2030-01-02T03:04:05Z"}]`},
+			1,
+		},
+		{
+			"empty_trash",
+			wantResponse{http.StatusNoContent, "", ``},
+			2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			strg := storage.NewMemoryStorage(
+				map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: true, DeletedAt: deletedAt}, 2: {Value: "http://google.com"}},
+				map[uint][]uint{1: {1}, 2: {2}},
+				3,
+			)
+			request := httptest.NewRequest(http.MethodGet, "/api/user/urls/trash", nil)
+			w := httptest.NewRecorder()
+			ctx := context.WithValue(request.Context(), types.UserIDCtxName, tc.userID)
+			request = request.WithContext(ctx)
+			handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).GetDeletedURLsHandler)
+			handler.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+			responseBody, err := io.ReadAll(result.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.want.code, result.StatusCode)
+			assert.Equal(t, tc.want.headerContent, result.Header.Get("Content-Type"))
+			assert.Equal(t, tc.want.responseContent, string(responseBody))
+		})
+	}
+}
+
+func TestCompactStorageHandler(t *testing.T) {
+	tt := []struct {
+		name       string
+		want       wantResponse
+		realIP     string
+		useMock    bool
+		trustedNet string
+		proxies    string
+	}{
+		{
+			"compaction_success",
+			wantResponse{http.StatusOK, "", ""},
+			"192.168.1.10",
+			false,
+			"192.168.1.1/24",
+			"192.0.2.1/32",
+		},
+		{
+			"untrusted_ip",
+			wantResponse{http.StatusForbidden, "text/plain; charset=utf-8", "Got bad IP address\n"},
+			"10.0.0.1",
+			false,
+			"192.168.1.1/24",
+			"192.0.2.1/32",
+		},
+		{
+			"header_from_untrusted_proxy",
+			wantResponse{http.StatusForbidden, "text/plain; charset=utf-8", "Got bad IP address\n"},
+			"192.168.1.10",
+			false,
+			"192.168.1.1/24",
+			"",
+		},
+		{
+			"compaction_not_supported",
+			wantResponse{http.StatusNotImplemented, "text/plain; charset=utf-8", "Storage doesn't support compaction\n"},
+			"192.168.1.10",
+			true,
+			"192.168.1.1/24",
+			"192.0.2.1/32",
+		},
+	}
+	defer func() { varprs.TrustedProxies = "" }()
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			varprs.TrustedSubnet = tc.trustedNet
+			varprs.TrustedProxies = tc.proxies
+			request := httptest.NewRequest(http.MethodPost, "/api/internal/compact", nil)
+			request.Header.Set("X-Real-IP", tc.realIP)
+			w := httptest.NewRecorder()
+			var repo storage.IRepository = storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1)
+			if tc.useMock {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				repo = mocks.NewMockIRepository(ctrl)
+			}
+			handler := http.HandlerFunc(NewHandlerWithStorage(repo, nil, nil).CompactStorageHandler)
 			handler.ServeHTTP(w, request)
 			result := w.Result()
 			defer result.Body.Close()
@@ -468,3 +1289,670 @@ func BenchmarkConvertShortURLToID(b *testing.B) {
 		}
 	}
 }
+
+func TestExpiredURLsSweeper(t *testing.T) {
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", ExpiresAt: time.Now()}}, map[uint][]uint{1: {1}}, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewHandlerWithStorage(strg, nil, nil).ExpiredURLsSweeper(ctx, time.Millisecond)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return strg.Snapshot().URLs[1].Deleted }, time.Second, time.Millisecond)
+	cancel()
+	<-done
+}
+
+func TestDeletedURLsPurger(t *testing.T) {
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru", Deleted: true, DeletedAt: time.Now().Add(-time.Hour)}}, map[uint][]uint{1: {1}}, 2)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		NewHandlerWithStorage(strg, nil, nil).DeletedURLsPurger(ctx, time.Millisecond, time.Minute)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		stats, _ := strg.GetStats()
+		return stats.Purged == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	_, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+}
+
+func TestClickRecorder(t *testing.T) {
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}}, map[uint][]uint{1: {1}}, 2)
+	recorder := NewClickRecorder(strg)
+	go recorder.Run()
+	handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, recorder).GetURLByIDHandler)
+	for i := 0; i < 3; i++ {
+		request := httptest.NewRequest(http.MethodGet, "/b", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "b")
+		request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+		request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(2)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, request)
+		assert.Equal(t, http.StatusTemporaryRedirect, w.Result().StatusCode)
+		w.Result().Body.Close()
+	}
+	recorder.Close()
+
+	stats, errCode := strg.GetClickStats(1, 1)
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, 3, stats.Total)
+}
+
+func TestGetURLStatsHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID uint
+		url    string
+		want   wantResponse
+	}{
+		{
+			"own_url",
+			1,
+			"b",
+			wantResponse{http.StatusOK, "application/json", `{"total":2,"days":[{"day":"2022-10-01","clicks":2}]}`},
+		},
+		{
+			"own_url_by_alias",
+			1,
+			"spring-sale",
+			wantResponse{http.StatusOK, "application/json", `{"total":0,"days":[]}`},
+		},
+		{
+			"foreign_url",
+			2,
+			"b",
+			wantResponse{http.StatusNotFound, "text/plain; charset=utf-8", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strg := storage.NewMemoryStorage(
+				map[uint]storage.URL{1: {Value: "http://ya.ru"}, 2: {Value: "http://google.com", Alias: "spring-sale"}},
+				map[uint][]uint{1: {1, 2}},
+				3,
+			)
+			day := time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC)
+			assert.Nil(t, strg.InsertClicks([]storage.Click{{URLID: 1, Time: day}, {URLID: 1, Time: day}}))
+			request := httptest.NewRequest(http.MethodGet, "/api/user/urls/"+tt.url+"/stats", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.url)
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+			request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, tt.userID))
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).GetURLStatsHandler)
+			handler.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.headerContent, result.Header.Get("Content-Type"))
+			if tt.want.code != http.StatusOK {
+				return
+			}
+			responseBody, err := io.ReadAll(result.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want.responseContent, string(responseBody))
+		})
+	}
+}
+
+func TestUpdateURLHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		userID uint
+		url    string
+		body   string
+		want   wantResponse
+	}{
+		{
+			"own_url",
+			1,
+			"b",
+			`{"url":"http://ya.ru/new"}`,
+			wantResponse{http.StatusOK, "application/json", `{"short_url":"http://localhost:8080/b","original_url":"http://ya.ru/new"}`},
+		},
+		{
+			"own_url_by_alias",
+			1,
+			"spring-sale",
+			`{"url":"http://google.com/new"}`,
+			wantResponse{http.StatusOK, "application/json", `{"short_url":"http://localhost:8080/spring-sale","original_url":"http://google.com/new"}`},
+		},
+		{
+			"foreign_url",
+			2,
+			"b",
+			`{"url":"http://ya.ru/new"}`,
+			wantResponse{http.StatusNotFound, "text/plain; charset=utf-8", "URL not found\n"},
+		},
+		{
+			"already_shortened_url",
+			1,
+			"b",
+			`{"url":"http://google.com"}`,
+			wantResponse{http.StatusConflict, "text/plain; charset=utf-8", "URL is already shortened\n"},
+		},
+		{
+			"deleted_url",
+			1,
+			"c",
+			`{"url":"http://ya.ru/new"}`,
+			wantResponse{http.StatusGone, "text/plain; charset=utf-8", "URL is deleted\n"},
+		},
+		{
+			"invalid_url",
+			1,
+			"b",
+			`{"url":"ya.ru"}`,
+			wantResponse{http.StatusBadRequest, "text/plain; charset=utf-8", "URL should be absolute http or https URL\n"},
+		},
+		{
+			"empty_url",
+			1,
+			"b",
+			`{}`,
+			wantResponse{http.StatusUnprocessableEntity, "text/plain; charset=utf-8", "Got empty url in Body\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strg := storage.NewMemoryStorage(
+				map[uint]storage.URL{1: {Value: "http://ya.ru"}, 2: {Value: "http://yandex.ru", Deleted: true}, 3: {Value: "http://google.com", Alias: "spring-sale"}},
+				map[uint][]uint{1: {1, 2, 3}},
+				4,
+			)
+			request := httptest.NewRequest(http.MethodPatch, "/api/user/urls/"+tt.url, strings.NewReader(tt.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.url)
+			request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+			request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, tt.userID))
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).UpdateURLHandler)
+			handler.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+			responseBody, err := io.ReadAll(result.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.headerContent, result.Header.Get("Content-Type"))
+			assert.Equal(t, tt.want.responseContent, string(responseBody))
+			if tt.want.code != http.StatusOK {
+				return
+			}
+			history, errCode := strg.GetURLHistory(ResolveShortURL(strg, tt.url), tt.userID)
+			assert.Equal(t, http.StatusOK, errCode)
+			assert.Equal(t, 1, len(history))
+		})
+	}
+}
+
+func TestGetURLHistoryHandler(t *testing.T) {
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}}, map[uint][]uint{1: {1}}, 2)
+	sendRequest := func(userID uint) (*http.Response, []byte) {
+		request := httptest.NewRequest(http.MethodGet, "/api/user/urls/b/history", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "b")
+		request = request.WithContext(context.WithValue(request.Context(), chi.RouteCtxKey, rctx))
+		request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, userID))
+		w := httptest.NewRecorder()
+		http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).GetURLHistoryHandler).ServeHTTP(w, request)
+		result := w.Result()
+		defer result.Body.Close()
+		responseBody, err := io.ReadAll(result.Body)
+		assert.Nil(t, err)
+		return result, responseBody
+	}
+
+	result, _ := sendRequest(1)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	_, errCode := strg.UpdateURLValue(1, "http://ya.ru/new", 1)
+	require.Equal(t, 0, errCode)
+	result, responseBody := sendRequest(1)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+	var history []storage.URLChange
+	require.Nil(t, json.Unmarshal(responseBody, &history))
+	require.Equal(t, 1, len(history))
+	assert.Equal(t, "http://ya.ru", history[0].OldValue)
+	assert.Equal(t, "http://ya.ru/new", history[0].NewValue)
+	result, _ = sendRequest(2)
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+}
+
+func TestClientSubnet(t *testing.T) {
+	tests := []struct {
+		name       string
+		realIP     string
+		remoteAddr string
+		want       string
+	}{
+		{"ipv4_from_header", "192.168.1.15", "10.0.0.1:1234", "192.168.1.0/24"},
+		{"ipv4_from_remote_addr", "", "10.0.0.1:1234", "10.0.0.0/24"},
+		{"ipv4_header_from_untrusted_proxy", "192.168.1.15", "172.16.0.1:1234", "172.16.0.0/24"},
+		{"ipv6", "2001:db8:1:2::1", "10.0.0.1:1234", "2001:db8:1::/48"},
+		{"bad_ip", "bad", "10.0.0.1:1234", ""},
+	}
+	varprs.TrustedProxies = "10.0.0.0/8"
+	defer func() { varprs.TrustedProxies = "" }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/b", nil)
+			request.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				request.Header.Set("X-Real-IP", tt.realIP)
+			}
+			assert.Equal(t, tt.want, ClientSubnet(request))
+		})
+	}
+}
+
+// testServerStream - grpc.ServerStream stub with given context
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context - returns stub context
+func (s testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestUserIDInterceptor(t *testing.T) {
+	now := time.Now()
+	keyring, err := auth.ParseKeyring("old:0123456789abcdef,new:fedcba9876543210", "new")
+	require.NoError(t, err)
+	otherKeyring, err := auth.ParseKeyring("new:another-secret-key", "")
+	require.NoError(t, err)
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1)
+	apiKey, _, errorCode := CommonServer{}.CreateAPIKey(strg, types.APIKeyRequest{Scopes: []string{auth.ScopeRead}}, 9)
+	require.Equal(t, 0, errorCode)
+	revokedKey, _, errorCode := CommonServer{}.CreateAPIKey(strg, types.APIKeyRequest{Scopes: []string{auth.ScopeRead}}, 9)
+	require.Equal(t, 0, errorCode)
+	require.Equal(t, 0, CommonServer{}.RevokeAPIKey(strg, revokedKey.ID, 9))
+	tests := []struct {
+		name           string
+		method         string
+		authorization  []string
+		expectedCode   codes.Code
+		expectedUserID uint
+	}{
+		{"bearer_token", pb.Shortender_GetAllURLs_FullMethodName, []string{"Bearer " + keyring.Issue(1<<40, now, time.Hour)}, codes.OK, 1 << 40},
+		{"raw_token", pb.Shortender_GetAllURLs_FullMethodName, []string{keyring.Issue(7, now, time.Hour)}, codes.OK, 7},
+		{"missing_token", pb.Shortender_GetAllURLs_FullMethodName, nil, codes.Unauthenticated, 0},
+		{"raw_user_id", pb.Shortender_GetAllURLs_FullMethodName, []string{"1"}, codes.Unauthenticated, 0},
+		{"forged_token", pb.Shortender_GetAllURLs_FullMethodName, []string{"Bearer " + otherKeyring.Issue(7, now, time.Hour)}, codes.Unauthenticated, 0},
+		{"expired_token", pb.Shortender_GetAllURLs_FullMethodName, []string{"Bearer " + keyring.Issue(7, now.Add(-2*time.Hour), time.Hour)}, codes.Unauthenticated, 0},
+		{"public_method", pb.Shortender_IssueToken_FullMethodName, nil, codes.OK, 0},
+		{"public_ping", pb.Shortender_Ping_FullMethodName, nil, codes.OK, 0},
+		{"public_stats", pb.Shortender_GetStats_FullMethodName, nil, codes.OK, 0},
+		{"api_key", pb.Shortender_ExportURLs_FullMethodName, []string{"Bearer " + apiKey.Key}, codes.OK, 9},
+		{"api_key_without_scope", pb.Shortender_DeleteURLs_FullMethodName, []string{"Bearer " + apiKey.Key}, codes.PermissionDenied, 0},
+		{"api_key_for_public_method", pb.Shortender_Ping_FullMethodName, []string{"Bearer " + apiKey.Key}, codes.OK, 0},
+		{"revoked_api_key", pb.Shortender_ExportURLs_FullMethodName, []string{"Bearer " + revokedKey.Key}, codes.Unauthenticated, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := metadata.MD{}
+			if tt.authorization != nil {
+				md.Set(AuthorizationMetadata, tt.authorization...)
+			}
+			ctx := metadata.NewIncomingContext(context.Background(), md)
+
+			var userID uint
+			_, err := UserIDInterceptor(keyring, strg)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				userID = GetUserIDFromContext(ctx)
+				return nil, nil
+			})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			assert.Equal(t, tt.expectedUserID, userID)
+
+			userID = 0
+			err = UserIDStreamInterceptor(keyring, strg)(nil, testServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method}, func(srv interface{}, stream grpc.ServerStream) error {
+				userID = GetUserIDFromContext(stream.Context())
+				return nil
+			})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			assert.Equal(t, tt.expectedUserID, userID)
+		})
+	}
+}
+
+func TestShortenderServer_IssueToken(t *testing.T) {
+	keyring, err := auth.ParseKeyring("old:0123456789abcdef,new:fedcba9876543210", "new")
+	require.NoError(t, err)
+	oldKeyring, err := auth.ParseKeyring("old:0123456789abcdef", "")
+	require.NoError(t, err)
+	server := NewShortenderServer(storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1), nil, keyring)
+
+	response, err := server.IssueToken(context.Background(), &pb.IssueTokenRequest{})
+	require.NoError(t, err)
+	identity, err := keyring.Verify(response.Token, time.Now())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, identity.UserID, uint(auth.MinUserID))
+	assert.Equal(t, identity.ExpiresAt.Unix(), response.ExpiresAt.AsTime().Unix())
+
+	response, err = server.IssueToken(context.Background(), &pb.IssueTokenRequest{Token: oldKeyring.Issue(42, time.Now(), time.Hour)})
+	require.NoError(t, err)
+	identity, err = keyring.Verify(response.Token, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), identity.UserID)
+	assert.Equal(t, "new", identity.KeyID)
+
+	_, err = server.IssueToken(context.Background(), &pb.IssueTokenRequest{Token: "forged"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// Legacy cookie key is public, so legacy cookies aren't re-issued even if HTTP server accepts them
+	keyring.AcceptLegacyUntil(time.Now().Add(time.Hour))
+	legacyID := []byte{0x00, 0x2a, 0x01, 0x02}
+	h := hmac.New(sha256.New, types.CookieKey)
+	h.Write(legacyID)
+	_, err = server.IssueToken(context.Background(), &pb.IssueTokenRequest{Token: hex.EncodeToString(append(legacyID, h.Sum(nil)...))})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestExportURLsHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   wantResponse
+	}{
+		{
+			"ndjson_by_default",
+			"/api/user/urls/export",
+			"",
+			wantResponse{
+				http.StatusOK,
+				"application/x-ndjson",
+				`{"short_url":"http://localhost:8080/b","original_url":"http://ya.ru","deleted":false}` + "\n" +
+					`{"short_url":"http://localhost:8080/spring-sale","original_url":"http://google.com","deleted":true,"expires_at":"2030-01-02T03:04:05Z"}` + "\n",
+			},
+		},
+		{
+			"csv_by_format",
+			"/api/user/urls/export?format=csv",
+			"application/x-ndjson",
+			wantResponse{
+				http.StatusOK,
+				"text/csv; charset=utf-8",
+				"short_url,original_url,deleted,expires_at\n" +
+					"http://localhost:8080/b,http://ya.ru,false,\n" +
+					"http://localhost:8080/spring-sale,http://google.com,true,2030-01-02T03:04:05Z\n",
+			},
+		},
+		{
+			"csv_by_accept",
+			"/api/user/urls/export",
+			"application/xml, text/csv;q=0.9",
+			wantResponse{
+				http.StatusOK,
+				"text/csv; charset=utf-8",
+				"short_url,original_url,deleted,expires_at\n" +
+					"http://localhost:8080/b,http://ya.ru,false,\n" +
+					"http://localhost:8080/spring-sale,http://google.com,true,2030-01-02T03:04:05Z\n",
+			},
+		},
+		{
+			"unknown_format",
+			"/api/user/urls/export?format=xml",
+			"",
+			wantResponse{http.StatusBadRequest, "text/plain; charset=utf-8", "format should be ndjson or csv\n"},
+		},
+		{
+			"not_acceptable",
+			"/api/user/urls/export",
+			"application/xml",
+			wantResponse{http.StatusNotAcceptable, "text/plain; charset=utf-8", "Only application/x-ndjson and text/csv are supported\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strg := storage.NewMemoryStorage(
+				map[uint]storage.URL{
+					1: {Value: "http://ya.ru"},
+					2: {Value: "http://google.com", Alias: "spring-sale", Deleted: true, ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)},
+					3: {Value: "http://yandex.ru"},
+				},
+				map[uint][]uint{1: {1, 2}, 2: {3}},
+				4,
+			)
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(1)))
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).ExportURLsHandler)
+			handler.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+			responseBody, err := io.ReadAll(result.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.headerContent, result.Header.Get("Content-Type"))
+			assert.Equal(t, tt.want.responseContent, string(responseBody))
+		})
+	}
+}
+
+func TestExportURLsHandler_StorageError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := mocks.NewMockIRepository(ctrl)
+	repo.EXPECT().IterateURLsByUserID(uint(1), "http://localhost:8080/", gomock.Any()).Return(errors.New("connection lost"))
+	request := httptest.NewRequest(http.MethodGet, "/api/user/urls/export", nil)
+	request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(1)))
+	w := httptest.NewRecorder()
+	handler := http.HandlerFunc(NewHandlerWithStorage(repo, nil, nil).ExportURLsHandler)
+	handler.ServeHTTP(w, request)
+	result := w.Result()
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, result.StatusCode)
+	assert.Equal(t, "", result.Header.Get("Content-Disposition"))
+}
+
+func TestImportURLsHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		want        wantResponse
+	}{
+		{
+			"csv_with_header",
+			"/api/shorten/import",
+			"text/csv",
+			"original_url\nhttp://ya.ru\nhttp://google.com\nnot a url\nhttp://a\"b\nhttp://google.com\nhttp://go.dev\n",
+			wantResponse{
+				http.StatusOK,
+				"application/json",
+				`{"created":2,"duplicates":2,"invalid":2,"rows":[` +
+					`{"row":1,"original_url":"http://ya.ru","status":"duplicate","short_url":"http://localhost:8080/b"},` +
+					`{"row":2,"original_url":"http://google.com","status":"created","short_url":"http://localhost:8080/c"},` +
+					`{"row":3,"original_url":"not a url","status":"invalid","error":"URL should be absolute http or https URL"},` +
+					`{"row":4,"original_url":"","status":"invalid","error":"Couldn't parse CSV row: bare \" in non-quoted-field"},` +
+					`{"row":5,"original_url":"http://google.com","status":"duplicate","short_url":"http://localhost:8080/c"},` +
+					`{"row":6,"original_url":"http://go.dev","status":"created","short_url":"http://localhost:8080/d"}]}`,
+			},
+		},
+		{
+			"ndjson_new_urls",
+			"/api/shorten/import",
+			"application/x-ndjson",
+			`{"original_url":"http://google.com"}` + "\n\n" + `{"original_url":"http://go.dev"}` + "\n" + `{"original_url":` + "\n",
+			wantResponse{
+				http.StatusOK,
+				"application/json",
+				`{"created":2,"duplicates":0,"invalid":1,"rows":[` +
+					`{"row":1,"original_url":"http://google.com","status":"created","short_url":"http://localhost:8080/c"},` +
+					`{"row":2,"original_url":"http://go.dev","status":"created","short_url":"http://localhost:8080/d"},` +
+					`{"row":3,"original_url":"","status":"invalid","error":"Couldn't parse JSON row"}]}`,
+			},
+		},
+		{
+			"format_param",
+			"/api/shorten/import?format=csv",
+			"application/octet-stream",
+			"http://ya.ru\n",
+			wantResponse{
+				http.StatusOK,
+				"application/json",
+				`{"created":0,"duplicates":1,"invalid":0,"rows":[{"row":1,"original_url":"http://ya.ru","status":"duplicate","short_url":"http://localhost:8080/b"}]}`,
+			},
+		},
+		{
+			"unsupported_content_type",
+			"/api/shorten/import",
+			"application/json",
+			`[]`,
+			wantResponse{http.StatusUnsupportedMediaType, "text/plain; charset=utf-8", "Only text/csv and application/x-ndjson uploads are supported\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strg := storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}}, map[uint][]uint{2: {1}}, 2)
+			request := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			request.Header.Set("Content-Type", tt.contentType)
+			request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(1)))
+			w := httptest.NewRecorder()
+			handler := http.HandlerFunc(NewHandlerWithStorage(strg, nil, nil).ImportURLsHandler)
+			handler.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+			responseBody, err := io.ReadAll(result.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.headerContent, result.Header.Get("Content-Type"))
+			assert.Equal(t, tt.want.responseContent, string(responseBody))
+		})
+	}
+}
+
+func TestCommonServer_ImportURLsWithConcurrentShortening(t *testing.T) {
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1)
+	const count = 200
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; i++ {
+			_, _, errCode := strg.CreateShortURLByURL(fmt.Sprintf("http://shortened.com/%d", i), 2)
+			assert.Equal(t, 0, errCode)
+		}
+	}()
+	URLs := make([]string, 0, count+1)
+	for i := 0; i < count; i++ {
+		URLs = append(URLs, fmt.Sprintf("http://imported.com/%d", i))
+	}
+	URLs = append(URLs, URLs[0])
+	results := make([]types.ImportRowResult, 0, len(URLs))
+	for start := 0; start < len(URLs); start += 10 {
+		end := start + 10
+		if end > len(URLs) {
+			end = len(URLs)
+		}
+		chunk, _, errorCode := CommonServer{}.ImportURLs(strg, URLs[start:end], 1, "http://localhost:8080/")
+		require.Equal(t, 0, errorCode)
+		results = append(results, chunk...)
+	}
+	wg.Wait()
+
+	require.Len(t, results, len(URLs))
+	for index, result := range results {
+		expectedStatus := types.ImportStatusCreated
+		if index == count {
+			expectedStatus = types.ImportStatusDuplicate
+		}
+		assert.Equal(t, expectedStatus, result.Status)
+		assert.Equal(t, URLs[index], result.OriginalURL)
+		value, errCode := strg.GetValueByKeyAndUserID(ConvertShortURLToID(strings.TrimPrefix(result.ShortURL, "http://localhost:8080/")), 1)
+		assert.Equal(t, 0, errCode)
+		assert.Equal(t, URLs[index], value)
+	}
+	stats, _ := strg.GetStats()
+	assert.Equal(t, 2*count, stats.URLs)
+}
+
+func TestImportURLsHandler_Chunks(t *testing.T) {
+	strg := storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1)
+	var body strings.Builder
+	for i := 0; i < ImportChunkSize*2+1; i++ {
+		body.WriteString(fmt.Sprintf("http://example.com/%d\n", i))
+	}
+	request := httptest.NewRequest(http.MethodPost, "/api/shorten/import", strings.NewReader(body.String()))
+	request.Header.Set("Content-Type", "text/csv")
+	request = request.WithContext(context.WithValue(request.Context(), types.UserIDCtxName, uint(1)))
+	w := httptest.NewRecorder()
+	NewHandlerWithStorage(strg, nil, nil).ImportURLsHandler(w, request)
+	result := w.Result()
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	var response types.ImportResponse
+	assert.Nil(t, json.NewDecoder(result.Body).Decode(&response))
+	assert.Equal(t, ImportChunkSize*2+1, response.Created)
+	stats, _ := strg.GetStats()
+	assert.Equal(t, ImportChunkSize*2+1, stats.URLs)
+	page, errCode := strg.GetURLsPageByUserID(1, storage.URLsPageQuery{Filter: fmt.Sprintf("/%d", ImportChunkSize)}, "http://localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []storage.FullInfoURLResponse{{ShortURL: response.Rows[ImportChunkSize].ShortURL, OriginalURL: response.Rows[ImportChunkSize].OriginalURL}}, page.URLs)
+}
+
+func TestCommonServer_LoginLimit(t *testing.T) {
+	repository := storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1)
+	_, _, _, errorCode := CommonServer{}.Register(repository, types.AccountRequest{Username: "alice", Password: "password123"}, 0)
+	require.Equal(t, 0, errorCode)
+	limiter := NewPasswordLimiter(2, 3, time.Minute)
+	login := func(username string, password string, client string) int {
+		_, _, _, _, errorCode := CommonServer{}.Login(repository, limiter, types.AccountRequest{Username: username, Password: password}, 0, client)
+		return errorCode
+	}
+
+	for _, username := range []string{"alice", "bob", "carol"} {
+		t.Run(username, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, login(username, "wrong-password", "10.0.0.1"))
+			assert.Equal(t, http.StatusUnauthorized, login(strings.ToUpper(username), "wrong-password", "10.0.0.1"))
+			assert.Equal(t, http.StatusTooManyRequests, login(username, "wrong-password", "10.0.0.1"))
+			assert.Equal(t, http.StatusUnauthorized, login(username, "wrong-password", "10.0.0.2"))
+			assert.Equal(t, http.StatusTooManyRequests, login(username, "wrong-password", "10.0.0.3"))
+		})
+	}
+	assert.Equal(t, http.StatusTooManyRequests, login("alice", "password123", "10.0.0.4"))
+}
+
+func TestShortenderServer_GetStats(t *testing.T) {
+	tests := []struct {
+		name         string
+		peerIP       string
+		realIP       string
+		proxies      string
+		expectedCode codes.Code
+	}{
+		{"trusted_peer", "192.168.1.10", "", "", codes.OK},
+		{"untrusted_peer", "10.0.0.1", "", "", codes.Aborted},
+		{"header_from_trusted_proxy", "10.0.0.1", "192.168.1.10", "10.0.0.0/8", codes.OK},
+		{"header_from_untrusted_proxy", "10.0.0.1", "192.168.1.10", "", codes.Aborted},
+	}
+	varprs.TrustedSubnet = "192.168.1.1/24"
+	defer func() { varprs.TrustedProxies = "" }()
+	server := NewShortenderServer(storage.NewMemoryStorage(map[uint]storage.URL{1: {Value: "http://ya.ru"}}, map[uint][]uint{1: {1}}, 2), nil, auth.DefaultKeyring())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			varprs.TrustedProxies = tt.proxies
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(tt.peerIP), Port: 1234}})
+			if tt.realIP != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("X-Real-IP", tt.realIP))
+			}
+			response, err := server.GetStats(ctx, &emptypb.Empty{})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, int32(1), response.Urls)
+			}
+		})
+	}
+}
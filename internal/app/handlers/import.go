@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tank4gun/gourlshortener/internal/app/types"
+)
+
+// ImportChunkSize - number of URLs inserted into storage at once during import
+const ImportChunkSize = 100
+
+// ImportMaxLineSize - max size of NDJSON line in import
+const ImportMaxLineSize = 1 << 20
+
+// ValidateOriginalURL - check that URL is absolute http or https URL
+func ValidateOriginalURL(value string) (errorMessage string, errorCode int) {
+	parsed, err := url.ParseRequestURI(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "URL should be absolute http or https URL", http.StatusBadRequest
+	}
+	return "", 0
+}
+
+// importContentFormat - choose import format by format query param or Content-Type header
+func importContentFormat(format string, contentType string) string {
+	if format != "" {
+		if _, ok := exportContentTypes[format]; ok {
+			return format
+		}
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "application/x-ndjson":
+		return FormatNDJSON
+	case "text/csv":
+		return FormatCSV
+	}
+	return ""
+}
+
+// importReader - reader of original URLs from uploaded file
+type importReader interface {
+	// Next - read URL from the next row, errorMessage is set for invalid row, io.EOF is returned after the last row
+	Next() (URL string, errorMessage string, err error)
+}
+
+// csvImportReader - importReader for CSV with URL in the first column and optional header row
+type csvImportReader struct {
+	reader *csv.Reader // reader - CSV reader for request body
+	rows   int         // rows - number of read rows
+}
+
+// Next - read URL from the next CSV row, header row with original_url or url is skipped
+func (r *csvImportReader) Next() (string, string, error) {
+	record, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		r.rows++
+		return "", "Couldn't parse CSV row: " + parseErr.Err.Error(), nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	r.rows++
+	value := strings.TrimSpace(record[0])
+	if r.rows == 1 && (value == "original_url" || value == "url") {
+		return r.Next()
+	}
+	return value, "", nil
+}
+
+// ndjsonImportReader - importReader for NDJSON with original_url field, empty lines are skipped
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner // scanner - line scanner for request body
+}
+
+// Next - read URL from the next NDJSON line
+func (r *ndjsonImportReader) Next() (string, string, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var row struct {
+			OriginalURL string `json:"original_url"`
+		}
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return "", "Couldn't parse JSON row", nil
+		}
+		return row.OriginalURL, "", nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return "", "", err
+	}
+	return "", "", io.EOF
+}
+
+// newImportReader - create importReader for given format
+func newImportReader(format string, body io.Reader) importReader {
+	if format == FormatCSV {
+		reader := csv.NewReader(body)
+		reader.FieldsPerRecord = -1
+		return &csvImportReader{reader: reader}
+	}
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), ImportMaxLineSize)
+	return &ndjsonImportReader{scanner: scanner}
+}
+
+// urlsImport - state of URLs import for single request
+type urlsImport struct {
+	response  types.ImportResponse // response - report with results for all rows
+	firstRows map[string]int       // firstRows - URL map to index of its first row in report
+	chunk     []int                // chunk - indexes of rows in report waiting for insertion
+	repeats   []int                // repeats - indexes of rows in report repeating URL from earlier row
+}
+
+// ImportURLsHandler creates short URLs from uploaded CSV or NDJSON file and returns result for every row.
+// URLs are inserted in chunks of ImportChunkSize, gzipped uploads are handled by ReceiveCompressed middleware.
+func (strg *HandlerWithStorage) ImportURLsHandler(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(types.UserIDCtxName).(uint)
+	format := importContentFormat(r.URL.Query().Get("format"), r.Header.Get("Content-Type"))
+	if format == "" {
+		http.Error(w, "Only text/csv and application/x-ndjson uploads are supported", http.StatusUnsupportedMediaType)
+		return
+	}
+	reader := newImportReader(format, r.Body)
+	state := urlsImport{response: types.ImportResponse{Rows: make([]types.ImportRowResult, 0)}, firstRows: map[string]int{}}
+	for {
+		URL, errorMessage, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Couldn't read request body, "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errorMessage == "" {
+			errorMessage, _ = ValidateOriginalURL(URL)
+		}
+		if errorMessage, errorCode := strg.addImportRow(&state, URL, errorMessage, userID); errorCode != 0 {
+			http.Error(w, errorMessage, errorCode)
+			return
+		}
+	}
+	if errorMessage, errorCode := strg.flushImportChunk(&state, userID); errorCode != 0 {
+		http.Error(w, errorMessage, errorCode)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if responseMarshalled, err := json.Marshal(state.response); err == nil {
+		_, err = w.Write(responseMarshalled)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// addImportRow - add row to import report, chunk is inserted into storage when it is full
+func (strg *HandlerWithStorage) addImportRow(state *urlsImport, URL string, errorMessage string, userID uint) (string, int) {
+	index := len(state.response.Rows)
+	state.response.Rows = append(state.response.Rows, types.ImportRowResult{Row: index + 1, OriginalURL: URL})
+	if errorMessage != "" {
+		state.response.Rows[index].Status = types.ImportStatusInvalid
+		state.response.Rows[index].Error = errorMessage
+		state.response.Invalid++
+		return "", 0
+	}
+	if _, ok := state.firstRows[URL]; ok {
+		state.repeats = append(state.repeats, index)
+		return "", 0
+	}
+	state.firstRows[URL] = index
+	state.chunk = append(state.chunk, index)
+	if len(state.chunk) < ImportChunkSize {
+		return "", 0
+	}
+	return strg.flushImportChunk(state, userID)
+}
+
+// flushImportChunk - insert rows waiting in chunk into storage and fill their results
+func (strg *HandlerWithStorage) flushImportChunk(state *urlsImport, userID uint) (string, int) {
+	if len(state.chunk) > 0 {
+		URLs := make([]string, 0, len(state.chunk))
+		for _, index := range state.chunk {
+			URLs = append(URLs, state.response.Rows[index].OriginalURL)
+		}
+		results, errorMessage, errorCode := CommonServer{}.ImportURLs(strg.storage, URLs, userID, strg.baseURL)
+		if errorCode != 0 {
+			return errorMessage, errorCode
+		}
+		for chunkIndex, index := range state.chunk {
+			state.response.Rows[index].Status = results[chunkIndex].Status
+			state.response.Rows[index].ShortURL = results[chunkIndex].ShortURL
+			if results[chunkIndex].Status == types.ImportStatusCreated {
+				state.response.Created++
+			} else {
+				state.response.Duplicates++
+			}
+		}
+		state.chunk = state.chunk[:0]
+	}
+	pending := state.repeats[:0]
+	for _, index := range state.repeats {
+		first := state.response.Rows[state.firstRows[state.response.Rows[index].OriginalURL]]
+		if first.Status == "" {
+			pending = append(pending, index)
+			continue
+		}
+		state.response.Rows[index].Status = types.ImportStatusDuplicate
+		state.response.Rows[index].ShortURL = first.ShortURL
+		state.response.Duplicates++
+	}
+	state.repeats = pending
+	return "", 0
+}
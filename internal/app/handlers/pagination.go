@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/tank4gun/gourlshortener/internal/app/storage"
+)
+
+// NextCursorHeader - response header with cursor for the next page of user URLs
+const NextCursorHeader = "X-Next-Cursor"
+
+// EncodeCursor - convert ID of the last URL in page to opaque cursor
+func EncodeCursor(ID uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(ID), 10)))
+}
+
+// DecodeCursor - convert opaque cursor to ID of the last URL in page, empty cursor means the first page
+func DecodeCursor(cursor string) (uint, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, err
+	}
+	ID, err := strconv.ParseUint(string(decoded), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if ID == 0 {
+		return 0, fmt.Errorf("cursor points to zero ID")
+	}
+	return uint(ID), nil
+}
+
+// ParseURLsPageQuery - validate pagination parameters and convert them to storage.URLsPageQuery.
+// Zero limit means storage.MaxPageLimit, order should be empty, "asc" or "desc".
+func ParseURLsPageQuery(limit int, cursor string, order string, filter string) (query storage.URLsPageQuery, errorMessage string, errorCode int) {
+	if limit < 0 || limit > storage.MaxPageLimit {
+		return query, fmt.Sprintf("limit should be between 1 and %d", storage.MaxPageLimit), http.StatusBadRequest
+	}
+	after, err := DecodeCursor(cursor)
+	if err != nil {
+		return query, "Invalid cursor", http.StatusBadRequest
+	}
+	switch order {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, "order should be asc or desc", http.StatusBadRequest
+	}
+	query.Limit = limit
+	query.After = after
+	query.Filter = filter
+	return query, "", 0
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/varprs"
+)
+
+// LinkPasswordHeader - header with password of protected URL for API clients
+const LinkPasswordHeader = "X-Link-Password"
+
+// PasswordAttempts - number of password attempts allowed for one URL and client within PasswordAttemptsWindow
+const PasswordAttempts = 5
+
+// PasswordAttemptsPerURL - number of password attempts allowed for one URL from all clients within PasswordAttemptsWindow
+const PasswordAttemptsPerURL = 100
+
+// LoginAttemptsPerAccount - number of login attempts allowed for one username from all clients within PasswordAttemptsWindow
+const LoginAttemptsPerAccount = 20
+
+// PasswordAttemptsWindow - period after which password attempts are allowed again
+const PasswordAttemptsWindow = 15 * time.Minute
+
+// passwordLimiterPruneSize - number of tracked targets or target and client pairs after which outdated ones are pruned
+const passwordLimiterPruneSize = 1024
+
+// passwordAttempts - password attempts made within current window
+type passwordAttempts struct {
+	// count - number of attempts in window
+	count int
+	// windowStart - time of the first attempt in window
+	windowStart time.Time
+}
+
+// PasswordLimiter - fixed window limiter of password attempts per target and client with ceiling per target,
+// so that clients with many addresses can't exceed it. Target is URL ID for protected URLs and normalized
+// username for login. One limiter should be shared by HTTP and gRPC servers.
+// Attempt is counted before password is verified, so parallel requests can't exceed the limit.
+type PasswordLimiter struct {
+	// mutex - guards attempts and targetAttempts
+	mutex sync.Mutex
+	// attempts - attempts by target and client key
+	attempts map[string]passwordAttempts
+	// targetAttempts - attempts by target from all clients
+	targetAttempts map[string]passwordAttempts
+	// maxAttempts - number of attempts allowed for target and client within window
+	maxAttempts int
+	// maxTargetAttempts - number of attempts allowed for target from all clients within window
+	maxTargetAttempts int
+	// window - period after which attempts are allowed again
+	window time.Duration
+}
+
+// NewPasswordLimiter creates PasswordLimiter which allows maxAttempts attempts per target and client
+// and maxTargetAttempts attempts per target from all clients per window.
+func NewPasswordLimiter(maxAttempts int, maxTargetAttempts int, window time.Duration) *PasswordLimiter {
+	return &PasswordLimiter{
+		attempts:          make(map[string]passwordAttempts),
+		targetAttempts:    make(map[string]passwordAttempts),
+		maxAttempts:       maxAttempts,
+		maxTargetAttempts: maxTargetAttempts,
+		window:            window,
+	}
+}
+
+// URLPasswordTarget returns limiter target for password of URL with given ID
+func URLPasswordTarget(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// passwordLimiterKey - key of target and client pair
+func passwordLimiterKey(target string, client string) string {
+	return target + "|" + client
+}
+
+// Attempt counts attempt for target and client, time after which next attempt is allowed is returned
+// if limit for target and client or ceiling for target is exceeded.
+func (limiter *PasswordLimiter) Attempt(target string, client string, now time.Time) (retryAfter time.Duration, ok bool) {
+	key := passwordLimiterKey(target, client)
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	if len(limiter.attempts) >= passwordLimiterPruneSize || len(limiter.targetAttempts) >= passwordLimiterPruneSize {
+		limiter.prune(now)
+	}
+	attempts := limiter.current(limiter.attempts[key], now)
+	if attempts.count >= limiter.maxAttempts {
+		return attempts.windowStart.Add(limiter.window).Sub(now), false
+	}
+	targetAttempts := limiter.current(limiter.targetAttempts[target], now)
+	if targetAttempts.count >= limiter.maxTargetAttempts {
+		return targetAttempts.windowStart.Add(limiter.window).Sub(now), false
+	}
+	attempts.count++
+	limiter.attempts[key] = attempts
+	targetAttempts.count++
+	limiter.targetAttempts[target] = targetAttempts
+	return 0, true
+}
+
+// current - returns attempts of current window, new window is started if previous one is finished
+func (limiter *PasswordLimiter) current(attempts passwordAttempts, now time.Time) passwordAttempts {
+	if attempts.windowStart.IsZero() || now.Sub(attempts.windowStart) >= limiter.window {
+		return passwordAttempts{windowStart: now}
+	}
+	return attempts
+}
+
+// Reset forgets attempts for target and client after successful one, ceiling for target isn't reset.
+func (limiter *PasswordLimiter) Reset(target string, client string) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	delete(limiter.attempts, passwordLimiterKey(target, client))
+}
+
+// prune removes attempts with finished window, should be called under mutex
+func (limiter *PasswordLimiter) prune(now time.Time) {
+	for _, attemptsMap := range []map[string]passwordAttempts{limiter.attempts, limiter.targetAttempts} {
+		for key, attempts := range attemptsMap {
+			if now.Sub(attempts.windowStart) >= limiter.window {
+				delete(attemptsMap, key)
+			}
+		}
+	}
+}
+
+// IsTrustedProxy checks that IP belongs to one of subnets from varprs.TrustedProxies
+func IsTrustedProxy(ipStr string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil || varprs.TrustedProxies == "" {
+		return false
+	}
+	for _, subnet := range strings.Split(varprs.TrustedProxies, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(subnet))
+		if err == nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIPFromProxy - returns real IP set by proxy if remote IP is trusted proxy, remote IP otherwise
+func clientIPFromProxy(remoteIP string, realIP string) string {
+	if realIP != "" && IsTrustedProxy(remoteIP) {
+		return realIP
+	}
+	return remoteIP
+}
+
+// ClientIP returns request client IP from remote address, X-Real-IP header is used only if request came from trusted proxy
+func ClientIP(r *http.Request) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	return clientIPFromProxy(remoteIP, r.Header.Get("X-Real-IP"))
+}
@@ -6,6 +6,7 @@ package mocks
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	storage "github.com/tank4gun/gourlshortener/internal/app/storage"
@@ -34,6 +35,48 @@ func (m *MockIRepository) EXPECT() *MockIRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ConsumeClick mocks base method.
+func (m *MockIRepository) ConsumeClick(arg0 uint) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeClick", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ConsumeClick indicates an expected call of ConsumeClick.
+func (mr *MockIRepositoryMockRecorder) ConsumeClick(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeClick", reflect.TypeOf((*MockIRepository)(nil).ConsumeClick), arg0)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockIRepository) CreateAPIKey(arg0 storage.APIKey) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockIRepositoryMockRecorder) CreateAPIKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockIRepository)(nil).CreateAPIKey), arg0)
+}
+
+// CreateAccount mocks base method.
+func (m *MockIRepository) CreateAccount(arg0 storage.Account) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccount", arg0)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockIRepositoryMockRecorder) CreateAccount(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockIRepository)(nil).CreateAccount), arg0)
+}
+
 // CreateShortURLBatch mocks base method.
 func (m *MockIRepository) CreateShortURLBatch(arg0 []storage.BatchURLRequest, arg1 uint, arg2 string) ([]storage.BatchURLResponse, string, int) {
 	m.ctrl.T.Helper()
@@ -66,6 +109,82 @@ func (mr *MockIRepositoryMockRecorder) CreateShortURLByURL(arg0, arg1 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortURLByURL", reflect.TypeOf((*MockIRepository)(nil).CreateShortURLByURL), arg0, arg1)
 }
 
+// CreateShortURLWithOptions mocks base method.
+func (m *MockIRepository) CreateShortURLWithOptions(arg0 string, arg1 storage.URLOptions, arg2 uint) (string, string, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateShortURLWithOptions", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(int)
+	return ret0, ret1, ret2
+}
+
+// CreateShortURLWithOptions indicates an expected call of CreateShortURLWithOptions.
+func (mr *MockIRepositoryMockRecorder) CreateShortURLWithOptions(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateShortURLWithOptions", reflect.TypeOf((*MockIRepository)(nil).CreateShortURLWithOptions), arg0, arg1, arg2)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockIRepository) GetAPIKeyByHash(arg0 string) (storage.APIKey, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", arg0)
+	ret0, _ := ret[0].(storage.APIKey)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockIRepositoryMockRecorder) GetAPIKeyByHash(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockIRepository)(nil).GetAPIKeyByHash), arg0)
+}
+
+// GetAPIKeysByUserID mocks base method.
+func (m *MockIRepository) GetAPIKeysByUserID(arg0 uint) ([]storage.APIKey, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeysByUserID", arg0)
+	ret0, _ := ret[0].([]storage.APIKey)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetAPIKeysByUserID indicates an expected call of GetAPIKeysByUserID.
+func (mr *MockIRepositoryMockRecorder) GetAPIKeysByUserID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeysByUserID", reflect.TypeOf((*MockIRepository)(nil).GetAPIKeysByUserID), arg0)
+}
+
+// GetAccountByUserID mocks base method.
+func (m *MockIRepository) GetAccountByUserID(arg0 uint) (storage.Account, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByUserID", arg0)
+	ret0, _ := ret[0].(storage.Account)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetAccountByUserID indicates an expected call of GetAccountByUserID.
+func (mr *MockIRepositoryMockRecorder) GetAccountByUserID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByUserID", reflect.TypeOf((*MockIRepository)(nil).GetAccountByUserID), arg0)
+}
+
+// GetAccountByUsername mocks base method.
+func (m *MockIRepository) GetAccountByUsername(arg0 string) (storage.Account, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByUsername", arg0)
+	ret0, _ := ret[0].(storage.Account)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetAccountByUsername indicates an expected call of GetAccountByUsername.
+func (mr *MockIRepositoryMockRecorder) GetAccountByUsername(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByUsername", reflect.TypeOf((*MockIRepository)(nil).GetAccountByUsername), arg0)
+}
+
 // GetAllURLsByUserID mocks base method.
 func (m *MockIRepository) GetAllURLsByUserID(arg0 uint, arg1 string) ([]storage.FullInfoURLResponse, int) {
 	m.ctrl.T.Helper()
@@ -81,19 +200,49 @@ func (mr *MockIRepositoryMockRecorder) GetAllURLsByUserID(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllURLsByUserID", reflect.TypeOf((*MockIRepository)(nil).GetAllURLsByUserID), arg0, arg1)
 }
 
-// GetNextIndex mocks base method.
-func (m *MockIRepository) GetNextIndex() (uint, error) {
+// GetClickStats mocks base method.
+func (m *MockIRepository) GetClickStats(arg0, arg1 uint) (storage.ClickStatsResponse, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClickStats", arg0, arg1)
+	ret0, _ := ret[0].(storage.ClickStatsResponse)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetClickStats indicates an expected call of GetClickStats.
+func (mr *MockIRepositoryMockRecorder) GetClickStats(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClickStats", reflect.TypeOf((*MockIRepository)(nil).GetClickStats), arg0, arg1)
+}
+
+// GetDeletedURLsByUserID mocks base method.
+func (m *MockIRepository) GetDeletedURLsByUserID(arg0 uint, arg1 string) ([]storage.DeletedURLResponse, int) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetNextIndex")
+	ret := m.ctrl.Call(m, "GetDeletedURLsByUserID", arg0, arg1)
+	ret0, _ := ret[0].([]storage.DeletedURLResponse)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetDeletedURLsByUserID indicates an expected call of GetDeletedURLsByUserID.
+func (mr *MockIRepositoryMockRecorder) GetDeletedURLsByUserID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletedURLsByUserID", reflect.TypeOf((*MockIRepository)(nil).GetDeletedURLsByUserID), arg0, arg1)
+}
+
+// GetIDByAlias mocks base method.
+func (m *MockIRepository) GetIDByAlias(arg0 string) (uint, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIDByAlias", arg0)
 	ret0, _ := ret[0].(uint)
-	ret1, _ := ret[1].(error)
+	ret1, _ := ret[1].(int)
 	return ret0, ret1
 }
 
-// GetNextIndex indicates an expected call of GetNextIndex.
-func (mr *MockIRepositoryMockRecorder) GetNextIndex() *gomock.Call {
+// GetIDByAlias indicates an expected call of GetIDByAlias.
+func (mr *MockIRepositoryMockRecorder) GetIDByAlias(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNextIndex", reflect.TypeOf((*MockIRepository)(nil).GetNextIndex))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIDByAlias", reflect.TypeOf((*MockIRepository)(nil).GetIDByAlias), arg0)
 }
 
 // GetStats mocks base method.
@@ -111,6 +260,51 @@ func (mr *MockIRepositoryMockRecorder) GetStats() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockIRepository)(nil).GetStats))
 }
 
+// GetURLByKeyAndUserID mocks base method.
+func (m *MockIRepository) GetURLByKeyAndUserID(arg0, arg1 uint) (storage.URL, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLByKeyAndUserID", arg0, arg1)
+	ret0, _ := ret[0].(storage.URL)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetURLByKeyAndUserID indicates an expected call of GetURLByKeyAndUserID.
+func (mr *MockIRepositoryMockRecorder) GetURLByKeyAndUserID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLByKeyAndUserID", reflect.TypeOf((*MockIRepository)(nil).GetURLByKeyAndUserID), arg0, arg1)
+}
+
+// GetURLHistory mocks base method.
+func (m *MockIRepository) GetURLHistory(arg0, arg1 uint) ([]storage.URLChange, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLHistory", arg0, arg1)
+	ret0, _ := ret[0].([]storage.URLChange)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetURLHistory indicates an expected call of GetURLHistory.
+func (mr *MockIRepositoryMockRecorder) GetURLHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLHistory", reflect.TypeOf((*MockIRepository)(nil).GetURLHistory), arg0, arg1)
+}
+
+// GetURLsPageByUserID mocks base method.
+func (m *MockIRepository) GetURLsPageByUserID(arg0 uint, arg1 storage.URLsPageQuery, arg2 string) (storage.URLsPage, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLsPageByUserID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(storage.URLsPage)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// GetURLsPageByUserID indicates an expected call of GetURLsPageByUserID.
+func (mr *MockIRepositoryMockRecorder) GetURLsPageByUserID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLsPageByUserID", reflect.TypeOf((*MockIRepository)(nil).GetURLsPageByUserID), arg0, arg1, arg2)
+}
+
 // GetValueByKeyAndUserID mocks base method.
 func (m *MockIRepository) GetValueByKeyAndUserID(arg0, arg1 uint) (string, int) {
 	m.ctrl.T.Helper()
@@ -126,18 +320,18 @@ func (mr *MockIRepositoryMockRecorder) GetValueByKeyAndUserID(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetValueByKeyAndUserID", reflect.TypeOf((*MockIRepository)(nil).GetValueByKeyAndUserID), arg0, arg1)
 }
 
-// InsertBatchValues mocks base method.
-func (m *MockIRepository) InsertBatchValues(arg0 []string, arg1, arg2 uint) error {
+// InsertClicks mocks base method.
+func (m *MockIRepository) InsertClicks(arg0 []storage.Click) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertBatchValues", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "InsertClicks", arg0)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// InsertBatchValues indicates an expected call of InsertBatchValues.
-func (mr *MockIRepositoryMockRecorder) InsertBatchValues(arg0, arg1, arg2 interface{}) *gomock.Call {
+// InsertClicks indicates an expected call of InsertClicks.
+func (mr *MockIRepositoryMockRecorder) InsertClicks(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBatchValues", reflect.TypeOf((*MockIRepository)(nil).InsertBatchValues), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertClicks", reflect.TypeOf((*MockIRepository)(nil).InsertClicks), arg0)
 }
 
 // InsertValue mocks base method.
@@ -154,6 +348,20 @@ func (mr *MockIRepositoryMockRecorder) InsertValue(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertValue", reflect.TypeOf((*MockIRepository)(nil).InsertValue), arg0, arg1)
 }
 
+// IterateURLsByUserID mocks base method.
+func (m *MockIRepository) IterateURLsByUserID(arg0 uint, arg1 string, arg2 func(storage.ExportedURL) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IterateURLsByUserID", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IterateURLsByUserID indicates an expected call of IterateURLsByUserID.
+func (mr *MockIRepositoryMockRecorder) IterateURLsByUserID(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IterateURLsByUserID", reflect.TypeOf((*MockIRepository)(nil).IterateURLsByUserID), arg0, arg1, arg2)
+}
+
 // MarkBatchAsDeleted mocks base method.
 func (m *MockIRepository) MarkBatchAsDeleted(arg0 []uint, arg1 uint) error {
 	m.ctrl.T.Helper()
@@ -168,6 +376,36 @@ func (mr *MockIRepositoryMockRecorder) MarkBatchAsDeleted(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkBatchAsDeleted", reflect.TypeOf((*MockIRepository)(nil).MarkBatchAsDeleted), arg0, arg1)
 }
 
+// MarkExpiredAsDeleted mocks base method.
+func (m *MockIRepository) MarkExpiredAsDeleted(arg0 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkExpiredAsDeleted", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkExpiredAsDeleted indicates an expected call of MarkExpiredAsDeleted.
+func (mr *MockIRepositoryMockRecorder) MarkExpiredAsDeleted(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkExpiredAsDeleted", reflect.TypeOf((*MockIRepository)(nil).MarkExpiredAsDeleted), arg0)
+}
+
+// MergeUser mocks base method.
+func (m *MockIRepository) MergeUser(arg0, arg1 uint) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeUser", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeUser indicates an expected call of MergeUser.
+func (mr *MockIRepositoryMockRecorder) MergeUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeUser", reflect.TypeOf((*MockIRepository)(nil).MergeUser), arg0, arg1)
+}
+
 // Ping mocks base method.
 func (m *MockIRepository) Ping() error {
 	m.ctrl.T.Helper()
@@ -182,6 +420,50 @@ func (mr *MockIRepositoryMockRecorder) Ping() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockIRepository)(nil).Ping))
 }
 
+// PurgeDeleted mocks base method.
+func (m *MockIRepository) PurgeDeleted(arg0 time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockIRepositoryMockRecorder) PurgeDeleted(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockIRepository)(nil).PurgeDeleted), arg0)
+}
+
+// RestoreBatch mocks base method.
+func (m *MockIRepository) RestoreBatch(arg0 []uint, arg1 uint) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBatch", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreBatch indicates an expected call of RestoreBatch.
+func (mr *MockIRepositoryMockRecorder) RestoreBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBatch", reflect.TypeOf((*MockIRepository)(nil).RestoreBatch), arg0, arg1)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockIRepository) RevokeAPIKey(arg0 string, arg1 uint) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockIRepositoryMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockIRepository)(nil).RevokeAPIKey), arg0, arg1)
+}
+
 // Shutdown mocks base method.
 func (m *MockIRepository) Shutdown() error {
 	m.ctrl.T.Helper()
@@ -195,3 +477,18 @@ func (mr *MockIRepositoryMockRecorder) Shutdown() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockIRepository)(nil).Shutdown))
 }
+
+// UpdateURLValue mocks base method.
+func (m *MockIRepository) UpdateURLValue(arg0 uint, arg1 string, arg2 uint) (string, int) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateURLValue", arg0, arg1, arg2)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int)
+	return ret0, ret1
+}
+
+// UpdateURLValue indicates an expected call of UpdateURLValue.
+func (mr *MockIRepositoryMockRecorder) UpdateURLValue(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateURLValue", reflect.TypeOf((*MockIRepository)(nil).UpdateURLValue), arg0, arg1, arg2)
+}
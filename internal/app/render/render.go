@@ -0,0 +1,54 @@
+// Package render contains html/template based rendering of HTML pages for URLShortener service.
+package render
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// templatesFS - HTML templates embedded into binary
+//
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+// templates - parsed HTML templates, template name is its file name
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"date": func(value time.Time) string { return value.UTC().Format("2006-01-02 15:04 MST") },
+}).ParseFS(templatesFS, "templates/*.html"))
+
+// PreviewTemplate - name of template for URL preview page
+const PreviewTemplate = "preview.html"
+
+// PreviewPage - data for URL preview page
+type PreviewPage struct {
+	ShortURL    string    // ShortURL - shorten URL which is previewed
+	OriginalURL string    // OriginalURL - URL to which short URL redirects
+	Title       string    // Title - title of URL set by its owner, not shown if empty
+	CreatedAt   time.Time // CreatedAt - time when URL was created, not shown if zero
+	ContinueURL string    // ContinueURL - URL for continue button
+}
+
+// PasswordTemplate - name of template for password form of protected URL
+const PasswordTemplate = "password.html"
+
+// PasswordPage - data for password form of protected URL
+type PasswordPage struct {
+	ShortURL string // ShortURL - protected shorten URL, password form is sent to it
+	Error    string // Error - error of previous attempt, not shown if empty
+}
+
+// Render - execute template with given name and data and write it with status into w.
+// Template is executed into buffer first, so nothing is written if execution fails.
+func Render(w http.ResponseWriter, status int, name string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -3,33 +3,20 @@ package server
 import (
 	"compress/gzip"
 	"context"
-	"crypto/hmac"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/binary"
-	"encoding/hex"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
 	"github.com/tank4gun/gourlshortener/internal/app/handlers"
 	"github.com/tank4gun/gourlshortener/internal/app/storage"
 	"github.com/tank4gun/gourlshortener/internal/app/types"
 	"github.com/tank4gun/gourlshortener/internal/app/varprs"
 )
 
-// GenerateNewID - generates new ID with 4 bytes for user randomly
-func GenerateNewID() []byte {
-	newData := make([]byte, 4)
-	_, err := rand.Read(newData)
-	if err != nil {
-		panic(err.Error())
-	}
-	return newData
-}
-
 // ReceiveCompressed - middleware for uncompressing request body
 func ReceiveCompressed(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -76,54 +63,114 @@ func SendCompressed(next http.Handler) http.Handler {
 	})
 }
 
-// CheckAuth - middleware for checking that user is authorized
-func CheckAuth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := (*r).Cookie(types.URLShortenderCookieName)
-		if cookie != nil && err != nil {
-			fmt.Println(err.Error())
-			io.WriteString(w, err.Error())
-			return
-		}
-		if cookie != nil {
-			cookieValue, _ := hex.DecodeString(cookie.Value)
-			h := hmac.New(sha256.New, types.CookieKey)
-			h.Write(cookieValue[:4])
-			sign := h.Sum(nil)
-			if hmac.Equal(sign, cookieValue[4:]) {
-				ctx := context.WithValue(r.Context(), types.UserIDCtxName, uint(binary.BigEndian.Uint16(cookieValue[:4])))
-				next.ServeHTTP(w, r.WithContext(ctx))
+// CheckAuth - middleware for checking that user is authorized with API key from Authorization header or with
+// identity token cookie. Request with unknown or revoked API key is rejected.
+// Tokens signed with not current key or close to expiration and legacy cookies accepted until keyring cutoff
+// are replaced keeping user ID, user with missing or invalid cookie gets new ID.
+func CheckAuth(keyring *auth.Keyring, ttl time.Duration, repository storage.IRepository) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if header := r.Header.Get("Authorization"); header != "" {
+				apiKey, errorCode := handlers.CommonServer{}.AuthenticateAPIKey(repository, auth.BearerToken(header))
+				if errorCode != 0 {
+					if errorCode == http.StatusUnauthorized {
+						w.Header().Set("WWW-Authenticate", "Bearer")
+					}
+					http.Error(w, "Invalid API key", errorCode)
+					return
+				}
+				ctx := context.WithValue(r.Context(), types.UserIDCtxName, apiKey.UserID)
+				next.ServeHTTP(w, r.WithContext(auth.WithScopes(ctx, apiKey.Scopes)))
 				return
 			}
-		}
+			now := time.Now()
+			var userID uint
+			cookie, err := r.Cookie(types.URLShortenderCookieName)
+			if err == nil {
+				var reissue bool
+				userID, reissue, err = keyring.Authenticate(cookie.Value, now)
+				if err == nil && reissue {
+					handlers.SetIdentityCookie(w, keyring, userID, now, ttl)
+				}
+			}
+			if userID == 0 {
+				userID = auth.NewUserID()
+				handlers.SetIdentityCookie(w, keyring, userID, now, ttl)
+			}
+			ctx := context.WithValue(r.Context(), types.UserIDCtxName, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-		newID := GenerateNewID()
-		h := hmac.New(sha256.New, types.CookieKey)
-		h.Write(newID)
-		sign := h.Sum(nil)
-		newCookie := http.Cookie{Name: types.URLShortenderCookieName, Value: hex.EncodeToString(append(newID[:], sign[:]...))}
-		http.SetCookie(w, &newCookie)
-		ctx := context.WithValue(r.Context(), types.UserIDCtxName, uint(binary.BigEndian.Uint16(newID)))
-		next.ServeHTTP(w, r.WithContext(ctx))
+// RequireScope - middleware for checking that request authorized with API key is allowed operations of scope
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.HasScope(r.Context(), scope) {
+				http.Error(w, fmt.Sprintf("API key doesn't have %s scope", scope), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession - middleware for checking that request isn't authorized with API key, i.e. API keys can't manage API keys
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.IsAPIKeyContext(r.Context()) {
+			http.Error(w, "API keys can't be used for this request", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
 	})
 }
 
-// CreateServer - base method for creating Router and use it in http.Server
-func CreateServer(startStorage storage.IRepository, deleteChannel chan types.RequestToDelete) *http.Server {
+// CreateServer - base method for creating Router and use it in http.Server.
+// Password attempts are limited with passwordLimiter shared with gRPC server, handler gets its own one if it's nil.
+func CreateServer(startStorage storage.IRepository, deleteQueue *handlers.DeleteQueue, clickRecorder *handlers.ClickRecorder, keyring *auth.Keyring, passwordLimiter *handlers.PasswordLimiter) *http.Server {
+	tokenTTL := varprs.AuthTokenTTL
+	if tokenTTL <= 0 {
+		tokenTTL = auth.DefaultTokenTTL
+	}
 	router := chi.NewRouter()
 	router.Use(ReceiveCompressed)
 	router.Use(SendCompressed)
-	router.Use(CheckAuth)
-	handlerWithStorage := handlers.NewHandlerWithStorage(startStorage, deleteChannel)
-	go handlerWithStorage.DeleteURLsDaemon()
-	router.Post("/", handlerWithStorage.CreateShortURLHandler)
+	router.Use(CheckAuth(keyring, tokenTTL, startStorage))
+	handlerWithStorage := handlers.NewHandlerWithStorage(startStorage, deleteQueue, clickRecorder)
+	handlerWithStorage.SetKeyring(keyring, tokenTTL)
+	if passwordLimiter != nil {
+		handlerWithStorage.SetPasswordLimiter(passwordLimiter)
+	}
+	shorten := router.With(RequireScope(auth.ScopeShorten))
+	read := router.With(RequireScope(auth.ScopeRead))
+	remove := router.With(RequireScope(auth.ScopeDelete))
+	shorten.Post("/", handlerWithStorage.CreateShortURLHandler)
 	router.Get("/{id}", handlerWithStorage.GetURLByIDHandler)
-	router.Post("/api/shorten", handlerWithStorage.CreateShortenURLFromBodyHandler)
-	router.Get("/api/user/urls", handlerWithStorage.GetAllURLsHandler)
-	router.Delete("/api/user/urls", handlerWithStorage.DeleteURLsHandler)
+	router.Head("/{id}", handlerWithStorage.GetURLByIDHandler)
+	router.Post("/{id}", handlerWithStorage.GetURLByIDHandler)
+	shorten.Post("/api/shorten", handlerWithStorage.CreateShortenURLFromBodyHandler)
+	read.Get("/api/user/urls", handlerWithStorage.GetAllURLsHandler)
+	read.Get("/api/user/urls/export", handlerWithStorage.ExportURLsHandler)
+	read.Get("/api/user/urls/trash", handlerWithStorage.GetDeletedURLsHandler)
+	remove.Post("/api/user/urls/restore", handlerWithStorage.RestoreURLsHandler)
+	read.Get("/api/user/urls/{id}/stats", handlerWithStorage.GetURLStatsHandler)
+	read.Get("/api/user/urls/{id}/history", handlerWithStorage.GetURLHistoryHandler)
+	shorten.Patch("/api/user/urls/{id}", handlerWithStorage.UpdateURLHandler)
+	remove.Delete("/api/user/urls", handlerWithStorage.DeleteURLsHandler)
+	remove.Get("/api/user/urls/delete-jobs/{id}", handlerWithStorage.GetDeleteJobHandler)
 	router.Get("/ping", handlerWithStorage.PingHandler)
-	router.Post("/api/shorten/batch", handlerWithStorage.CreateShortenURLBatchHandler)
+	shorten.Post("/api/shorten/batch", handlerWithStorage.CreateShortenURLBatchHandler)
+	shorten.Post("/api/shorten/import", handlerWithStorage.ImportURLsHandler)
+	session := router.With(RequireSession)
+	session.Post("/api/user/keys", handlerWithStorage.CreateAPIKeyHandler)
+	session.Get("/api/user/keys", handlerWithStorage.GetAPIKeysHandler)
+	session.Delete("/api/user/keys/{id}", handlerWithStorage.RevokeAPIKeyHandler)
+	session.Post("/api/user/register", handlerWithStorage.RegisterHandler)
+	session.Post("/api/user/login", handlerWithStorage.LoginHandler)
 	router.Get("/api/internal/stats", handlerWithStorage.GetStatsHandler)
+	router.Post("/api/internal/compact", handlerWithStorage.CompactStorageHandler)
 
 	// Add handlers for pprof
 	router.Handle("/debug/pprof/*", http.DefaultServeMux)
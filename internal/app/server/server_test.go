@@ -1,10 +1,21 @@
 package server
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
 	"github.com/tank4gun/gourlshortener/internal/app/storage"
 	"github.com/tank4gun/gourlshortener/internal/app/types"
 )
@@ -12,23 +23,248 @@ import (
 func TestCreateServer(t *testing.T) {
 	tests := []struct {
 		name         string
-		startStorage storage.Storage
+		startStorage *storage.Storage
 	}{
 		{
 			"server_created",
-			storage.Storage{InternalStorage: map[uint]storage.URL{}, NextIndex: 1},
+			storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			createdServer := CreateServer(&tt.startStorage, make(chan types.RequestToDelete, 10))
+			createdServer := CreateServer(tt.startStorage, nil, nil, auth.DefaultKeyring(), nil)
 			assert.NotNil(t, createdServer)
 		})
 	}
 }
 
-func TestGenerateNewID(t *testing.T) {
-	t.Run("same_size", func(t *testing.T) {
-		assert.Equal(t, len(GenerateNewID()), 4)
-	})
+func TestCheckAuth(t *testing.T) {
+	now := time.Now()
+	oldKeyring, err := auth.ParseKeyring("old:0123456789abcdef", "")
+	require.NoError(t, err)
+	keyring, err := auth.ParseKeyring("old:0123456789abcdef,new:fedcba9876543210", "new")
+	require.NoError(t, err)
+	legacyID := []byte{0x00, 0x2a, 0x01, 0x02}
+	h := hmac.New(sha256.New, types.CookieKey)
+	h.Write(legacyID)
+	legacyCookie := hex.EncodeToString(append(legacyID, h.Sum(nil)...))
+	tests := []struct {
+		name            string
+		cookie          string
+		legacyUntil     time.Time
+		expectedUserID  uint
+		expectedReissue bool
+	}{
+		{"no_cookie", "", time.Time{}, 0, true},
+		{"current_key", keyring.Issue(1<<40, now, time.Hour), time.Time{}, 1 << 40, false},
+		{"previous_key", oldKeyring.Issue(1<<40, now, time.Hour), time.Time{}, 1 << 40, true},
+		{"close_to_expiration", keyring.Issue(1<<40, now.Add(-50*time.Minute), time.Hour), time.Time{}, 1 << 40, true},
+		{"expired", keyring.Issue(1<<40, now.Add(-2*time.Hour), time.Hour), time.Time{}, 0, true},
+		{"bad_signature", keyring.Issue(1<<40, now, time.Hour) + "A", time.Time{}, 0, true},
+		{"legacy_cookie", legacyCookie, now.Add(time.Hour), 0x2a, true},
+		{"legacy_cookie_not_accepted", legacyCookie, time.Time{}, 0, true},
+		{"legacy_cookie_after_cutoff", legacyCookie, now.Add(-time.Hour), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring.AcceptLegacyUntil(tt.legacyUntil)
+			var userID uint
+			handler := CheckAuth(keyring, time.Hour, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				userID = r.Context().Value(types.UserIDCtxName).(uint)
+			}))
+			request := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+			if tt.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: types.URLShortenderCookieName, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+			if tt.expectedUserID != 0 {
+				assert.Equal(t, tt.expectedUserID, userID)
+			} else {
+				assert.GreaterOrEqual(t, userID, uint(auth.MinUserID))
+			}
+			cookies := result.Cookies()
+			if !tt.expectedReissue {
+				assert.Empty(t, cookies)
+				return
+			}
+			require.Len(t, cookies, 1)
+			identity, err := keyring.Verify(cookies[0].Value, time.Now())
+			require.NoError(t, err)
+			assert.Equal(t, userID, identity.UserID)
+			assert.Equal(t, "new", identity.KeyID)
+			assert.True(t, cookies[0].HttpOnly)
+		})
+	}
+}
+
+func TestCreateServer_GzipImport(t *testing.T) {
+	var body bytes.Buffer
+	compressed := gzip.NewWriter(&body)
+	_, err := compressed.Write([]byte("http://ya.ru\nhttp://google.com\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, compressed.Close())
+	request := httptest.NewRequest(http.MethodPost, "/api/shorten/import", &body)
+	request.Header.Set("Content-Type", "text/csv")
+	request.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	createdServer := CreateServer(storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1), nil, nil, auth.DefaultKeyring(), nil)
+	createdServer.Handler.ServeHTTP(w, request)
+	result := w.Result()
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	var response types.ImportResponse
+	assert.Nil(t, json.NewDecoder(result.Body).Decode(&response))
+	assert.Equal(t, 2, response.Created)
+}
+
+func TestCreateServer_APIKeys(t *testing.T) {
+	createdServer := CreateServer(storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1), nil, nil, auth.DefaultKeyring(), nil)
+	serve := func(method string, target string, body string, authorization string, cookies ...*http.Cookie) *http.Response {
+		request := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		createdServer.Handler.ServeHTTP(w, request)
+		return w.Result()
+	}
+
+	result := serve(http.MethodPost, "/api/user/keys", `{"name":"backend","scopes":["shorten","shorten"]}`, "")
+	defer result.Body.Close()
+	require.Equal(t, http.StatusCreated, result.StatusCode)
+	cookies := result.Cookies()
+	require.Len(t, cookies, 1)
+	var created types.APIKeyResponse
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&created))
+	assert.Equal(t, []string{auth.ScopeShorten}, created.Scopes)
+	bearer := "Bearer " + created.Key
+
+	result = serve(http.MethodPost, "/api/user/keys", `{"scopes":["admin"]}`, "", cookies...)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+
+	result = serve(http.MethodPost, "/api/shorten/batch", `[{"correlation_id":"1","original_url":"http://ya.ru"}]`, bearer)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.Empty(t, result.Cookies())
+
+	result = serve(http.MethodGet, "/api/user/urls", "", bearer)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+	result = serve(http.MethodGet, "/api/user/keys", "", bearer)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+
+	result = serve(http.MethodGet, "/api/user/urls", "", "", cookies...)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	var urls []storage.FullInfoURLResponse
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&urls))
+	require.Len(t, urls, 1)
+	assert.Equal(t, "http://ya.ru", urls[0].OriginalURL)
+
+	result = serve(http.MethodGet, "/api/user/keys", "", "", cookies...)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	var keys []types.APIKeyResponse
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.ID, keys[0].ID)
+	assert.Empty(t, keys[0].Key)
+
+	result = serve(http.MethodDelete, "/api/user/keys/"+created.ID, "", "")
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
+	result = serve(http.MethodDelete, "/api/user/keys/"+created.ID, "", "", cookies...)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+
+	result = serve(http.MethodPost, "/api/shorten", `{"url":"http://google.com"}`, bearer)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.Equal(t, "Bearer", result.Header.Get("WWW-Authenticate"))
+}
+
+func TestCreateServer_Accounts(t *testing.T) {
+	createdServer := CreateServer(storage.NewMemoryStorage(map[uint]storage.URL{}, map[uint][]uint{}, 1), nil, nil, auth.DefaultKeyring(), nil)
+	serve := func(method string, target string, body string, cookies ...*http.Cookie) *http.Response {
+		request := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+		for _, cookie := range cookies {
+			request.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		createdServer.Handler.ServeHTTP(w, request)
+		return w.Result()
+	}
+	decodeAccount := func(result *http.Response) types.AccountResponse {
+		var response types.AccountResponse
+		require.NoError(t, json.NewDecoder(result.Body).Decode(&response))
+		return response
+	}
+
+	result := serve(http.MethodPost, "/api/shorten", `{"url":"http://ya.ru"}`)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusCreated, result.StatusCode)
+	anonymous := result.Cookies()
+	require.Len(t, anonymous, 1)
+
+	result = serve(http.MethodPost, "/api/user/register", `{"username":"Alice","password":"short"}`, anonymous...)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+
+	result = serve(http.MethodPost, "/api/user/register", `{"username":"Alice","password":"password123"}`, anonymous...)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.Equal(t, types.AccountResponse{Username: "alice", Merged: 1}, decodeAccount(result))
+	registered := result.Cookies()
+	require.Len(t, registered, 1)
+	assert.NotEqual(t, anonymous[0].Value, registered[0].Value)
+
+	result = serve(http.MethodPost, "/api/user/register", `{"username":"alice","password":"password456"}`)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusConflict, result.StatusCode)
+
+	result = serve(http.MethodPost, "/api/user/login", `{"username":"alice","password":"wrong-password"}`)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	result = serve(http.MethodPost, "/api/user/login", `{"username":"bob","password":"password123"}`)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+	result = serve(http.MethodPost, "/api/shorten", `{"url":"http://google.com"}`)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusCreated, result.StatusCode)
+	otherBrowser := result.Cookies()
+	require.Len(t, otherBrowser, 1)
+	result = serve(http.MethodPost, "/api/user/login", `{"username":"ALICE","password":"password123"}`, otherBrowser...)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, types.AccountResponse{Username: "alice", Merged: 1}, decodeAccount(result))
+	loggedIn := result.Cookies()
+	require.Len(t, loggedIn, 1)
+
+	result = serve(http.MethodGet, "/api/user/urls", "", loggedIn...)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+	var urls []storage.FullInfoURLResponse
+	require.NoError(t, json.NewDecoder(result.Body).Decode(&urls))
+	assert.Len(t, urls, 2)
+
+	result = serve(http.MethodPost, "/api/user/register", `{"username":"bob","password":"password123"}`)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.Equal(t, 0, decodeAccount(result).Merged)
+	result = serve(http.MethodPost, "/api/user/login", `{"username":"bob","password":"password123"}`, registered...)
+	defer result.Body.Close()
+	require.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, 0, decodeAccount(result).Merged)
+	result = serve(http.MethodGet, "/api/user/urls", "", registered...)
+	defer result.Body.Close()
+	assert.Equal(t, http.StatusOK, result.StatusCode)
 }
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// Account - registered user with username and password, its ID is UserID of the user
+type Account struct {
+	ID           uint      `json:"id"`            // ID - UserID of account
+	Username     string    `json:"username"`      // Username - unique name for login
+	PasswordHash string    `json:"password_hash"` // PasswordHash - salted hash of account password
+	CreatedAt    time.Time `json:"created_at"`    // CreatedAt - registration time
+}
+
+// scanAccount - read account from row with id, username, password_hash and created_at columns
+func scanAccount(row *sql.Row) (Account, int) {
+	var account Account
+	err := row.Scan(&account.ID, &account.Username, &account.PasswordHash, &account.CreatedAt)
+	if err == sql.ErrNoRows {
+		return Account{}, http.StatusNotFound
+	}
+	if err != nil {
+		return Account{}, http.StatusInternalServerError
+	}
+	account.CreatedAt = account.CreatedAt.UTC()
+	return account, 0
+}
+
+// setAccount - put account into Storage
+func (strg *Storage) setAccount(account Account) {
+	strg.accountLock.Lock()
+	defer strg.accountLock.Unlock()
+	strg.accounts[account.Username] = account
+	strg.accountUsernames[account.ID] = account.Username
+}
+
+// mergeUser - move URLs and API keys of fromUserID to toUserID in Storage, returns number of moved URLs
+func (strg *Storage) mergeUser(fromUserID uint, toUserID uint) int {
+	shard := strg.userShardFor(fromUserID)
+	shard.mutex.Lock()
+	userURLs := shard.userURLs[fromUserID]
+	delete(shard.userURLs, fromUserID)
+	shard.mutex.Unlock()
+	for _, URLID := range userURLs {
+		strg.addUserURLID(toUserID, URLID)
+	}
+	strg.apiKeyLock.Lock()
+	for ID, key := range strg.apiKeys {
+		if key.UserID == fromUserID {
+			key.UserID = toUserID
+			strg.apiKeys[ID] = key
+		}
+	}
+	strg.apiKeyLock.Unlock()
+	return len(userURLs)
+}
+
+// CreateAccount - save account into Storage, http.StatusConflict is returned if its username or ID is already used
+func (strg *Storage) CreateAccount(account Account) int {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	strg.accountLock.Lock()
+	defer strg.accountLock.Unlock()
+	if _, ok := strg.accounts[account.Username]; ok {
+		return http.StatusConflict
+	}
+	if _, ok := strg.accountUsernames[account.ID]; ok {
+		return http.StatusConflict
+	}
+	if err := strg.writeRecords(NewAccountCreatedRecord(account)); err != nil {
+		return http.StatusInternalServerError
+	}
+	strg.accounts[account.Username] = account
+	strg.accountUsernames[account.ID] = account.Username
+	strg.checkLogSize()
+	return 0
+}
+
+// GetAccountByUsername - get account by its username from Storage
+func (strg *Storage) GetAccountByUsername(username string) (Account, int) {
+	strg.accountLock.RLock()
+	defer strg.accountLock.RUnlock()
+	account, ok := strg.accounts[username]
+	if !ok {
+		return Account{}, http.StatusNotFound
+	}
+	return account, 0
+}
+
+// GetAccountByUserID - get account by its UserID from Storage, http.StatusNotFound is returned for anonymous user
+func (strg *Storage) GetAccountByUserID(userID uint) (Account, int) {
+	strg.accountLock.RLock()
+	defer strg.accountLock.RUnlock()
+	username, ok := strg.accountUsernames[userID]
+	if !ok {
+		return Account{}, http.StatusNotFound
+	}
+	return strg.accounts[username], 0
+}
+
+// MergeUser - move URLs and API keys of fromUserID to toUserID in Storage, returns number of moved URLs
+func (strg *Storage) MergeUser(fromUserID uint, toUserID uint) (int, error) {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	if err := strg.writeRecords(NewUserMergedRecord(fromUserID, toUserID)); err != nil {
+		return 0, err
+	}
+	merged := strg.mergeUser(fromUserID, toUserID)
+	strg.checkLogSize()
+	return merged, nil
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxAPIKeyNameLength - max length of API key name
+const MaxAPIKeyNameLength = 128
+
+// APIKey - key of server-to-server client which acts on behalf of its owner, only hash of the key itself is stored
+type APIKey struct {
+	ID        string     `json:"id"`                   // ID - public ID of key, it is a part of the key
+	UserID    uint       `json:"user_id"`              // UserID - owner of key
+	Name      string     `json:"name"`                 // Name - name of key given by its owner
+	Hash      string     `json:"hash"`                 // Hash - hash of the key
+	Scopes    []string   `json:"scopes"`               // Scopes - operations allowed for key
+	CreatedAt time.Time  `json:"created_at"`           // CreatedAt - creation time of key
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // RevokedAt - revocation time of key, nil for active key
+}
+
+// IsRevoked - check whether key was revoked by its owner
+func (key APIKey) IsRevoked() bool {
+	return key.RevokedAt != nil
+}
+
+// scanAPIKeys - read API keys from rows with id, user_id, name, hash, scopes, created_at and revoked_at columns
+func scanAPIKeys(rows *sql.Rows) ([]APIKey, int) {
+	keys := make([]APIKey, 0)
+	for rows.Next() {
+		var key APIKey
+		var scopes string
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Hash, &scopes, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, http.StatusInternalServerError
+		}
+		key.Scopes = strings.Split(scopes, ",")
+		key.CreatedAt = key.CreatedAt.UTC()
+		if revokedAt.Valid {
+			value := revokedAt.Time.UTC()
+			key.RevokedAt = &value
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	if len(keys) == 0 {
+		return nil, http.StatusNoContent
+	}
+	return keys, http.StatusOK
+}
+
+// sortAPIKeys - sort API keys by creation time, the oldest go first
+func sortAPIKeys(keys []APIKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+}
+
+// setAPIKey - put API key into Storage
+func (strg *Storage) setAPIKey(key APIKey) {
+	strg.apiKeyLock.Lock()
+	defer strg.apiKeyLock.Unlock()
+	strg.apiKeys[key.ID] = key
+	strg.apiKeyHashes[key.Hash] = key.ID
+}
+
+// revokeAPIKey - set revocation time of API key in Storage, the first revocation time is kept
+func (strg *Storage) revokeAPIKey(ID string, revokedAt time.Time) {
+	strg.apiKeyLock.Lock()
+	defer strg.apiKeyLock.Unlock()
+	key, ok := strg.apiKeys[ID]
+	if !ok || key.IsRevoked() {
+		return
+	}
+	key.RevokedAt = &revokedAt
+	strg.apiKeys[ID] = key
+}
+
+// CreateAPIKey - save API key into Storage, http.StatusConflict is returned if its ID or hash is already used
+func (strg *Storage) CreateAPIKey(key APIKey) int {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	strg.apiKeyLock.Lock()
+	defer strg.apiKeyLock.Unlock()
+	if _, ok := strg.apiKeys[key.ID]; ok {
+		return http.StatusConflict
+	}
+	if _, ok := strg.apiKeyHashes[key.Hash]; ok {
+		return http.StatusConflict
+	}
+	if err := strg.writeRecords(NewAPIKeyCreatedRecord(key)); err != nil {
+		return http.StatusInternalServerError
+	}
+	strg.apiKeys[key.ID] = key
+	strg.apiKeyHashes[key.Hash] = key.ID
+	strg.checkLogSize()
+	return 0
+}
+
+// GetAPIKeysByUserID - get API keys of userID including revoked ones from Storage, the oldest go first
+func (strg *Storage) GetAPIKeysByUserID(userID uint) ([]APIKey, int) {
+	strg.apiKeyLock.RLock()
+	keys := make([]APIKey, 0)
+	for _, key := range strg.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+	strg.apiKeyLock.RUnlock()
+	if len(keys) == 0 {
+		return nil, http.StatusNoContent
+	}
+	sortAPIKeys(keys)
+	return keys, http.StatusOK
+}
+
+// GetAPIKeyByHash - get not revoked API key by its hash from Storage
+func (strg *Storage) GetAPIKeyByHash(hash string) (APIKey, int) {
+	strg.apiKeyLock.RLock()
+	defer strg.apiKeyLock.RUnlock()
+	key, ok := strg.apiKeys[strg.apiKeyHashes[hash]]
+	if !ok || key.IsRevoked() {
+		return APIKey{}, http.StatusNotFound
+	}
+	return key, 0
+}
+
+// RevokeAPIKey - revoke API key by its ID if it belongs to userID in Storage, revocation of revoked key does nothing
+func (strg *Storage) RevokeAPIKey(ID string, userID uint) int {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	strg.apiKeyLock.Lock()
+	defer strg.apiKeyLock.Unlock()
+	key, ok := strg.apiKeys[ID]
+	if !ok || key.UserID != userID {
+		return http.StatusNotFound
+	}
+	if key.IsRevoked() {
+		return 0
+	}
+	revokedAt := time.Now().UTC()
+	if err := strg.writeRecords(NewAPIKeyRevokedRecord(ID, userID, revokedAt)); err != nil {
+		return http.StatusInternalServerError
+	}
+	key.RevokedAt = &revokedAt
+	strg.apiKeys[ID] = key
+	strg.checkLogSize()
+	return 0
+}
@@ -0,0 +1,215 @@
+package storage
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCompactionNotSupported - error for compaction of storage which doesn't support it
+var ErrCompactionNotSupported = errors.New("storage doesn't support compaction")
+
+// CacheStats - counters of CachedStorage lookups
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`   // Hits - number of lookups served from cache
+	Misses uint64 `json:"misses"` // Misses - number of lookups passed to wrapped storage
+}
+
+// cacheLoad - in-flight reads of one key from wrapped storage
+type cacheLoad struct {
+	readers    int    // readers - number of reads in progress
+	generation uint64 // generation - number of invalidations of key since the first of reads started
+}
+
+// cacheEntry - cached URL with its expiration time
+type cacheEntry struct {
+	key       uint      // key - URL ID
+	value     URL       // value - URL
+	expiresAt time.Time // expiresAt - time after which entry isn't served
+}
+
+// CachedStorage - IRepository decorator which serves GetURLByKeyAndUserID and GetValueByKeyAndUserID from bounded LRU cache with TTL.
+// Entries are invalidated on URL deletion and value change, entry of URL with expiration time lives no longer than URL itself.
+// Value read from wrapped storage isn't cached if the key was invalidated during the read, so stale value isn't served for TTL.
+type CachedStorage struct {
+	IRepository // IRepository - wrapped storage, all not cached methods are passed to it
+
+	size    int                    // size - max number of cached entries
+	ttl     time.Duration          // ttl - time to live of cached entry
+	mutex   sync.Mutex             // mutex - guards entries and order
+	entries map[uint]*list.Element // entries - URL ID map to element of order
+	order   *list.List             // order - cache entries from the most to the least recently used
+	loads   map[uint]*cacheLoad    // loads - URL ID map to its in-flight reads from wrapped storage
+	hits    atomic.Uint64          // hits - number of lookups served from cache
+	misses  atomic.Uint64          // misses - number of lookups passed to wrapped storage
+}
+
+// NewCachedStorage - wrap storage with LRU cache of given size and entries TTL
+func NewCachedStorage(repository IRepository, size int, ttl time.Duration) *CachedStorage {
+	return &CachedStorage{
+		IRepository: repository,
+		size:        size,
+		ttl:         ttl,
+		entries:     make(map[uint]*list.Element),
+		order:       list.New(),
+		loads:       make(map[uint]*cacheLoad),
+	}
+}
+
+// get - get not expired cached value by key
+func (strg *CachedStorage) get(key uint, now time.Time) (URL, bool) {
+	strg.mutex.Lock()
+	defer strg.mutex.Unlock()
+	element, ok := strg.entries[key]
+	if !ok {
+		return URL{}, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !now.Before(entry.expiresAt) {
+		strg.order.Remove(element)
+		delete(strg.entries, key)
+		return URL{}, false
+	}
+	strg.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// startLoad - register read of key from wrapped storage, returns generation to pass to finishLoad
+func (strg *CachedStorage) startLoad(key uint) uint64 {
+	strg.mutex.Lock()
+	defer strg.mutex.Unlock()
+	load, ok := strg.loads[key]
+	if !ok {
+		load = &cacheLoad{}
+		strg.loads[key] = load
+	}
+	load.readers++
+	return load.generation
+}
+
+// finishLoad - unregister read of key and cache its value unless key was invalidated after the read started
+func (strg *CachedStorage) finishLoad(key uint, generation uint64, value URL, found bool, now time.Time) {
+	strg.mutex.Lock()
+	defer strg.mutex.Unlock()
+	load := strg.loads[key]
+	load.readers--
+	if load.readers == 0 {
+		delete(strg.loads, key)
+	}
+	if found && load.generation == generation {
+		strg.put(key, value, now)
+	}
+}
+
+// put - cache value by key evicting the least recently used entry if cache is full, mutex must be held
+func (strg *CachedStorage) put(key uint, value URL, now time.Time) {
+	expiresAt := now.Add(strg.ttl)
+	if !value.ExpiresAt.IsZero() && value.ExpiresAt.Before(expiresAt) {
+		expiresAt = value.ExpiresAt
+	}
+	if element, ok := strg.entries[key]; ok {
+		element.Value = &cacheEntry{key: key, value: value, expiresAt: expiresAt}
+		strg.order.MoveToFront(element)
+		return
+	}
+	if strg.order.Len() >= strg.size {
+		oldest := strg.order.Back()
+		strg.order.Remove(oldest)
+		delete(strg.entries, oldest.Value.(*cacheEntry).key)
+	}
+	strg.entries[key] = strg.order.PushFront(&cacheEntry{key: key, value: value, expiresAt: expiresAt})
+}
+
+// invalidate - remove cached values by keys
+func (strg *CachedStorage) invalidate(keys []uint) {
+	strg.mutex.Lock()
+	defer strg.mutex.Unlock()
+	for _, key := range keys {
+		if element, ok := strg.entries[key]; ok {
+			strg.order.Remove(element)
+			delete(strg.entries, key)
+		}
+		if load, ok := strg.loads[key]; ok {
+			load.generation++
+		}
+	}
+}
+
+// invalidateAll - remove all cached values
+func (strg *CachedStorage) invalidateAll() {
+	strg.mutex.Lock()
+	defer strg.mutex.Unlock()
+	strg.entries = make(map[uint]*list.Element)
+	strg.order.Init()
+	for _, load := range strg.loads {
+		load.generation++
+	}
+}
+
+// GetValueByKeyAndUserID - get value by key and userID from cache or from wrapped storage
+func (strg *CachedStorage) GetValueByKeyAndUserID(key uint, userID uint) (string, int) {
+	value, errCode := strg.GetURLByKeyAndUserID(key, userID)
+	return value.Value, errCode
+}
+
+// GetURLByKeyAndUserID - get URL by key and userID from cache or from wrapped storage
+func (strg *CachedStorage) GetURLByKeyAndUserID(key uint, userID uint) (URL, int) {
+	now := time.Now()
+	if value, ok := strg.get(key, now); ok {
+		strg.hits.Add(1)
+		return value, 0
+	}
+	strg.misses.Add(1)
+	generation := strg.startLoad(key)
+	value, errCode := strg.IRepository.GetURLByKeyAndUserID(key, userID)
+	strg.finishLoad(key, generation, value, errCode == 0, now)
+	return value, errCode
+}
+
+// MarkBatchAsDeleted - set deleted=true for rows by its IDs and userID in wrapped storage and drop them from cache
+func (strg *CachedStorage) MarkBatchAsDeleted(IDs []uint, userID uint) error {
+	err := strg.IRepository.MarkBatchAsDeleted(IDs, userID)
+	strg.invalidate(IDs)
+	return err
+}
+
+// UpdateURLValue - set new value for URL in wrapped storage and drop it from cache
+func (strg *CachedStorage) UpdateURLValue(key uint, value string, userID uint) (errMsg string, errCode int) {
+	errMsg, errCode = strg.IRepository.UpdateURLValue(key, value, userID)
+	strg.invalidate([]uint{key})
+	return errMsg, errCode
+}
+
+// ConsumeClick - count click of URL in wrapped storage and drop it from cache, so cached URL doesn't outlive its clicks limit
+func (strg *CachedStorage) ConsumeClick(key uint) int {
+	errCode := strg.IRepository.ConsumeClick(key)
+	strg.invalidate([]uint{key})
+	return errCode
+}
+
+// MarkExpiredAsDeleted - set deleted=true for expired URLs in wrapped storage and drop cache if any URL expired
+func (strg *CachedStorage) MarkExpiredAsDeleted(now time.Time) (int, error) {
+	count, err := strg.IRepository.MarkExpiredAsDeleted(now)
+	if count > 0 {
+		strg.invalidateAll()
+	}
+	return count, err
+}
+
+// GetStats - get stats from wrapped storage together with cache counters
+func (strg *CachedStorage) GetStats() (response StatsResponse, errCode int) {
+	response, errCode = strg.IRepository.GetStats()
+	response.Cache = &CacheStats{Hits: strg.hits.Load(), Misses: strg.misses.Load()}
+	return response, errCode
+}
+
+// Compact - compact wrapped storage if it supports compaction
+func (strg *CachedStorage) Compact() error {
+	compactor, ok := strg.IRepository.(ICompactor)
+	if !ok {
+		return ErrCompactionNotSupported
+	}
+	return compactor.Compact()
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCachedStorage(size int, ttl time.Duration) *CachedStorage {
+	return NewCachedStorage(
+		NewMemoryStorage(map[uint]URL{1: {Value: "aaa"}, 2: {Value: "bbb"}, 3: {Value: "ccc"}}, map[uint][]uint{1: {1, 2, 3}}, 4),
+		size,
+		ttl,
+	)
+}
+
+func TestCachedStorage_GetValueByKeyAndUserID(t *testing.T) {
+	tests := []struct {
+		name         string
+		size         int
+		ttl          time.Duration
+		keys         []uint
+		expectedHits uint64
+	}{
+		{
+			"repeated_key",
+			2,
+			time.Minute,
+			[]uint{1, 1, 1},
+			2,
+		},
+		{
+			"least_recently_used_evicted",
+			2,
+			time.Minute,
+			[]uint{1, 2, 3, 1},
+			0,
+		},
+		{
+			"recently_used_kept",
+			2,
+			time.Minute,
+			[]uint{1, 2, 1, 3, 1},
+			2,
+		},
+		{
+			"expired_entry",
+			2,
+			time.Nanosecond,
+			[]uint{1, 1},
+			0,
+		},
+		{
+			"missing_key_not_cached",
+			2,
+			time.Minute,
+			[]uint{5, 5},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strg := newTestCachedStorage(tt.size, tt.ttl)
+			for _, key := range tt.keys {
+				strg.GetValueByKeyAndUserID(key, 1)
+			}
+			stats, errCode := strg.GetStats()
+			assert.Equal(t, http.StatusOK, errCode)
+			assert.Equal(t, &CacheStats{Hits: tt.expectedHits, Misses: uint64(len(tt.keys)) - tt.expectedHits}, stats.Cache)
+		})
+	}
+}
+
+func TestCachedStorage_MarkBatchAsDeleted(t *testing.T) {
+	strg := newTestCachedStorage(10, time.Minute)
+	value, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "aaa", value)
+
+	assert.Nil(t, strg.MarkBatchAsDeleted([]uint{1}, 1))
+	_, errCode = strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+}
+
+func TestCachedStorage_UpdateURLValue(t *testing.T) {
+	strg := newTestCachedStorage(10, time.Minute)
+	_, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+
+	_, errCode = strg.UpdateURLValue(1, "ddd", 1)
+	assert.Equal(t, 0, errCode)
+	value, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "ddd", value)
+}
+
+func TestCachedStorage_MarkExpiredAsDeleted(t *testing.T) {
+	now := time.Now()
+	strg := NewCachedStorage(NewMemoryStorage(map[uint]URL{1: {Value: "aaa", ExpiresAt: now.Add(time.Hour)}}, map[uint][]uint{1: {1}}, 2), 10, time.Hour)
+	_, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+
+	count, err := strg.MarkExpiredAsDeleted(now.Add(2 * time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	_, errCode = strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+}
+
+func TestCachedStorage_ExpiredURL(t *testing.T) {
+	strg := NewCachedStorage(NewMemoryStorage(map[uint]URL{1: {Value: "aaa", ExpiresAt: time.Now().Add(50 * time.Millisecond)}}, map[uint][]uint{1: {1}}, 2), 10, time.Hour)
+	_, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	_, errCode = strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+
+	time.Sleep(100 * time.Millisecond)
+	_, errCode = strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+	stats, _ := strg.GetStats()
+	assert.Equal(t, &CacheStats{Hits: 1, Misses: 2}, stats.Cache)
+}
+
+func TestCachedStorage_Compact(t *testing.T) {
+	strg := newTestCachedStorage(10, time.Minute)
+	assert.Nil(t, strg.Compact())
+
+	strg = NewCachedStorage(newTestSQLiteStorage(t), 10, time.Minute)
+	assert.ErrorIs(t, strg.Compact(), ErrCompactionNotSupported)
+}
+
+// pausedRepository - IRepository which pauses the first GetURLByKeyAndUserID after reading URL until released
+type pausedRepository struct {
+	IRepository
+	once    sync.Once
+	read    chan struct{}
+	release chan struct{}
+}
+
+func (strg *pausedRepository) GetURLByKeyAndUserID(key uint, userID uint) (URL, int) {
+	value, errCode := strg.IRepository.GetURLByKeyAndUserID(key, userID)
+	strg.once.Do(func() {
+		strg.read <- struct{}{}
+		<-strg.release
+	})
+	return value, errCode
+}
+
+func TestCachedStorage_InvalidateDuringFill(t *testing.T) {
+	tests := []struct {
+		name            string
+		invalidate      func(strg *CachedStorage)
+		expectedValue   string
+		expectedErrCode int
+	}{
+		{
+			"update_url_value",
+			func(strg *CachedStorage) {
+				_, errCode := strg.UpdateURLValue(1, "ddd", 1)
+				assert.Equal(t, 0, errCode)
+			},
+			"ddd",
+			0,
+		},
+		{
+			"mark_batch_as_deleted",
+			func(strg *CachedStorage) {
+				assert.Nil(t, strg.MarkBatchAsDeleted([]uint{1}, 1))
+			},
+			"",
+			http.StatusGone,
+		},
+		{
+			"mark_expired_as_deleted",
+			func(strg *CachedStorage) {
+				count, err := strg.MarkExpiredAsDeleted(time.Now().Add(2 * time.Hour))
+				assert.Nil(t, err)
+				assert.Equal(t, 1, count)
+			},
+			"",
+			http.StatusGone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paused := &pausedRepository{
+				IRepository: NewMemoryStorage(map[uint]URL{1: {Value: "aaa", ExpiresAt: time.Now().Add(time.Hour)}}, map[uint][]uint{1: {1}}, 2),
+				read:        make(chan struct{}),
+				release:     make(chan struct{}),
+			}
+			strg := NewCachedStorage(paused, 10, time.Hour)
+			filled := make(chan string)
+			go func() {
+				value, _ := strg.GetValueByKeyAndUserID(1, 1)
+				filled <- value
+			}()
+
+			<-paused.read
+			tt.invalidate(strg)
+			close(paused.release)
+			assert.Equal(t, "aaa", <-filled)
+
+			value, errCode := strg.GetValueByKeyAndUserID(1, 1)
+			assert.Equal(t, tt.expectedErrCode, errCode)
+			assert.Equal(t, tt.expectedValue, value)
+			assert.Empty(t, strg.loads)
+		})
+	}
+}
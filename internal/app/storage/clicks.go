@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// ClicksSuffix - suffix for Storage clicks file name, clicks are stored next to the log file
+const ClicksSuffix = ".clicks"
+
+// ClickDayLayout - layout of day in click statistics
+const ClickDayLayout = "2006-01-02"
+
+// Click - event of redirect by short URL
+type Click struct {
+	URLID        uint      `json:"url_id"`                  // URLID - ID of clicked URL
+	Time         time.Time `json:"time"`                    // Time - time of redirect
+	Referrer     string    `json:"referrer,omitempty"`      // Referrer - Referer header of request
+	UserAgent    string    `json:"user_agent,omitempty"`    // UserAgent - User-Agent header of request
+	ClientSubnet string    `json:"client_subnet,omitempty"` // ClientSubnet - subnet of client IP address
+}
+
+// DayClicks - number of clicks during one day
+type DayClicks struct {
+	Day    string `json:"day"`    // Day - day in ClickDayLayout, UTC
+	Clicks int    `json:"clicks"` // Clicks - number of clicks during the day
+}
+
+// ClickStatsResponse - response object for URL click statistics
+type ClickStatsResponse struct {
+	Total int         `json:"total"` // Total - total number of clicks
+	Days  []DayClicks `json:"days"`  // Days - number of clicks per day in ascending order
+}
+
+// addClick - add click to in-memory click statistics, clickMutex should be held
+func (strg *Storage) addClick(click Click) {
+	days, ok := strg.clickStats[click.URLID]
+	if !ok {
+		days = make(map[string]int)
+		strg.clickStats[click.URLID] = days
+	}
+	days[click.Time.UTC().Format(ClickDayLayout)]++
+}
+
+// loadClicks - open clicks file and load click statistics from it
+func (strg *Storage) loadClicks(filename string) error {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0777)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(file)
+	for {
+		var click Click
+		err := decoder.Decode(&click)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Drop partially written tail so that new clicks are appended right after the last valid one
+			if err := file.Truncate(decoder.InputOffset()); err != nil {
+				file.Close()
+				return err
+			}
+			break
+		}
+		if !strg.isPurged(click.URLID) {
+			strg.addClick(click)
+		}
+	}
+	strg.clickFile = file
+	strg.clickEncoder = json.NewEncoder(file)
+	return nil
+}
+
+// removeClicks - drop click statistics for URLIDs
+func (strg *Storage) removeClicks(keys map[uint]bool) {
+	strg.clickMutex.Lock()
+	defer strg.clickMutex.Unlock()
+	for key := range keys {
+		delete(strg.clickStats, key)
+	}
+}
+
+// InsertClicks - save clicks batch into Storage
+func (strg *Storage) InsertClicks(clicks []Click) error {
+	strg.clickMutex.Lock()
+	defer strg.clickMutex.Unlock()
+	for _, click := range clicks {
+		if strg.clickEncoder != nil {
+			if err := strg.clickEncoder.Encode(click); err != nil {
+				return err
+			}
+		}
+		strg.addClick(click)
+	}
+	return nil
+}
+
+// GetClickStats - get click statistics for URL by key if it belongs to userID
+func (strg *Storage) GetClickStats(key uint, userID uint) (ClickStatsResponse, int) {
+	userURLs, _ := strg.getUserURLIDs(userID)
+	owned := false
+	for _, URLID := range userURLs {
+		owned = owned || URLID == key
+	}
+	if !owned {
+		return ClickStatsResponse{}, http.StatusNotFound
+	}
+	strg.clickMutex.Lock()
+	defer strg.clickMutex.Unlock()
+	response := ClickStatsResponse{Days: make([]DayClicks, 0)}
+	for day, clicks := range strg.clickStats[key] {
+		response.Total += clicks
+		response.Days = append(response.Days, DayClicks{Day: day, Clicks: clicks})
+	}
+	sort.Slice(response.Days, func(i, j int) bool { return response.Days[i].Day < response.Days[j].Day })
+	return response, http.StatusOK
+}
+
+// closeClicks - close clicks file if it is used
+func (strg *Storage) closeClicks() error {
+	strg.clickMutex.Lock()
+	defer strg.clickMutex.Unlock()
+	if strg.clickFile == nil {
+		return nil
+	}
+	err := strg.clickFile.Close()
+	strg.clickFile, strg.clickEncoder = nil, nil
+	return err
+}
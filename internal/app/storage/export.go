@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// ExportedURL - URL info for user URLs export
+type ExportedURL struct {
+	ShortURL    string     `json:"short_url"`            // ShortURL - result shorten URL
+	OriginalURL string     `json:"original_url"`         // OriginalURL - original URL
+	Deleted     bool       `json:"deleted"`              // Deleted - true if URL is marked as deleted
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // ExpiresAt - time after which URL stops working, nil if URL never expires
+}
+
+// newExportedURL - create ExportedURL for URL with given ID
+func newExportedURL(ID uint, url URL, baseURL string) ExportedURL {
+	exported := ExportedURL{ShortURL: baseURL + GetShortURL(ID, url.Alias), OriginalURL: url.Value, Deleted: url.Deleted}
+	if !url.ExpiresAt.IsZero() {
+		expiresAt := url.ExpiresAt.UTC()
+		exported.ExpiresAt = &expiresAt
+	}
+	return exported
+}
+
+// ExportPageSize - number of URLs read by one query during export from SQLiteStorage
+const ExportPageSize = 100
+
+// scanURLRow - read current row with id, value, alias, deleted and expires_at columns
+func scanURLRow(rows *sql.Rows) (uint, URL, error) {
+	// URLID - URL ID
+	var URLID uint
+	var url URL
+	var expiresAt sql.NullTime
+	if err := rows.Scan(&URLID, &url.Value, &url.Alias, &url.Deleted, &expiresAt); err != nil {
+		return 0, URL{}, err
+	}
+	url.ExpiresAt = expiresAt.Time
+	return URLID, url, nil
+}
+
+// iterateURLRows - pass rows with id, value, alias, deleted and expires_at columns to yield one by one
+func iterateURLRows(rows *sql.Rows, baseURL string, yield func(url ExportedURL) error) error {
+	for rows.Next() {
+		URLID, url, err := scanURLRow(rows)
+		if err != nil {
+			return err
+		}
+		if err := yield(newExportedURL(URLID, url, baseURL)); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// IterateURLsByUserID - pass all URLs of userID including deleted ones to yield in creation order, stops on the first yield error
+func (strg *Storage) IterateURLsByUserID(userID uint, baseURL string, yield func(url ExportedURL) error) error {
+	userURLs, _ := strg.getUserURLIDs(userID)
+	sort.Slice(userURLs, func(i, j int) bool { return userURLs[i] < userURLs[j] })
+	// URLID - URL ID
+	for _, URLID := range userURLs {
+		url, ok := strg.getURL(URLID)
+		if !ok {
+			continue
+		}
+		if err := yield(newExportedURL(URLID, url, baseURL)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
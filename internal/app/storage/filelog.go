@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// LogRecordVersion - current version of Storage file log format
+const LogRecordVersion = 1
+
+// Types of LogRecord
+const (
+	RecordCreated       = "created"  // RecordCreated - URL was created with Key and Value
+	RecordOwnerAssigned = "owner"    // RecordOwnerAssigned - URL by Key belongs to UserID
+	RecordDeleted       = "deleted"  // RecordDeleted - URL by Key was marked as deleted by UserID, UserID is 0 for expired URL
+	RecordRestored      = "restored" // RecordRestored - deleted URL by Key was restored by UserID
+	RecordPurged        = "purged"   // RecordPurged - deleted URL by Key was removed after retention period, Key is never reissued
+	RecordUpdated       = "updated"  // RecordUpdated - value of URL by Key was changed from PreviousValue to Value by UserID
+	RecordClicked       = "clicked"  // RecordClicked - URL by Key limited with max clicks was clicked, UsedClicks is its clicks count after it
+
+	RecordAPIKeyCreated  = "api_key_created" // RecordAPIKeyCreated - APIKey was created, Key is 0
+	RecordAPIKeyRevoked  = "api_key_revoked" // RecordAPIKeyRevoked - API key by APIKeyID was revoked by UserID at RevokedAt, Key is 0
+	RecordAccountCreated = "account_created" // RecordAccountCreated - Account was registered, Key is 0
+	RecordUserMerged     = "user_merged"     // RecordUserMerged - URLs and API keys of UserID were moved to ToUserID, Key is 0
+)
+
+// LogRecord - event of append-only Storage file log.
+//
+// Lines written before the log became versioned are plain MapItem objects. They are decoded into
+// LogRecord with Version = 0 and empty Type and are treated as RecordCreated.
+type LogRecord struct {
+	Version   int        `json:"version"`              // Version - version of record format
+	Seq       uint64     `json:"seq,omitempty"`        // Seq - sequence number of record in log, absent in records written before it was added
+	Type      string     `json:"type"`                 // Type - record type, one of RecordCreated, RecordOwnerAssigned, RecordDeleted
+	Key       uint       `json:"key"`                  // Key - key for URL
+	Value     string     `json:"value,omitempty"`      // Value - value for URL, set for RecordCreated and RecordUpdated
+	Alias     string     `json:"alias,omitempty"`      // Alias - custom alias for URL, set for RecordCreated only
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // ExpiresAt - expiration time for URL, set for RecordCreated only
+	UserID    uint       `json:"user_id,omitempty"`    // UserID - user ID for RecordOwnerAssigned, RecordDeleted, RecordRestored, RecordUpdated and API key records
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // DeletedAt - deletion time for RecordDeleted, absent in records written before it was added
+
+	RedirectType        int        `json:"redirect_type,omitempty"`        // RedirectType - redirect status code for URL, set for RecordCreated only
+	Title               string     `json:"title,omitempty"`                // Title - title of URL for preview page, set for RecordCreated only
+	RequireInterstitial bool       `json:"require_interstitial,omitempty"` // RequireInterstitial - true if redirect goes through preview page, set for RecordCreated only
+	CreatedAt           *time.Time `json:"created_at,omitempty"`           // CreatedAt - creation time for RecordCreated, absent in records written before it was added
+	PasswordHash        string     `json:"password_hash,omitempty"`        // PasswordHash - salted hash of URL password, set for RecordCreated only
+	MaxClicks           int        `json:"max_clicks,omitempty"`           // MaxClicks - number of clicks after which URL stops working, set for RecordCreated only
+	UsedClicks          int        `json:"used_clicks,omitempty"`          // UsedClicks - clicks count of URL, set for RecordClicked only
+
+	PreviousValue string     `json:"previous_value,omitempty"` // PreviousValue - value of URL before change, set for RecordUpdated only
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`     // UpdatedAt - time of URL value change, set for RecordUpdated only
+
+	APIKey    *APIKey    `json:"api_key,omitempty"`    // APIKey - created API key, set for RecordAPIKeyCreated only
+	APIKeyID  string     `json:"api_key_id,omitempty"` // APIKeyID - ID of revoked API key, set for RecordAPIKeyRevoked only
+	RevokedAt *time.Time `json:"revoked_at,omitempty"` // RevokedAt - revocation time of API key, set for RecordAPIKeyRevoked only
+
+	Account  *Account `json:"account,omitempty"`    // Account - registered account, set for RecordAccountCreated only
+	ToUserID uint     `json:"to_user_id,omitempty"` // ToUserID - user which got URLs and API keys of UserID, set for RecordUserMerged only
+}
+
+// NewCreatedRecord - create RecordCreated LogRecord for URL
+func NewCreatedRecord(key uint, value URL) LogRecord {
+	record := LogRecord{
+		Version: LogRecordVersion, Type: RecordCreated, Key: key, Value: value.Value, Alias: value.Alias,
+		RedirectType: value.RedirectType, Title: value.Title, RequireInterstitial: value.RequireInterstitial, PasswordHash: value.PasswordHash,
+		MaxClicks: value.MaxClicks,
+	}
+	if !value.ExpiresAt.IsZero() {
+		record.ExpiresAt = &value.ExpiresAt
+	}
+	if !value.CreatedAt.IsZero() {
+		record.CreatedAt = &value.CreatedAt
+	}
+	return record
+}
+
+// NewOwnerAssignedRecord - create RecordOwnerAssigned LogRecord for URL and user
+func NewOwnerAssignedRecord(key uint, userID uint) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordOwnerAssigned, Key: key, UserID: userID}
+}
+
+// NewClickedRecord - create RecordClicked LogRecord for URL with given clicks count.
+// Count is stored instead of increment, so record applied twice doesn't consume extra click.
+func NewClickedRecord(key uint, usedClicks int) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordClicked, Key: key, UsedClicks: usedClicks}
+}
+
+// NewDeletedRecord - create RecordDeleted LogRecord for URL deleted by user at deletedAt
+func NewDeletedRecord(key uint, userID uint, deletedAt time.Time) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordDeleted, Key: key, UserID: userID, DeletedAt: &deletedAt}
+}
+
+// NewRestoredRecord - create RecordRestored LogRecord for URL and user
+func NewRestoredRecord(key uint, userID uint) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordRestored, Key: key, UserID: userID}
+}
+
+// NewPurgedRecord - create RecordPurged LogRecord for URL
+func NewPurgedRecord(key uint) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordPurged, Key: key}
+}
+
+// NewUpdatedRecord - create RecordUpdated LogRecord for URL value change
+func NewUpdatedRecord(key uint, change URLChange) LogRecord {
+	return LogRecord{
+		Version: LogRecordVersion, Type: RecordUpdated, Key: key, Value: change.NewValue, UserID: change.UserID,
+		PreviousValue: change.OldValue, UpdatedAt: &change.ChangedAt,
+	}
+}
+
+// NewAPIKeyCreatedRecord - create RecordAPIKeyCreated LogRecord for API key
+func NewAPIKeyCreatedRecord(key APIKey) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordAPIKeyCreated, UserID: key.UserID, APIKey: &key}
+}
+
+// NewAPIKeyRevokedRecord - create RecordAPIKeyRevoked LogRecord for API key revoked by user at revokedAt
+func NewAPIKeyRevokedRecord(ID string, userID uint, revokedAt time.Time) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordAPIKeyRevoked, UserID: userID, APIKeyID: ID, RevokedAt: &revokedAt}
+}
+
+// NewAccountCreatedRecord - create RecordAccountCreated LogRecord for account
+func NewAccountCreatedRecord(account Account) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordAccountCreated, UserID: account.ID, Account: &account}
+}
+
+// NewUserMergedRecord - create RecordUserMerged LogRecord for moving URLs and API keys of user to another one
+func NewUserMergedRecord(fromUserID uint, toUserID uint) LogRecord {
+	return LogRecord{Version: LogRecordVersion, Type: RecordUserMerged, UserID: fromUserID, ToUserID: toUserID}
+}
+
+// writeRecords - append records to Storage file log if it is used, every record gets the next sequence number
+func (strg *Storage) writeRecords(records ...LogRecord) error {
+	if strg.Encoder == nil {
+		return nil
+	}
+	strg.logMutex.Lock()
+	defer strg.logMutex.Unlock()
+	for _, record := range records {
+		strg.logSeq++
+		record.Seq = strg.logSeq
+		if err := strg.Encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyRecord - apply LogRecord to Storage in-memory state.
+// Records for purged URLs are skipped, they could be met if compaction was interrupted before log truncation.
+func (strg *Storage) applyRecord(record LogRecord) {
+	if strg.isPurged(record.Key) {
+		return
+	}
+	switch record.Type {
+	case "", RecordCreated:
+		url := URL{
+			Value: record.Value, Alias: record.Alias, RedirectType: record.RedirectType,
+			Title: record.Title, RequireInterstitial: record.RequireInterstitial, PasswordHash: record.PasswordHash,
+			MaxClicks: record.MaxClicks,
+		}
+		if record.ExpiresAt != nil {
+			url.ExpiresAt = *record.ExpiresAt
+		}
+		if record.CreatedAt != nil {
+			url.CreatedAt = *record.CreatedAt
+		}
+		strg.setURL(record.Key, url)
+		strg.setValueID(record.Value, record.Key)
+		strg.setAlias(record.Alias, record.Key)
+		strg.bumpNextIndex(record.Key + 1)
+	case RecordOwnerAssigned:
+		// Record could be already applied from snapshot if compaction was interrupted before log truncation
+		strg.addUserURLID(record.UserID, record.Key)
+	case RecordDeleted:
+		strg.updateURL(record.Key, func(value *URL) {
+			if value.Deleted {
+				return
+			}
+			value.Deleted = true
+			if record.DeletedAt != nil {
+				value.DeletedAt = *record.DeletedAt
+			}
+		})
+	case RecordRestored:
+		strg.updateURL(record.Key, func(value *URL) {
+			value.Deleted = false
+			value.DeletedAt = time.Time{}
+		})
+	case RecordUpdated:
+		change := URLChange{OldValue: record.PreviousValue, NewValue: record.Value, UserID: record.UserID}
+		if record.UpdatedAt != nil {
+			change.ChangedAt = *record.UpdatedAt
+		}
+		strg.applyURLChange(record.Key, change)
+	case RecordClicked:
+		strg.updateURL(record.Key, func(value *URL) {
+			if record.UsedClicks > value.UsedClicks {
+				value.UsedClicks = record.UsedClicks
+			}
+		})
+	case RecordPurged:
+		strg.purgeURL(record.Key, nil)
+		strg.addTombstone(record.Key)
+	case RecordAPIKeyCreated:
+		if record.APIKey != nil {
+			strg.setAPIKey(*record.APIKey)
+		}
+	case RecordAPIKeyRevoked:
+		if record.RevokedAt != nil {
+			strg.revokeAPIKey(record.APIKeyID, *record.RevokedAt)
+		}
+	case RecordAccountCreated:
+		if record.Account != nil {
+			strg.setAccount(*record.Account)
+		}
+	case RecordUserMerged:
+		strg.mergeUser(record.UserID, record.ToUserID)
+	}
+}
+
+// replayRecords - read all records from decoder and apply them to Storage, returns offset right after the last read record.
+// Records with sequence number below snapshotSeq are already covered by snapshot and are skipped,
+// they are met if compaction was interrupted before log truncation.
+// Replay stops at the end of the log or at the first broken record.
+// Purged URLs are removed from users lists once after replay.
+func (strg *Storage) replayRecords(decoder *json.Decoder, snapshotSeq uint64) (int64, error) {
+	purged := make(map[uint]bool)
+	defer func() { strg.removeUserURLIDs(purged) }()
+	for {
+		offset := decoder.InputOffset()
+		var record LogRecord
+		err := decoder.Decode(&record)
+		if err == io.EOF {
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+		if record.Seq > strg.logSeq {
+			strg.logSeq = record.Seq
+		}
+		if record.Seq < snapshotSeq {
+			continue
+		}
+		if record.Type == RecordPurged {
+			purged[record.Key] = true
+		}
+		strg.applyRecord(record)
+	}
+}
+
+// truncatePartialRecord - drop partially written last record of log file which starts at offset,
+// so that new records are appended right after the last valid one.
+// Broken record followed by a line break isn't a partial write, error is returned for such log as it is corrupted.
+func truncatePartialRecord(file *os.File, offset int64) error {
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	tail := make([]byte, info.Size()-offset)
+	if _, err := file.ReadAt(tail, offset); err != nil {
+		return err
+	}
+	if bytes.ContainsRune(bytes.TrimLeft(tail, " \t\r\n"), '\n') {
+		return fmt.Errorf("log %s is corrupted at offset %d", file.Name(), offset)
+	}
+	return file.Truncate(offset)
+}
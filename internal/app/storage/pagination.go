@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"database/sql"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// MaxPageLimit - max number of URLs in one page of user URLs
+const MaxPageLimit = 1000
+
+// URLsPageQuery - parameters for getting page of user URLs.
+// URLs are ordered by ID, which grows with URL creation time.
+type URLsPageQuery struct {
+	Limit  int    // Limit - max number of URLs in page, MaxPageLimit is used if it's out of range
+	After  uint   // After - ID of the last URL from the previous page, 0 for the first page
+	Desc   bool   // Desc - order URLs from the newest to the oldest
+	Filter string // Filter - substring of original URL, empty Filter matches all URLs
+}
+
+// URLsPage - page of user URLs
+type URLsPage struct {
+	URLs      []FullInfoURLResponse // URLs - URLs in page
+	NextAfter uint                  // NextAfter - After value for the next page, 0 if it is the last page
+}
+
+// limit - get page size for query
+func (query URLsPageQuery) limit() int {
+	if query.Limit <= 0 || query.Limit > MaxPageLimit {
+		return MaxPageLimit
+	}
+	return query.Limit
+}
+
+// follows - check that URL ID goes after query cursor
+func (query URLsPageQuery) follows(ID uint) bool {
+	if query.After == 0 {
+		return true
+	}
+	if query.Desc {
+		return ID < query.After
+	}
+	return ID > query.After
+}
+
+// sqlBounds - get SQL order direction, comparison operator and cursor value for query
+func (query URLsPageQuery) sqlBounds() (order string, comparison string, after int64) {
+	if !query.Desc {
+		return "ASC", ">", int64(query.After)
+	}
+	if query.After == 0 {
+		return "DESC", "<", math.MaxInt64
+	}
+	return "DESC", "<", int64(query.After)
+}
+
+// scanURLsPage - read page of URLs from rows with id, value and alias columns, rows should be limited by limit + 1
+func scanURLsPage(rows *sql.Rows, limit int, baseURL string) (URLsPage, int) {
+	page := URLsPage{URLs: make([]FullInfoURLResponse, 0)}
+	var lastID uint
+	for rows.Next() {
+		// URLID - URL ID
+		var URLID uint
+		var originalURL, alias string
+		var remainingClicks sql.NullInt64
+		if err := rows.Scan(&URLID, &originalURL, &alias, &remainingClicks); err != nil {
+			return URLsPage{}, http.StatusInternalServerError
+		}
+		if len(page.URLs) == limit {
+			page.NextAfter = lastID
+			break
+		}
+		URL := FullInfoURLResponse{ShortURL: baseURL + GetShortURL(URLID, alias), OriginalURL: originalURL}
+		if remainingClicks.Valid {
+			remaining := int(remainingClicks.Int64)
+			URL.RemainingClicks = &remaining
+		}
+		page.URLs = append(page.URLs, URL)
+		lastID = URLID
+	}
+	if err := rows.Err(); err != nil {
+		return URLsPage{}, http.StatusInternalServerError
+	}
+	if len(page.URLs) == 0 {
+		return page, http.StatusNoContent
+	}
+	return page, http.StatusOK
+}
+
+// GetURLsPageByUserID - get page of not deleted URLs by userID from Storage
+func (strg *Storage) GetURLsPageByUserID(userID uint, query URLsPageQuery, baseURL string) (URLsPage, int) {
+	page := URLsPage{URLs: make([]FullInfoURLResponse, 0)}
+	userURLs, _ := strg.getUserURLIDs(userID)
+	sort.Slice(userURLs, func(i, j int) bool {
+		if query.Desc {
+			return userURLs[i] > userURLs[j]
+		}
+		return userURLs[i] < userURLs[j]
+	})
+	limit := query.limit()
+	var lastID uint
+	// URLID - URL ID
+	for _, URLID := range userURLs {
+		if !query.follows(URLID) {
+			continue
+		}
+		originalURL, ok := strg.getURL(URLID)
+		if !ok || originalURL.Deleted || !strings.Contains(originalURL.Value, query.Filter) {
+			continue
+		}
+		if len(page.URLs) == limit {
+			page.NextAfter = lastID
+			break
+		}
+		page.URLs = append(page.URLs, FullInfoURLResponse{
+			ShortURL:        baseURL + GetShortURL(URLID, originalURL.Alias),
+			OriginalURL:     originalURL.Value,
+			RemainingClicks: originalURL.RemainingClicks(),
+		})
+		lastID = URLID
+	}
+	if len(page.URLs) == 0 {
+		return page, http.StatusNoContent
+	}
+	return page, http.StatusOK
+}
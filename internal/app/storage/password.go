@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxPasswordLength - max length of URL or account password in bytes, longer passwords are truncated by bcrypt
+const MaxPasswordLength = 72
+
+// HashPassword - get salted hash of URL or account password which is stored instead of password itself
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// IsProtected - check that URL is protected with password
+func (url URL) IsProtected() bool {
+	return url.PasswordHash != ""
+}
+
+// CheckPassword - check that password matches URL password hash, always true for not protected URL
+func (url URL) CheckPassword(password string) bool {
+	if !url.IsProtected() {
+		return true
+	}
+	return CheckPasswordHash(url.PasswordHash, password)
+}
+
+// CheckPasswordHash - check that password matches hash made by HashPassword
+func CheckPasswordHash(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// CheckPassword - check that password matches account password hash
+func (account Account) CheckPassword(password string) bool {
+	return CheckPasswordHash(account.PasswordHash, password)
+}
@@ -0,0 +1,39 @@
+package storage
+
+import "time"
+
+// PurgeDeleted - remove URLs deleted before deletedBefore with their owners and clicks from Storage.
+// IDs of purged URLs are kept as tombstones, so they are never reissued, while their values could be shortened again.
+// URLs deleted before deletion time was stored are never purged.
+func (strg *Storage) PurgeDeleted(deletedBefore time.Time) (int, error) {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	canPurge := func(url URL) bool {
+		return url.Deleted && !url.DeletedAt.IsZero() && url.DeletedAt.Before(deletedBefore)
+	}
+	candidates := make([]uint, 0)
+	for i := range strg.urlShards {
+		strg.urlShards[i].mutex.RLock()
+		for key, url := range strg.urlShards[i].urls {
+			if canPurge(url) {
+				candidates = append(candidates, key)
+			}
+		}
+		strg.urlShards[i].mutex.RUnlock()
+	}
+	purged := make(map[uint]bool, len(candidates))
+	var err error
+	for _, key := range candidates {
+		if !strg.purgeURL(key, canPurge) {
+			continue
+		}
+		purged[key] = true
+		if err = strg.writeRecords(NewPurgedRecord(key)); err != nil {
+			break
+		}
+	}
+	strg.removeUserURLIDs(purged)
+	strg.removeClicks(purged)
+	strg.checkLogSize()
+	return len(purged), err
+}
@@ -0,0 +1,513 @@
+package storage
+
+import (
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ShardsCount - number of shards for every Storage map
+const ShardsCount = 32
+
+// urlShard - part of URLID map to URL struct with its own lock
+type urlShard struct {
+	mutex   sync.RWMutex         // mutex - guards urls, purged and history
+	urls    map[uint]URL         // urls - URLID map to URL struct
+	purged  map[uint]bool        // purged - tombstones of purged URLIDs, they are never reissued
+	history map[uint][]URLChange // history - URLID map to changes of URL value from the oldest to the newest
+}
+
+// valueShard - part of URL value map to URLID with its own lock
+type valueShard struct {
+	mutex sync.Mutex      // mutex - guards ids, is held during the whole URL insertion
+	ids   map[string]uint // ids - URL value map to URLID
+}
+
+// userShard - part of UserID map to URLIDs with its own lock
+type userShard struct {
+	mutex    sync.RWMutex    // mutex - guards userURLs
+	userURLs map[uint][]uint // userURLs - relationships between UserID and URLID
+}
+
+// initShards - create empty maps for all Storage shards
+func (strg *Storage) initShards() {
+	for i := 0; i < ShardsCount; i++ {
+		strg.urlShards[i].urls = make(map[uint]URL)
+		strg.urlShards[i].purged = make(map[uint]bool)
+		strg.urlShards[i].history = make(map[uint][]URLChange)
+		strg.valueShards[i].ids = make(map[string]uint)
+		strg.userShards[i].userURLs = make(map[uint][]uint)
+	}
+	strg.aliases = make(map[string]uint)
+	strg.clickStats = make(map[uint]map[string]int)
+	strg.apiKeys = make(map[string]APIKey)
+	strg.apiKeyHashes = make(map[string]string)
+	strg.accounts = make(map[string]Account)
+	strg.accountUsernames = make(map[uint]string)
+}
+
+// urlShardFor - get shard for URLID
+func (strg *Storage) urlShardFor(key uint) *urlShard {
+	return &strg.urlShards[key%ShardsCount]
+}
+
+// valueShardIndex - get index of shard for URL value
+func valueShardIndex(value string) uint32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(value))
+	return hash.Sum32() % ShardsCount
+}
+
+// valueShardFor - get shard for URL value
+func (strg *Storage) valueShardFor(value string) *valueShard {
+	return &strg.valueShards[valueShardIndex(value)]
+}
+
+// userShardFor - get shard for UserID
+func (strg *Storage) userShardFor(userID uint) *userShard {
+	return &strg.userShards[userID%ShardsCount]
+}
+
+// getURL - get URL by its ID
+func (strg *Storage) getURL(key uint) (URL, bool) {
+	shard := strg.urlShardFor(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	value, ok := shard.urls[key]
+	return value, ok
+}
+
+// setURL - set URL by its ID
+func (strg *Storage) setURL(key uint, value URL) {
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	shard.urls[key] = value
+}
+
+// claimURL - set URL by its ID only if ID isn't used yet and wasn't purged
+func (strg *Storage) claimURL(key uint, value URL) bool {
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if _, ok := shard.urls[key]; ok || shard.purged[key] {
+		return false
+	}
+	shard.urls[key] = value
+	return true
+}
+
+// updateURL - apply update to URL by its ID, returns false if there is no such URL
+func (strg *Storage) updateURL(key uint, update func(value *URL)) bool {
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	value, ok := shard.urls[key]
+	if !ok {
+		return false
+	}
+	update(&value)
+	shard.urls[key] = value
+	return true
+}
+
+// consumeURLClick - count click of URL limited with max clicks, persist is called under URL shard lock with new clicks count,
+// so concurrent clicks are written into log in the same order as they are counted
+func (strg *Storage) consumeURLClick(key uint, now time.Time, persist func(usedClicks int) error) int {
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	value, ok := shard.urls[key]
+	if !ok || value.Deleted || value.IsExpired(now) || value.IsExhausted() {
+		return http.StatusGone
+	}
+	if value.MaxClicks == 0 {
+		return 0
+	}
+	if err := persist(value.UsedClicks + 1); err != nil {
+		log.Printf("Couldn't save click of %d, %s", key, err.Error())
+		return http.StatusInternalServerError
+	}
+	value.UsedClicks++
+	shard.urls[key] = value
+	return 0
+}
+
+// isPurged - check that URLID belongs to purged URL
+func (strg *Storage) isPurged(key uint) bool {
+	shard := strg.urlShardFor(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	return shard.purged[key]
+}
+
+// addTombstone - remember URLID as purged one
+func (strg *Storage) addTombstone(key uint) {
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	shard.purged[key] = true
+}
+
+// purgeURL - remove URL by its ID together with its value and alias and keep ID as tombstone.
+// URL is removed only if canPurge returns true for it, nil canPurge removes URL unconditionally.
+func (strg *Storage) purgeURL(key uint, canPurge func(url URL) bool) bool {
+	url, ok := strg.getURL(key)
+	if !ok {
+		return false
+	}
+	valueShard := strg.valueShardFor(url.Value)
+	valueShard.mutex.Lock()
+	defer valueShard.mutex.Unlock()
+	strg.aliasLock.Lock()
+	defer strg.aliasLock.Unlock()
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	// URL could be changed after it was read without locks
+	current, ok := shard.urls[key]
+	if !ok || current.Value != url.Value || current.Alias != url.Alias || (canPurge != nil && !canPurge(current)) {
+		return false
+	}
+	delete(shard.urls, key)
+	delete(shard.history, key)
+	shard.purged[key] = true
+	if valueShard.ids[url.Value] == key {
+		delete(valueShard.ids, url.Value)
+	}
+	if url.Alias != "" && strg.aliases[url.Alias] == key {
+		delete(strg.aliases, url.Alias)
+	}
+	return true
+}
+
+// retargetURL - set new value from change for not deleted URL by its ID and append change to URL history.
+// Change is persisted before it is applied while URL and both its values are locked,
+// so changes of the same URL are persisted in the same order as they are applied.
+func (strg *Storage) retargetURL(key uint, change *URLChange, persist func() error) (errMsg string, errCode int) {
+	for {
+		url, ok := strg.getURL(key)
+		if !ok {
+			return "URL not found", http.StatusNotFound
+		}
+		errMsg, errCode, retry := strg.retargetLockedURL(key, url.Value, change, persist)
+		if !retry {
+			return errMsg, errCode
+		}
+	}
+}
+
+// retargetLockedURL - retargetURL step which locks value shards of old and new values and URL shard,
+// retry is returned if URL value was changed since oldValue was read
+func (strg *Storage) retargetLockedURL(key uint, oldValue string, change *URLChange, persist func() error) (errMsg string, errCode int, retry bool) {
+	oldIndex, newIndex := valueShardIndex(oldValue), valueShardIndex(change.NewValue)
+	// Value shards are locked in the order of their indexes in order not to deadlock with concurrent retarget
+	first, second := oldIndex, newIndex
+	if first > second {
+		first, second = second, first
+	}
+	strg.valueShards[first].mutex.Lock()
+	defer strg.valueShards[first].mutex.Unlock()
+	if second != first {
+		strg.valueShards[second].mutex.Lock()
+		defer strg.valueShards[second].mutex.Unlock()
+	}
+	oldShard, newShard := &strg.valueShards[oldIndex], &strg.valueShards[newIndex]
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	current, ok := shard.urls[key]
+	if !ok {
+		return "URL not found", http.StatusNotFound, false
+	}
+	if current.Value != oldValue {
+		return "", 0, true
+	}
+	if current.Deleted || current.IsExpired(change.ChangedAt) {
+		return "URL is deleted", http.StatusGone, false
+	}
+	if current.Value == change.NewValue {
+		return "", 0, false
+	}
+	if ID, ok := newShard.ids[change.NewValue]; ok && ID != key {
+		return "URL is already shortened", http.StatusConflict, false
+	}
+	change.OldValue = oldValue
+	if err := persist(); err != nil {
+		return err.Error(), http.StatusInternalServerError, false
+	}
+	current.Value = change.NewValue
+	shard.urls[key] = current
+	shard.history[key] = append(shard.history[key], *change)
+	if oldShard.ids[oldValue] == key {
+		delete(oldShard.ids, oldValue)
+	}
+	newShard.ids[change.NewValue] = key
+	return "", 0, false
+}
+
+// applyURLChange - set new value from change for URL by its ID and append change to URL history during replay.
+// Change which is already in history, i.e. was restored from snapshot, is skipped.
+func (strg *Storage) applyURLChange(key uint, change URLChange) {
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	url, ok := shard.urls[key]
+	if !ok {
+		shard.mutex.Unlock()
+		return
+	}
+	for _, applied := range shard.history[key] {
+		if applied.NewValue == change.NewValue && applied.ChangedAt.Equal(change.ChangedAt) {
+			shard.mutex.Unlock()
+			return
+		}
+	}
+	url.Value = change.NewValue
+	shard.urls[key] = url
+	shard.history[key] = append(shard.history[key], change)
+	shard.mutex.Unlock()
+	valueShard := strg.valueShardFor(change.OldValue)
+	valueShard.mutex.Lock()
+	if valueShard.ids[change.OldValue] == key {
+		delete(valueShard.ids, change.OldValue)
+	}
+	valueShard.mutex.Unlock()
+	strg.setValueID(change.NewValue, key)
+}
+
+// getURLHistory - get copy of URL value changes by its ID
+func (strg *Storage) getURLHistory(key uint) []URLChange {
+	shard := strg.urlShardFor(key)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	return append(make([]URLChange, 0, len(shard.history[key])), shard.history[key]...)
+}
+
+// setURLHistory - set URL value changes by its ID
+func (strg *Storage) setURLHistory(key uint, changes []URLChange) {
+	shard := strg.urlShardFor(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	shard.history[key] = append(make([]URLChange, 0, len(changes)), changes...)
+}
+
+// purgedCount - get number of purged URLs in Storage
+func (strg *Storage) purgedCount() int {
+	count := 0
+	for i := range strg.urlShards {
+		strg.urlShards[i].mutex.RLock()
+		count += len(strg.urlShards[i].purged)
+		strg.urlShards[i].mutex.RUnlock()
+	}
+	return count
+}
+
+// claimNewURL - reserve new ID for URL and claim it together with its alias, if alias is set
+func (strg *Storage) claimNewURL(url URL) (uint, error) {
+	if url.Alias != "" {
+		strg.aliasLock.Lock()
+		defer strg.aliasLock.Unlock()
+		if _, ok := strg.aliases[url.Alias]; ok {
+			return 0, ErrAliasTaken
+		}
+	}
+	// Index could be already taken by batch inserted with explicit indexes, such indexes are skipped
+	ID := strg.reserveIndexes(1)
+	for !strg.claimURL(ID, url) {
+		ID = strg.reserveIndexes(1)
+	}
+	if url.Alias != "" {
+		strg.aliases[url.Alias] = ID
+	}
+	return ID, nil
+}
+
+// setAlias - remember URLID for custom alias
+func (strg *Storage) setAlias(alias string, key uint) {
+	if alias == "" {
+		return
+	}
+	strg.aliasLock.Lock()
+	defer strg.aliasLock.Unlock()
+	strg.aliases[alias] = key
+}
+
+// getValueID - get URLID for URL value
+func (strg *Storage) getValueID(value string) (uint, bool) {
+	shard := strg.valueShardFor(value)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	ID, ok := shard.ids[value]
+	return ID, ok
+}
+
+// setValueID - remember URLID for URL value if value isn't known yet
+func (strg *Storage) setValueID(value string, key uint) {
+	shard := strg.valueShardFor(value)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	if _, ok := shard.ids[value]; !ok {
+		shard.ids[value] = key
+	}
+}
+
+// getUserURLIDs - get copy of URLIDs list for UserID
+func (strg *Storage) getUserURLIDs(userID uint) ([]uint, bool) {
+	shard := strg.userShardFor(userID)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	userURLs, ok := shard.userURLs[userID]
+	if !ok {
+		return nil, false
+	}
+	return append(make([]uint, 0, len(userURLs)), userURLs...), true
+}
+
+// hasUserURLID - check that URLID belongs to UserID
+func (strg *Storage) hasUserURLID(userID uint, key uint) bool {
+	shard := strg.userShardFor(userID)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+	for _, URLID := range shard.userURLs[userID] {
+		if URLID == key {
+			return true
+		}
+	}
+	return false
+}
+
+// addUserURLID - add URLID to UserID list if it isn't there yet
+func (strg *Storage) addUserURLID(userID uint, key uint) {
+	shard := strg.userShardFor(userID)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+	for _, URLID := range shard.userURLs[userID] {
+		if URLID == key {
+			return
+		}
+	}
+	shard.userURLs[userID] = append(shard.userURLs[userID], key)
+}
+
+// removeUserURLIDs - remove URLIDs from lists of all users, users left without URLs are removed too
+func (strg *Storage) removeUserURLIDs(keys map[uint]bool) {
+	if len(keys) == 0 {
+		return
+	}
+	for i := range strg.userShards {
+		shard := &strg.userShards[i]
+		shard.mutex.Lock()
+		for userID, userURLs := range shard.userURLs {
+			kept := make([]uint, 0, len(userURLs))
+			for _, URLID := range userURLs {
+				if !keys[URLID] {
+					kept = append(kept, URLID)
+				}
+			}
+			if len(kept) == 0 {
+				delete(shard.userURLs, userID)
+			} else if len(kept) < len(userURLs) {
+				shard.userURLs[userID] = kept
+			}
+		}
+		shard.mutex.Unlock()
+	}
+}
+
+// reserveIndexes - atomically reserve count sequential indexes, returns the first one
+func (strg *Storage) reserveIndexes(count uint) uint {
+	return uint(strg.nextIndex.Add(uint64(count))) - count
+}
+
+// bumpNextIndex - make next index at least nextInd
+func (strg *Storage) bumpNextIndex(nextInd uint) {
+	for {
+		current := strg.nextIndex.Load()
+		if current >= uint64(nextInd) || strg.nextIndex.CompareAndSwap(current, uint64(nextInd)) {
+			return
+		}
+	}
+}
+
+// usersCount - get number of users in Storage
+func (strg *Storage) usersCount() int {
+	count := 0
+	for i := range strg.userShards {
+		strg.userShards[i].mutex.RLock()
+		count += len(strg.userShards[i].userURLs)
+		strg.userShards[i].mutex.RUnlock()
+	}
+	return count
+}
+
+// Snapshot - get copy of Storage state
+func (strg *Storage) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		Version:       SnapshotVersion,
+		NextIndex:     uint(strg.nextIndex.Load()),
+		URLs:          make(map[uint]URL),
+		UserIDToURLID: make(map[uint][]uint),
+	}
+	strg.logMutex.Lock()
+	snapshot.LastSeq = strg.logSeq
+	strg.logMutex.Unlock()
+	for i := range strg.urlShards {
+		strg.urlShards[i].mutex.RLock()
+		for key, value := range strg.urlShards[i].urls {
+			snapshot.URLs[key] = value
+		}
+		for key := range strg.urlShards[i].purged {
+			snapshot.Purged = append(snapshot.Purged, key)
+		}
+		for key, changes := range strg.urlShards[i].history {
+			if snapshot.History == nil {
+				snapshot.History = make(map[uint][]URLChange)
+			}
+			snapshot.History[key] = append(make([]URLChange, 0, len(changes)), changes...)
+		}
+		strg.urlShards[i].mutex.RUnlock()
+	}
+	sort.Slice(snapshot.Purged, func(i, j int) bool { return snapshot.Purged[i] < snapshot.Purged[j] })
+	for i := range strg.userShards {
+		strg.userShards[i].mutex.RLock()
+		for userID, userURLs := range strg.userShards[i].userURLs {
+			snapshot.UserIDToURLID[userID] = append(make([]uint, 0, len(userURLs)), userURLs...)
+		}
+		strg.userShards[i].mutex.RUnlock()
+	}
+	strg.apiKeyLock.RLock()
+	for _, key := range strg.apiKeys {
+		snapshot.APIKeys = append(snapshot.APIKeys, key)
+	}
+	strg.apiKeyLock.RUnlock()
+	sortAPIKeys(snapshot.APIKeys)
+	strg.accountLock.RLock()
+	for _, account := range strg.accounts {
+		snapshot.Accounts = append(snapshot.Accounts, account)
+	}
+	strg.accountLock.RUnlock()
+	sort.Slice(snapshot.Accounts, func(i, j int) bool { return snapshot.Accounts[i].Username < snapshot.Accounts[j].Username })
+	return snapshot
+}
+
+// restore - fill empty Storage with given state
+func (strg *Storage) restore(urls map[uint]URL, userIDToURLID map[uint][]uint, nextInd uint) {
+	for key, value := range urls {
+		strg.setURL(key, value)
+		shard := strg.valueShardFor(value.Value)
+		if ID, ok := shard.ids[value.Value]; !ok || key < ID {
+			shard.ids[value.Value] = key
+		}
+		if value.Alias != "" {
+			strg.aliases[value.Alias] = key
+		}
+	}
+	for userID, userURLs := range userIDToURLID {
+		shard := strg.userShardFor(userID)
+		shard.userURLs[userID] = append(make([]uint, 0, len(userURLs)), userURLs...)
+	}
+	strg.nextIndex.Store(uint64(nextInd))
+}
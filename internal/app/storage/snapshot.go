@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// SnapshotVersion - current version of Storage snapshot format
+const SnapshotVersion = 1
+
+// SnapshotSuffix - suffix for Storage snapshot file name, snapshot is stored next to the log file
+const SnapshotSuffix = ".snapshot"
+
+// Snapshot - full state of Storage written by compaction
+type Snapshot struct {
+	Version       int             `json:"version"`          // Version - version of snapshot format
+	NextIndex     uint            `json:"next_index"`       // NextIndex - next index to insert
+	LastSeq       uint64          `json:"last_seq"`         // LastSeq - log records with lower sequence number are covered by snapshot
+	URLs          map[uint]URL    `json:"urls"`             // URLs - URLID map to URL struct
+	UserIDToURLID map[uint][]uint `json:"user_urls"`        // UserIDToURLID - relationships between UserID and URLID
+	Purged        []uint          `json:"purged,omitempty"` // Purged - tombstones of purged URLIDs
+
+	History  map[uint][]URLChange `json:"history,omitempty"`  // History - URLID map to changes of URL value
+	APIKeys  []APIKey             `json:"api_keys,omitempty"` // APIKeys - API keys including revoked ones
+	Accounts []Account            `json:"accounts,omitempty"` // Accounts - registered user accounts
+}
+
+// ICompactor interface for storages which support on demand compaction
+type ICompactor interface {
+	Compact() error // Compact - write snapshot of storage state and truncate its log
+}
+
+// loadSnapshot - load Storage state from snapshot file if it exists
+func (strg *Storage) loadSnapshot(snapshotName string) error {
+	data, err := os.ReadFile(snapshotName)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	strg.restore(snapshot.URLs, snapshot.UserIDToURLID, Max(uint(strg.nextIndex.Load()), snapshot.NextIndex))
+	strg.logSeq = snapshot.LastSeq
+	for _, key := range snapshot.Purged {
+		strg.addTombstone(key)
+	}
+	for key, changes := range snapshot.History {
+		strg.setURLHistory(key, changes)
+	}
+	for _, key := range snapshot.APIKeys {
+		strg.setAPIKey(key)
+	}
+	for _, account := range snapshot.Accounts {
+		strg.setAccount(account)
+	}
+	return nil
+}
+
+// Compact - write snapshot of Storage state into temp file, swap it with the current snapshot and truncate the log
+func (strg *Storage) Compact() error {
+	strg.compactionLock.Lock()
+	defer strg.compactionLock.Unlock()
+	if strg.file == nil {
+		return nil
+	}
+	snapshotName := strg.file.Name() + SnapshotSuffix
+	tmpName := snapshotName + ".tmp"
+	tmpFile, err := os.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	// Snapshot gets its own sequence number, so all records in the log are below it and all new ones are above it
+	strg.logMutex.Lock()
+	strg.logSeq++
+	strg.logMutex.Unlock()
+	snapshot := strg.Snapshot()
+	if err := json.NewEncoder(tmpFile).Encode(snapshot); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, snapshotName); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(snapshotName)); err != nil {
+		return err
+	}
+	// Records left in the log after crash before truncation are skipped on replay by their sequence numbers
+	if err := strg.file.Truncate(0); err != nil {
+		return err
+	}
+	log.Printf("Storage %s was compacted, %d URLs in snapshot", strg.file.Name(), len(snapshot.URLs))
+	return strg.file.Sync()
+}
+
+// CompactionDaemon runs daemon for Storage compaction on log size threshold.
+func (strg *Storage) CompactionDaemon() {
+	for range strg.compactChannel {
+		if err := strg.Compact(); err != nil {
+			log.Printf("Couldn't compact storage, %s", err.Error())
+		}
+	}
+}
+
+// checkLogSize - request compaction if log file exceeds compaction threshold
+func (strg *Storage) checkLogSize() {
+	if strg.file == nil || strg.compactThreshold <= 0 {
+		return
+	}
+	info, err := strg.file.Stat()
+	if err != nil || info.Size() < strg.compactThreshold {
+		return
+	}
+	select {
+	case strg.compactChannel <- struct{}{}:
+	default:
+	}
+}
+
+// syncDir - fsync directory in order to persist renamed files in it
+func syncDir(dirName string) error {
+	dir, err := os.Open(dirName)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
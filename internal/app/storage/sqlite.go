@@ -0,0 +1,683 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/tank4gun/gourlshortener/internal/app/db"
+)
+
+// SQLiteStorage - struct for SQLite database storage
+type SQLiteStorage struct {
+	db *sql.DB // db - sql.DB pointer
+}
+
+// NewSQLiteStorage - open SQLite database by given path and apply its migrations
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	database, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows only one writer at a time, so all queries go through single connection
+	database.SetMaxOpenConns(1)
+	// Foreign keys are enforced only after migrations, as SQLite rebuilds referenced tables with foreign keys off
+	if err := db.RunSQLiteMigrations(database); err != nil {
+		database.Close()
+		return nil, err
+	}
+	if _, err := database.Exec("PRAGMA foreign_keys = ON"); err != nil {
+		database.Close()
+		return nil, err
+	}
+	return &SQLiteStorage{database}, nil
+}
+
+// Shutdown - close db connection for SQLiteStorage
+func (strg *SQLiteStorage) Shutdown() error {
+	return strg.db.Close()
+}
+
+// Ping - check that connection to SQLiteStorage is alive
+func (strg *SQLiteStorage) Ping() error {
+	return strg.db.Ping()
+}
+
+// InsertValue - insert value for userID into SQLiteStorage
+func (strg *SQLiteStorage) InsertValue(value string, userID uint) error {
+	_, err := strg.insertValue(URL{Value: value}, userID)
+	return err
+}
+
+// insertValue - insert URL for userID into SQLiteStorage, returns ID allocated by the INSERT itself
+func (strg *SQLiteStorage) insertValue(url URL, userID uint) (uint, error) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	// URLID - URL ID
+	var URLID uint
+	row := tx.QueryRow(
+		"INSERT INTO url (value, alias, expires_at, redirect_type, title, require_interstitial, created_at, password_hash, max_clicks) "+
+			"VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id",
+		url.Value, url.Alias, nullTime(url.ExpiresAt), url.RedirectType, url.Title, url.RequireInterstitial, time.Now().UTC(), url.PasswordHash, url.MaxClicks,
+	)
+	err = row.Scan(&URLID)
+	if err == sql.ErrNoRows {
+		// Either value or alias is already used
+		row = tx.QueryRow("SELECT id FROM url WHERE value = ?", url.Value)
+		if err = row.Scan(&URLID); err == sql.ErrNoRows {
+			return 0, ErrAliasTaken
+		}
+		if err != nil {
+			return 0, err
+		}
+		return 0, &ExistError{URLID, "Got existing URL"}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("INSERT INTO user_url (user_id, url_id) VALUES (?, ?)", userID, URLID); err != nil {
+		return 0, err
+	}
+	return URLID, tx.Commit()
+}
+
+// GetValueByKeyAndUserID - get value by key and userID from SQLiteStorage
+func (strg *SQLiteStorage) GetValueByKeyAndUserID(key uint, userID uint) (string, int) {
+	value, errCode := strg.GetURLByKeyAndUserID(key, userID)
+	return value.Value, errCode
+}
+
+// GetURLByKeyAndUserID - get not deleted and not expired URL by key and userID from SQLiteStorage
+func (strg *SQLiteStorage) GetURLByKeyAndUserID(key uint, userID uint) (URL, int) {
+	row := strg.db.QueryRow(
+		"SELECT value, COALESCE(alias, ''), deleted, expires_at, redirect_type, title, require_interstitial, created_at, password_hash, max_clicks, used_clicks "+
+			"FROM url WHERE id = ?", key,
+	)
+	var value URL
+	var expiresAt, createdAt sql.NullTime
+	err := row.Scan(
+		&value.Value, &value.Alias, &value.Deleted, &expiresAt, &value.RedirectType, &value.Title, &value.RequireInterstitial, &createdAt, &value.PasswordHash,
+		&value.MaxClicks, &value.UsedClicks,
+	)
+	if err == sql.ErrNoRows {
+		var purged bool
+		if err := strg.db.QueryRow("SELECT EXISTS (SELECT 1 FROM purged_url WHERE id = ?)", key).Scan(&purged); err == nil && purged {
+			return URL{}, http.StatusGone
+		}
+	}
+	if err != nil {
+		log.Printf("got key %d not presented in storage", key)
+		return URL{}, http.StatusBadRequest
+	}
+	value.ExpiresAt = expiresAt.Time
+	value.CreatedAt = createdAt.Time
+	if value.Deleted || value.IsExpired(time.Now()) || value.IsExhausted() {
+		return URL{}, http.StatusGone
+	}
+	return value, 0
+}
+
+// ConsumeClick - atomically count click of URL limited with max clicks with a single UPDATE ... RETURNING, nothing is counted for not limited URL
+func (strg *SQLiteStorage) ConsumeClick(key uint) int {
+	var ID uint
+	err := strg.db.QueryRow(
+		"UPDATE url SET used_clicks = used_clicks + 1 "+
+			"WHERE id = ? AND max_clicks > 0 AND used_clicks < max_clicks AND deleted = false AND (expires_at IS NULL OR expires_at > ?) RETURNING id",
+		key, time.Now().UTC(),
+	).Scan(&ID)
+	if err == nil {
+		return 0
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("Couldn't count click of %d, %s", key, err.Error())
+		return http.StatusInternalServerError
+	}
+	// Nothing is updated for not limited URL as well as for unavailable one
+	var limited bool
+	err = strg.db.QueryRow(
+		"SELECT max_clicks > 0 FROM url WHERE id = ? AND deleted = false AND (expires_at IS NULL OR expires_at > ?)", key, time.Now().UTC(),
+	).Scan(&limited)
+	if err != nil || limited {
+		return http.StatusGone
+	}
+	return 0
+}
+
+// GetAllURLsByUserID - get all URLs by userID from SQLiteStorage
+func (strg *SQLiteStorage) GetAllURLsByUserID(userID uint, baseURL string) ([]FullInfoURLResponse, int) {
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, '') FROM user_url JOIN url ON url.id = user_url.url_id WHERE user_url.user_id = ? ORDER BY user_url.id",
+		userID,
+	)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	responseList := make([]FullInfoURLResponse, 0)
+	for rows.Next() {
+		// URLID - URL ID
+		var URLID uint
+		var originalURL, alias string
+		if err = rows.Scan(&URLID, &originalURL, &alias); err != nil {
+			return nil, http.StatusInternalServerError
+		}
+		responseList = append(responseList, FullInfoURLResponse{ShortURL: baseURL + GetShortURL(URLID, alias), OriginalURL: originalURL})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	if len(responseList) == 0 {
+		return nil, http.StatusNoContent
+	}
+	return responseList, 200
+}
+
+// GetURLsPageByUserID - get page of not deleted URLs by userID from SQLiteStorage with a single query
+func (strg *SQLiteStorage) GetURLsPageByUserID(userID uint, query URLsPageQuery, baseURL string) (URLsPage, int) {
+	order, comparison, after := query.sqlBounds()
+	limit := query.limit()
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, ''), "+
+			"CASE WHEN url.max_clicks > 0 THEN max(url.max_clicks - url.used_clicks, 0) END FROM user_url JOIN url ON url.id = user_url.url_id "+
+			"WHERE user_url.user_id = ? AND url.deleted = false AND url.id "+comparison+" ? AND instr(url.value, ?) > 0 "+
+			"ORDER BY url.id "+order+" LIMIT ?",
+		userID, after, query.Filter, limit+1,
+	)
+	if err != nil {
+		return URLsPage{}, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	return scanURLsPage(rows, limit, baseURL)
+}
+
+// IterateURLsByUserID - pass all URLs of userID including deleted ones to yield in creation order.
+// URLs are read from SQLiteStorage by pages of ExportPageSize, so its only connection isn't held while yield writes them to slow client.
+func (strg *SQLiteStorage) IterateURLsByUserID(userID uint, baseURL string, yield func(url ExportedURL) error) error {
+	var afterID uint
+	for {
+		IDs, urls, err := strg.getExportPage(userID, afterID)
+		if err != nil {
+			return err
+		}
+		for index, URLID := range IDs {
+			if err := yield(newExportedURL(URLID, urls[index], baseURL)); err != nil {
+				return err
+			}
+		}
+		if len(IDs) < ExportPageSize {
+			return nil
+		}
+		afterID = IDs[len(IDs)-1]
+	}
+}
+
+// getExportPage - read up to ExportPageSize URLs of userID with ID greater than afterID from SQLiteStorage in creation order
+func (strg *SQLiteStorage) getExportPage(userID uint, afterID uint) ([]uint, []URL, error) {
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, ''), url.deleted, url.expires_at FROM user_url JOIN url ON url.id = user_url.url_id "+
+			"WHERE user_url.user_id = ? AND url.id > ? ORDER BY url.id LIMIT ?",
+		userID, afterID, ExportPageSize,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	IDs := make([]uint, 0, ExportPageSize)
+	urls := make([]URL, 0, ExportPageSize)
+	for rows.Next() {
+		URLID, url, err := scanURLRow(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		IDs = append(IDs, URLID)
+		urls = append(urls, url)
+	}
+	return IDs, urls, rows.Err()
+}
+
+// MarkBatchAsDeleted - set deleted=true for rows by its IDs and userID in SQLiteStorage
+func (strg *SQLiteStorage) MarkBatchAsDeleted(IDs []uint, userID uint) error {
+	if len(IDs) == 0 {
+		return nil
+	}
+	log.Printf("Delete urls %v for user_id %d", IDs, userID)
+	args := make([]interface{}, 0, len(IDs)+2)
+	args = append(args, time.Now().UTC(), userID)
+	for _, ID := range IDs {
+		args = append(args, ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(IDs)), ", ")
+	_, err := strg.db.Exec(
+		"UPDATE url SET deleted = true, deleted_at = ? WHERE deleted = false AND id IN (SELECT url_id FROM user_url WHERE user_id = ? AND url_id IN ("+placeholders+"))",
+		args...,
+	)
+	return err
+}
+
+// GetDeletedURLsByUserID - get deleted URLs by userID from SQLiteStorage, the most recently deleted go first
+func (strg *SQLiteStorage) GetDeletedURLsByUserID(userID uint, baseURL string) ([]DeletedURLResponse, int) {
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, ''), url.deleted_at FROM user_url JOIN url ON url.id = user_url.url_id "+
+			"WHERE user_url.user_id = ? AND url.deleted = true ORDER BY url.deleted_at DESC, url.id",
+		userID,
+	)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	return scanDeletedURLs(rows, baseURL)
+}
+
+// RestoreBatch - set deleted=false for deleted and not expired URLs by IDs which belong to userID in SQLiteStorage, returns number of restored URLs
+func (strg *SQLiteStorage) RestoreBatch(IDs []uint, userID uint) (int, error) {
+	if len(IDs) == 0 {
+		return 0, nil
+	}
+	log.Printf("Restore urls %v for user_id %d", IDs, userID)
+	args := make([]interface{}, 0, len(IDs)+2)
+	args = append(args, time.Now().UTC(), userID)
+	for _, ID := range IDs {
+		args = append(args, ID)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(IDs)), ", ")
+	result, err := strg.db.Exec(
+		"UPDATE url SET deleted = false, deleted_at = NULL WHERE deleted = true AND (expires_at IS NULL OR expires_at > ?) "+
+			"AND id IN (SELECT url_id FROM user_url WHERE user_id = ? AND url_id IN ("+placeholders+"))",
+		args...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// GetStats - get stats from SQLiteStorage
+func (strg *SQLiteStorage) GetStats() (response StatsResponse, errCode int) {
+	// URLsCount - number of URLs in SQLiteStorage
+	var URLsCount int
+	// UsersCount - number of users in SQLiteStorage
+	var UsersCount int
+	row := strg.db.QueryRow("SELECT count(*) FROM url WHERE deleted = false")
+	if err := row.Scan(&URLsCount); err != nil {
+		log.Print("Couldn't get URLs count")
+		return StatsResponse{}, http.StatusBadRequest
+	}
+	row = strg.db.QueryRow("SELECT count(distinct user_id) FROM user_url")
+	if err := row.Scan(&UsersCount); err != nil {
+		log.Print("Couldn't get Users count")
+		return StatsResponse{}, http.StatusBadRequest
+	}
+	// PurgedCount - number of purged URLs in SQLiteStorage
+	var PurgedCount int
+	if err := strg.db.QueryRow("SELECT count(*) FROM purged_url").Scan(&PurgedCount); err != nil {
+		log.Print("Couldn't get purged URLs count")
+		return StatsResponse{}, http.StatusBadRequest
+	}
+	return StatsResponse{URLs: URLsCount, Users: UsersCount, Purged: PurgedCount}, 200
+}
+
+// CreateShortURLByURL creates short URL by given URL and inserts it into storage.
+func (strg *SQLiteStorage) CreateShortURLByURL(url string, userID uint) (shortURLResult string, errMsg string, errCode int) {
+	return strg.CreateShortURLWithOptions(url, URLOptions{}, userID)
+}
+
+// CreateShortURLWithOptions creates short URL by given URL and options and inserts it into storage.
+func (strg *SQLiteStorage) CreateShortURLWithOptions(url string, options URLOptions, userID uint) (shortURLResult string, errMsg string, errCode int) {
+	URLID, strgErr := strg.insertValue(options.newURL(url), userID)
+	var exErr *ExistError
+	if errors.As(strgErr, &exErr) {
+		var existingURL URL
+		if err := strg.db.QueryRow(
+			"SELECT COALESCE(alias, ''), password_hash, max_clicks FROM url WHERE id = ?", exErr.ID,
+		).Scan(&existingURL.Alias, &existingURL.PasswordHash, &existingURL.MaxClicks); err != nil {
+			return "", err.Error(), http.StatusInternalServerError
+		}
+		if !options.matchesExisting(existingURL) {
+			return "", ErrOptionsMismatch.Error(), http.StatusConflict
+		}
+		return GetShortURL(exErr.ID, existingURL.Alias), "", http.StatusConflict
+	}
+	if errors.Is(strgErr, ErrAliasTaken) {
+		return "", "Alias " + options.Alias + " is already taken", http.StatusConflict
+	}
+	if strgErr != nil {
+		return "", strgErr.Error(), http.StatusInternalServerError
+	}
+	return GetShortURL(URLID, options.Alias), "", 0
+}
+
+// MarkExpiredAsDeleted - set deleted=true for URLs expired by now in SQLiteStorage, returns number of marked URLs
+func (strg *SQLiteStorage) MarkExpiredAsDeleted(now time.Time) (int, error) {
+	result, err := strg.db.Exec("UPDATE url SET deleted = true, deleted_at = expires_at WHERE deleted = false AND expires_at <= ?", now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// PurgeDeleted - remove URLs deleted before deletedBefore with their owners and clicks from SQLiteStorage in one transaction.
+// Purged IDs are saved into purged_url table, so they are never reissued, while their values could be shortened again.
+func (strg *SQLiteStorage) PurgeDeleted(deletedBefore time.Time) (int, error) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	deletedBefore = deletedBefore.UTC()
+	if _, err := tx.Exec(
+		"INSERT INTO purged_url (id, purged_at) SELECT id, ? FROM url WHERE deleted = true AND deleted_at < ?", time.Now().UTC(), deletedBefore,
+	); err != nil {
+		return 0, err
+	}
+	for _, query := range []string{
+		"DELETE FROM click WHERE url_id IN (SELECT id FROM url WHERE deleted = true AND deleted_at < ?)",
+		"DELETE FROM url_history WHERE url_id IN (SELECT id FROM url WHERE deleted = true AND deleted_at < ?)",
+		"DELETE FROM user_url WHERE url_id IN (SELECT id FROM url WHERE deleted = true AND deleted_at < ?)",
+	} {
+		if _, err := tx.Exec(query, deletedBefore); err != nil {
+			return 0, err
+		}
+	}
+	result, err := tx.Exec("DELETE FROM url WHERE deleted = true AND deleted_at < ?", deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(count), tx.Commit()
+}
+
+// GetIDByAlias - get URL ID by its custom alias from SQLiteStorage
+func (strg *SQLiteStorage) GetIDByAlias(alias string) (uint, int) {
+	// URLID - URL ID
+	var URLID uint
+	err := strg.db.QueryRow("SELECT id FROM url WHERE alias = ?", alias).Scan(&URLID)
+	if err == sql.ErrNoRows {
+		return 0, http.StatusNotFound
+	}
+	if err != nil {
+		return 0, http.StatusInternalServerError
+	}
+	return URLID, 0
+}
+
+// CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage.
+// Short URLs are built from IDs returned by the INSERTs of the same transaction, URLs which are already stored
+// or repeat earlier batch items aren't inserted, their existing short URLs are returned with Conflict flag.
+func (strg *SQLiteStorage) CreateShortURLBatch(batchURLs []BatchURLRequest, userID uint, baseURL string) ([]BatchURLResponse, string, int) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	now := time.Now()
+	var resultURLs []BatchURLResponse
+	for _, URLrequest := range batchURLs {
+		// URLID - URL ID
+		var URLID uint
+		expiresAt := nullTime(ExpirationTime(URLrequest.ExpiresAt, URLrequest.TTLSeconds, now))
+		err := tx.QueryRow(
+			"INSERT INTO url (value, expires_at, created_at) VALUES (?, ?, ?) ON CONFLICT DO NOTHING RETURNING id", URLrequest.OriginalURL, expiresAt, now.UTC(),
+		).Scan(&URLID)
+		if err == sql.ErrNoRows {
+			var alias string
+			if err := tx.QueryRow("SELECT id, COALESCE(alias, '') FROM url WHERE value = ?", URLrequest.OriginalURL).Scan(&URLID, &alias); err != nil {
+				return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+			}
+			resultURLs = append(resultURLs, BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + GetShortURL(URLID, alias), Conflict: true})
+			continue
+		}
+		if err != nil {
+			return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+		}
+		if _, err := tx.Exec("INSERT INTO user_url (user_id, url_id) VALUES (?, ?)", userID, URLID); err != nil {
+			return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+		}
+		resultURLs = append(resultURLs, BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + CreateShortURL(URLID)})
+	}
+	if err := tx.Commit(); err != nil {
+		return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+	}
+	return resultURLs, "", 0
+}
+
+// InsertClicks - save clicks batch into SQLiteStorage
+func (strg *SQLiteStorage) InsertClicks(clicks []Click) error {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	clickStmt, err := tx.Prepare("INSERT INTO click (url_id, clicked_at, referrer, user_agent, client_subnet) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer clickStmt.Close()
+	for _, click := range clicks {
+		if _, err := clickStmt.Exec(click.URLID, click.Time.UTC(), click.Referrer, click.UserAgent, click.ClientSubnet); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetClickStats - get click statistics for URL by key if it belongs to userID from SQLiteStorage
+func (strg *SQLiteStorage) GetClickStats(key uint, userID uint) (ClickStatsResponse, int) {
+	var owned bool
+	row := strg.db.QueryRow("SELECT EXISTS (SELECT 1 FROM user_url WHERE user_id = ? AND url_id = ?)", userID, key)
+	if err := row.Scan(&owned); err != nil {
+		return ClickStatsResponse{}, http.StatusInternalServerError
+	}
+	if !owned {
+		return ClickStatsResponse{}, http.StatusNotFound
+	}
+	rows, err := strg.db.Query(
+		"SELECT substr(clicked_at, 1, 10) AS day, count(*) FROM click WHERE url_id = ? GROUP BY day ORDER BY day",
+		key,
+	)
+	if err != nil {
+		return ClickStatsResponse{}, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	response := ClickStatsResponse{Days: make([]DayClicks, 0)}
+	for rows.Next() {
+		var dayClicks DayClicks
+		if err := rows.Scan(&dayClicks.Day, &dayClicks.Clicks); err != nil {
+			return ClickStatsResponse{}, http.StatusInternalServerError
+		}
+		response.Total += dayClicks.Clicks
+		response.Days = append(response.Days, dayClicks)
+	}
+	if err := rows.Err(); err != nil {
+		return ClickStatsResponse{}, http.StatusInternalServerError
+	}
+	return response, http.StatusOK
+}
+
+// UpdateURLValue - set new value for not deleted URL by key which belongs to userID in SQLiteStorage and save the change into url_history table.
+// http.StatusConflict is returned if value is already used by another URL.
+func (strg *SQLiteStorage) UpdateURLValue(key uint, value string, userID uint) (errMsg string, errCode int) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	var oldValue string
+	var deleted bool
+	var expiresAt sql.NullTime
+	row := tx.QueryRow(
+		"SELECT value, deleted, expires_at FROM url WHERE id = ?1 AND EXISTS (SELECT 1 FROM user_url WHERE user_id = ?2 AND url_id = ?1)",
+		key, userID,
+	)
+	err = row.Scan(&oldValue, &deleted, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "URL not found", http.StatusNotFound
+	}
+	if err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	now := time.Now().UTC()
+	if deleted || (URL{ExpiresAt: expiresAt.Time}).IsExpired(now) {
+		return "URL is deleted", http.StatusGone
+	}
+	if oldValue == value {
+		return "", 0
+	}
+	// Value is checked against unique_url by the UPDATE itself, conflicting row is left unchanged
+	result, err := tx.Exec("UPDATE OR IGNORE url SET value = ? WHERE id = ?", value, key)
+	if err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return "URL is already shortened", http.StatusConflict
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO url_history (url_id, old_value, new_value, user_id, changed_at) VALUES (?, ?, ?, ?, ?)", key, oldValue, value, userID, now,
+	); err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	if err := tx.Commit(); err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	return "", 0
+}
+
+// GetURLHistory - get changes of URL value by key which belongs to userID from SQLiteStorage, the oldest go first
+func (strg *SQLiteStorage) GetURLHistory(key uint, userID uint) ([]URLChange, int) {
+	var owned bool
+	if err := strg.db.QueryRow("SELECT EXISTS (SELECT 1 FROM user_url WHERE user_id = ? AND url_id = ?)", userID, key).Scan(&owned); err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	if !owned {
+		return nil, http.StatusNotFound
+	}
+	rows, err := strg.db.Query("SELECT old_value, new_value, user_id, changed_at FROM url_history WHERE url_id = ? ORDER BY id", key)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	return scanURLHistory(rows)
+}
+
+// CreateAPIKey - save API key into SQLiteStorage, http.StatusConflict is returned if its ID or hash is already used
+func (strg *SQLiteStorage) CreateAPIKey(key APIKey) int {
+	var ID string
+	err := strg.db.QueryRow(
+		"INSERT INTO api_key (id, user_id, name, hash, scopes, created_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id",
+		key.ID, key.UserID, key.Name, key.Hash, strings.Join(key.Scopes, ","), key.CreatedAt.UTC(),
+	).Scan(&ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusConflict
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return 0
+}
+
+// GetAPIKeysByUserID - get API keys of userID including revoked ones from SQLiteStorage, the oldest go first
+func (strg *SQLiteStorage) GetAPIKeysByUserID(userID uint) ([]APIKey, int) {
+	rows, err := strg.db.Query("SELECT id, user_id, name, hash, scopes, created_at, revoked_at FROM api_key WHERE user_id = ? ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	return scanAPIKeys(rows)
+}
+
+// GetAPIKeyByHash - get not revoked API key by its hash from SQLiteStorage
+func (strg *SQLiteStorage) GetAPIKeyByHash(hash string) (APIKey, int) {
+	rows, err := strg.db.Query("SELECT id, user_id, name, hash, scopes, created_at, revoked_at FROM api_key WHERE hash = ? AND revoked_at IS NULL", hash)
+	if err != nil {
+		return APIKey{}, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	keys, errCode := scanAPIKeys(rows)
+	if errCode == http.StatusNoContent {
+		return APIKey{}, http.StatusNotFound
+	}
+	if errCode != http.StatusOK {
+		return APIKey{}, errCode
+	}
+	return keys[0], 0
+}
+
+// RevokeAPIKey - revoke API key by its ID if it belongs to userID in SQLiteStorage, revocation of revoked key does nothing
+func (strg *SQLiteStorage) RevokeAPIKey(ID string, userID uint) int {
+	var found bool
+	if err := strg.db.QueryRow("SELECT EXISTS (SELECT 1 FROM api_key WHERE id = ? AND user_id = ?)", ID, userID).Scan(&found); err != nil {
+		return http.StatusInternalServerError
+	}
+	if !found {
+		return http.StatusNotFound
+	}
+	if _, err := strg.db.Exec("UPDATE api_key SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL", time.Now().UTC(), ID, userID); err != nil {
+		return http.StatusInternalServerError
+	}
+	return 0
+}
+
+// CreateAccount - save account into SQLiteStorage, http.StatusConflict is returned if its username or ID is already used
+func (strg *SQLiteStorage) CreateAccount(account Account) int {
+	var ID uint
+	err := strg.db.QueryRow(
+		"INSERT INTO account (id, username, password_hash, created_at) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING RETURNING id",
+		account.ID, account.Username, account.PasswordHash, account.CreatedAt.UTC(),
+	).Scan(&ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusConflict
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return 0
+}
+
+// GetAccountByUsername - get account by its username from SQLiteStorage
+func (strg *SQLiteStorage) GetAccountByUsername(username string) (Account, int) {
+	return scanAccount(strg.db.QueryRow("SELECT id, username, password_hash, created_at FROM account WHERE username = ?", username))
+}
+
+// GetAccountByUserID - get account by its UserID from SQLiteStorage, http.StatusNotFound is returned for anonymous user
+func (strg *SQLiteStorage) GetAccountByUserID(userID uint) (Account, int) {
+	return scanAccount(strg.db.QueryRow("SELECT id, username, password_hash, created_at FROM account WHERE id = ?", userID))
+}
+
+// MergeUser - move URLs and API keys of fromUserID to toUserID in SQLiteStorage, returns number of moved URLs.
+// URLs which already belong to toUserID are dropped from fromUserID so that they aren't listed twice.
+func (strg *SQLiteStorage) MergeUser(fromUserID uint, toUserID uint) (int, error) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	deleted, err := tx.Exec("DELETE FROM user_url WHERE user_id = ? AND url_id IN (SELECT url_id FROM user_url WHERE user_id = ?)", fromUserID, toUserID)
+	if err != nil {
+		return 0, err
+	}
+	updated, err := tx.Exec("UPDATE user_url SET user_id = ? WHERE user_id = ?", toUserID, fromUserID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("UPDATE api_key SET user_id = ? WHERE user_id = ?", toUserID, fromUserID); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	deletedCount, _ := deleted.RowsAffected()
+	updatedCount, _ := updated.RowsAffected()
+	return int(deletedCount + updatedCount), nil
+}
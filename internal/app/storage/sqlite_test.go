@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQLiteStorage(t *testing.T) *SQLiteStorage {
+	strg, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "shortener.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { strg.Shutdown() })
+	return strg
+}
+
+func TestSQLiteStorage_CreateShortURLByURL(t *testing.T) {
+	tests := []struct {
+		name             string
+		urls             []string
+		expectedShortURL string
+		expectedErrCode  int
+	}{
+		{
+			"first_url",
+			[]string{"http://ya.ru"},
+			"b",
+			0,
+		},
+		{
+			"second_url",
+			[]string{"http://ya.ru", "http://google.com"},
+			"c",
+			0,
+		},
+		{
+			"same_url",
+			[]string{"http://ya.ru", "http://google.com", "http://ya.ru"},
+			"b",
+			http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strg := newTestSQLiteStorage(t)
+			var shortURL string
+			var errCode int
+			for _, url := range tt.urls {
+				shortURL, _, errCode = strg.CreateShortURLByURL(url, 1)
+			}
+			assert.Equal(t, tt.expectedShortURL, shortURL)
+			assert.Equal(t, tt.expectedErrCode, errCode)
+		})
+	}
+}
+
+func TestSQLiteStorage_CreateShortURLBatch(t *testing.T) {
+	strg := newTestSQLiteStorage(t)
+	_, _, errCode := strg.CreateShortURLByURL("http://ya.ru", 1)
+	assert.Equal(t, 0, errCode)
+	response, _, errCode := strg.CreateShortURLBatch(
+		[]BatchURLRequest{{CorrelationID: "1", OriginalURL: "http://google.com"}, {CorrelationID: "2", OriginalURL: "http://yandex.ru"}},
+		2,
+		"localhost:8080/",
+	)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, []BatchURLResponse{{CorrelationID: "1", ShortURL: "localhost:8080/c"}, {CorrelationID: "2", ShortURL: "localhost:8080/d"}}, response)
+	value, errCode := strg.GetValueByKeyAndUserID(3, 2)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://yandex.ru", value)
+	shortURL, _, errCode := strg.CreateShortURLByURL("http://go.dev", 2)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "e", shortURL)
+}
+
+func TestSQLiteStorage_GetAllURLsByUserID(t *testing.T) {
+	strg := newTestSQLiteStorage(t)
+	strg.CreateShortURLByURL("http://ya.ru", 1)
+	strg.CreateShortURLByURL("http://google.com", 2)
+	strg.CreateShortURLByURL("http://yandex.ru", 1)
+
+	response, errCode := strg.GetAllURLsByUserID(1, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []FullInfoURLResponse{{ShortURL: "localhost:8080/b", OriginalURL: "http://ya.ru"}, {ShortURL: "localhost:8080/d", OriginalURL: "http://yandex.ru"}}, response)
+
+	response, errCode = strg.GetAllURLsByUserID(3, "localhost:8080/")
+	assert.Equal(t, http.StatusNoContent, errCode)
+	assert.Nil(t, response)
+}
+
+func TestSQLiteStorage_MarkBatchAsDeleted(t *testing.T) {
+	strg := newTestSQLiteStorage(t)
+	strg.CreateShortURLByURL("http://ya.ru", 1)
+	strg.CreateShortURLByURL("http://google.com", 2)
+
+	assert.Nil(t, strg.MarkBatchAsDeleted([]uint{1, 2}, 1))
+	_, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+	_, errCode = strg.GetValueByKeyAndUserID(2, 1)
+	assert.Equal(t, 0, errCode)
+
+	stats, errCode := strg.GetStats()
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, StatsResponse{URLs: 1, Users: 2}, stats)
+}
+
+func TestSQLiteStorage_CreateShortURLWithOptions(t *testing.T) {
+	strg := newTestSQLiteStorage(t)
+	shortURL, _, errCode := strg.CreateShortURLWithOptions("http://ya.ru", URLOptions{Alias: "spring-sale", RedirectType: http.StatusFound}, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "spring-sale", shortURL)
+	url, errCode := strg.GetURLByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.False(t, url.CreatedAt.IsZero())
+	url.CreatedAt = time.Time{}
+	assert.Equal(t, URL{Value: "http://ya.ru", Alias: "spring-sale", RedirectType: http.StatusFound}, url)
+
+	shortURL, _, errCode = strg.CreateShortURLWithOptions("http://google.com", URLOptions{Alias: "spring-sale"}, 1)
+	assert.Equal(t, http.StatusConflict, errCode)
+
+	passwordHash, err := HashPassword("secret")
+	require.NoError(t, err)
+	_, _, errCode = strg.CreateShortURLWithOptions("http://yandex.ru", URLOptions{Title: "Spring sale", RequireInterstitial: true, PasswordHash: passwordHash}, 1)
+	assert.Equal(t, 0, errCode)
+	url, errCode = strg.GetURLByKeyAndUserID(2, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "Spring sale", url.Title)
+	assert.True(t, url.RequireInterstitial)
+	assert.False(t, url.CreatedAt.IsZero())
+	assert.True(t, url.CheckPassword("secret"))
+	assert.False(t, url.CheckPassword("wrong"))
+	assert.Equal(t, "", shortURL)
+
+	shortURL, _, errCode = strg.CreateShortURLByURL("http://ya.ru", 2)
+	assert.Equal(t, http.StatusConflict, errCode)
+	assert.Equal(t, "spring-sale", shortURL)
+
+	ID, errCode := strg.GetIDByAlias("spring-sale")
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, uint(1), ID)
+	_, errCode = strg.GetIDByAlias("unknown-alias")
+	assert.Equal(t, http.StatusNotFound, errCode)
+}
+
+func TestSQLiteStorage_MarkExpiredAsDeleted(t *testing.T) {
+	strg := newTestSQLiteStorage(t)
+	now := time.Now()
+	_, _, errCode := strg.CreateShortURLWithOptions("http://ya.ru", URLOptions{ExpiresAt: now.Add(time.Minute)}, 1)
+	assert.Equal(t, 0, errCode)
+	expiresAt := now.Add(time.Hour)
+	_, _, errCode = strg.CreateShortURLBatch([]BatchURLRequest{{CorrelationID: "1", OriginalURL: "http://google.com", ExpiresAt: &expiresAt}}, 1, "")
+	assert.Equal(t, 0, errCode)
+
+	value, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://ya.ru", value)
+
+	count, err := strg.MarkExpiredAsDeleted(now.Add(2 * time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	_, errCode = strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+	_, errCode = strg.GetValueByKeyAndUserID(2, 1)
+	assert.Equal(t, 0, errCode)
+}
+
+func TestSQLiteStorage_GetClickStats(t *testing.T) {
+	strg := newTestSQLiteStorage(t)
+	strg.CreateShortURLByURL("http://ya.ru", 1)
+	day := time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC)
+	assert.Nil(t, strg.InsertClicks([]Click{{URLID: 1, Time: day}, {URLID: 1, Time: day.Add(24 * time.Hour)}, {URLID: 1, Time: day}}))
+
+	stats, errCode := strg.GetClickStats(1, 1)
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, ClickStatsResponse{Total: 3, Days: []DayClicks{{Day: "2022-10-01", Clicks: 2}, {Day: "2022-10-02", Clicks: 1}}}, stats)
+
+	_, errCode = strg.GetClickStats(1, 2)
+	assert.Equal(t, http.StatusNotFound, errCode)
+}
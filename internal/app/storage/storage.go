@@ -9,36 +9,90 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/db"
+	"github.com/tank4gun/gourlshortener/internal/app/varprs"
 )
 
 // FullInfoURLResponse - response object for shortened URL with original one
 type FullInfoURLResponse struct {
-	ShortURL    string `json:"short_url"`    // ShortURL - result shorten URL
-	OriginalURL string `json:"original_url"` // OriginalURL - original URL
+	ShortURL        string `json:"short_url"`                  // ShortURL - result shorten URL
+	OriginalURL     string `json:"original_url"`               // OriginalURL - original URL
+	RemainingClicks *int   `json:"remaining_clicks,omitempty"` // RemainingClicks - number of clicks left for URL limited with max clicks, nil otherwise
 }
 
 // StatsResponse - response object for GetStatsHandler method
 type StatsResponse struct {
-	URLs  int `json:"urls"`  // URLs - total URLs amount in database
-	Users int `json:"users"` // Users - total users amount in database
+	URLs   int         `json:"urls"`            // URLs - total URLs amount in database
+	Users  int         `json:"users"`           // Users - total users amount in database
+	Purged int         `json:"purged"`          // Purged - number of deleted URLs purged after retention period
+	Cache  *CacheStats `json:"cache,omitempty"` // Cache - lookup cache counters, nil if cache isn't used
 }
 
-// AllPossibleChars - chars for shorten URL creation
-var AllPossibleChars = "abcdefghijklmnopqrstuvwxwzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+// MinAliasLength - min length of custom alias, short URLs built from IDs are shorter until 62^5 URLs are stored
+const MinAliasLength = 6
+
+// MaxAliasLength - max length of custom alias
+const MaxAliasLength = 64
+
+// MaxTitleLength - max length of URL title
+const MaxTitleLength = 256
+
+// ErrAliasTaken - error for custom alias which is already used by another URL
+var ErrAliasTaken = errors.New("alias is already taken")
+
+// ErrOptionsMismatch - error for URL which is already shortened with options other than requested
+var ErrOptionsMismatch = errors.New("URL is already shortened with other password protection or clicks limit")
+
+// AllPossibleChars - chars for shorten URL creation.
+// Digit 22 is written as 'y', 'w' stays at index 24, so already issued short URLs with 'w' are read as before.
+var AllPossibleChars = "abcdefghijklmnopqrstuvyxwzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// charToIndex - AllPossibleChars map to their indexes
+var charToIndex = func() map[rune]uint {
+	result := make(map[rune]uint)
+	for index, char := range AllPossibleChars {
+		result[char] = uint(index)
+	}
+	return result
+}()
 
 // IRepository interface for usage as storage
 type IRepository interface {
-	InsertValue(value string, userID uint) error                                                                    // InsertValue - insert value for userID into IRepository
-	GetValueByKeyAndUserID(key uint, userID uint) (string, int)                                                     // GetValueByKeyAndUserID - get value by key and userID from IRepository
-	GetNextIndex() (uint, error)                                                                                    // GetNextIndex - get next index for insertion into IRepository
-	GetAllURLsByUserID(userID uint, baseURL string) ([]FullInfoURLResponse, int)                                    // GetAllURLsByUserID - get all URLs by userID from IRepository
-	InsertBatchValues(values []string, startIndex uint, userID uint) error                                          // InsertBatchValues - insert values batch for userID into IRepository
-	MarkBatchAsDeleted(IDs []uint, userID uint) error                                                               // MarkBatchAsDeleted - set deleted=true for rows by its IDs and userID in IRepository
-	GetStats() (response StatsResponse, errCode int)                                                                // GetStats - get stats from database
-	Ping() error                                                                                                    // Ping - check that connection to IRepository is alive
-	Shutdown() error                                                                                                // Shutdown - gracefully shotdown IRepository
-	CreateShortURLByURL(url string, userID uint) (shortURLResult string, errMsg string, errCode int)                // CreateShortURLByURL creates short URL by given URL and inserts it into storage.
-	CreateShortURLBatch(batchURLs []BatchURLRequest, userID uint, baseURL string) ([]BatchURLResponse, string, int) // CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage.
+	InsertValue(value string, userID uint) error                                                                               // InsertValue - insert value for userID into IRepository
+	GetValueByKeyAndUserID(key uint, userID uint) (string, int)                                                                // GetValueByKeyAndUserID - get value by key and userID from IRepository
+	GetURLByKeyAndUserID(key uint, userID uint) (URL, int)                                                                     // GetURLByKeyAndUserID - get not deleted and not expired URL by key and userID from IRepository
+	GetAllURLsByUserID(userID uint, baseURL string) ([]FullInfoURLResponse, int)                                               // GetAllURLsByUserID - get all URLs by userID from IRepository
+	GetURLsPageByUserID(userID uint, query URLsPageQuery, baseURL string) (URLsPage, int)                                      // GetURLsPageByUserID - get page of not deleted URLs by userID from IRepository
+	IterateURLsByUserID(userID uint, baseURL string, yield func(url ExportedURL) error) error                                  // IterateURLsByUserID - pass all URLs of userID to yield one by one, stops on the first yield error
+	MarkBatchAsDeleted(IDs []uint, userID uint) error                                                                          // MarkBatchAsDeleted - set deleted=true for rows by its IDs and userID in IRepository
+	GetDeletedURLsByUserID(userID uint, baseURL string) ([]DeletedURLResponse, int)                                            // GetDeletedURLsByUserID - get deleted URLs by userID from IRepository, the most recently deleted go first
+	UpdateURLValue(key uint, value string, userID uint) (errMsg string, errCode int)                                           // UpdateURLValue - set new value for not deleted URL by key which belongs to userID in IRepository and save the change into URL history
+	GetURLHistory(key uint, userID uint) ([]URLChange, int)                                                                    // GetURLHistory - get changes of URL value by key which belongs to userID from IRepository, the oldest go first
+	RestoreBatch(IDs []uint, userID uint) (int, error)                                                                         // RestoreBatch - set deleted=false for deleted and not expired URLs by IDs which belong to userID in IRepository, returns number of restored URLs
+	GetStats() (response StatsResponse, errCode int)                                                                           // GetStats - get stats from database
+	Ping() error                                                                                                               // Ping - check that connection to IRepository is alive
+	Shutdown() error                                                                                                           // Shutdown - gracefully shotdown IRepository
+	CreateShortURLByURL(url string, userID uint) (shortURLResult string, errMsg string, errCode int)                           // CreateShortURLByURL creates short URL by given URL and inserts it into storage.
+	CreateShortURLWithOptions(url string, options URLOptions, userID uint) (shortURLResult string, errMsg string, errCode int) // CreateShortURLWithOptions creates short URL by given URL and options and inserts it into storage.
+	GetIDByAlias(alias string) (uint, int)                                                                                     // GetIDByAlias - get URL ID by its custom alias from IRepository
+	MarkExpiredAsDeleted(now time.Time) (int, error)                                                                           // MarkExpiredAsDeleted - set deleted=true for URLs expired by now in IRepository, returns number of marked URLs
+	PurgeDeleted(deletedBefore time.Time) (int, error)                                                                         // PurgeDeleted - remove URLs deleted before deletedBefore with their owners and clicks from IRepository, their IDs are never reissued
+	CreateShortURLBatch(batchURLs []BatchURLRequest, userID uint, baseURL string) ([]BatchURLResponse, string, int)            // CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage.
+	InsertClicks(clicks []Click) error                                                                                         // InsertClicks - save clicks batch into IRepository
+	GetClickStats(key uint, userID uint) (ClickStatsResponse, int)                                                             // GetClickStats - get click statistics for URL by key if it belongs to userID
+	ConsumeClick(key uint) int                                                                                                 // ConsumeClick - atomically count click of URL limited with max clicks, http.StatusGone is returned if no clicks are left
+	CreateAPIKey(key APIKey) int                                                                                               // CreateAPIKey - save API key into IRepository, http.StatusConflict is returned if its ID or hash is already used
+	GetAPIKeysByUserID(userID uint) ([]APIKey, int)                                                                            // GetAPIKeysByUserID - get API keys of userID including revoked ones from IRepository, the oldest go first
+	GetAPIKeyByHash(hash string) (APIKey, int)                                                                                 // GetAPIKeyByHash - get not revoked API key by its hash from IRepository
+	RevokeAPIKey(ID string, userID uint) int                                                                                   // RevokeAPIKey - revoke API key by its ID if it belongs to userID in IRepository
+	CreateAccount(account Account) int                                                                                         // CreateAccount - save account into IRepository, http.StatusConflict is returned if its username or ID is already used
+	GetAccountByUsername(username string) (Account, int)                                                                       // GetAccountByUsername - get account by its username from IRepository
+	GetAccountByUserID(userID uint) (Account, int)                                                                             // GetAccountByUserID - get account by its UserID from IRepository, http.StatusNotFound is returned for anonymous user
+	MergeUser(fromUserID uint, toUserID uint) (int, error)                                                                     // MergeUser - move URLs and API keys of fromUserID to toUserID in IRepository, returns number of moved URLs
 }
 
 // ExistError - error type for existing ID in Repository
@@ -54,17 +108,136 @@ func (err *ExistError) Error() string {
 
 // URL - base struct with Value and deletion mark
 type URL struct {
-	Value   string // Value - URL value
-	Deleted bool   // Deleted - true if URL is marked as deleted
+	Value     string    // Value - URL value
+	Deleted   bool      // Deleted - true if URL is marked as deleted
+	Alias     string    // Alias - custom short URL, empty if short URL is built from ID
+	ExpiresAt time.Time // ExpiresAt - time after which URL stops working, zero if URL never expires
+	DeletedAt time.Time // DeletedAt - time when URL was marked as deleted, zero if URL isn't deleted or deletion time is unknown
+
+	RedirectType int // RedirectType - redirect status code for URL, 0 if default one is used
+
+	Title               string    // Title - title of URL set by its owner for preview page
+	RequireInterstitial bool      // RequireInterstitial - true if redirect always goes through preview page
+	CreatedAt           time.Time // CreatedAt - time when URL was created, zero if URL was created before creation time was stored
+	PasswordHash        string    // PasswordHash - salted hash of URL password, empty if URL isn't protected with password
+
+	MaxClicks  int // MaxClicks - number of clicks after which URL stops working, 0 if URL isn't limited
+	UsedClicks int // UsedClicks - number of clicks counted for URL limited with MaxClicks
 }
 
-// Storage - struct for file storage
+// IsExpired - check that URL has expiration time and it has passed by now
+func (url URL) IsExpired(now time.Time) bool {
+	return !url.ExpiresAt.IsZero() && !now.Before(url.ExpiresAt)
+}
+
+// IsExhausted - check that URL is limited with max clicks and all of them are used
+func (url URL) IsExhausted() bool {
+	return url.MaxClicks > 0 && url.UsedClicks >= url.MaxClicks
+}
+
+// RemainingClicks - get number of clicks left for URL, nil if URL isn't limited with max clicks
+func (url URL) RemainingClicks() *int {
+	if url.MaxClicks == 0 {
+		return nil
+	}
+	remaining := url.MaxClicks - url.UsedClicks
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+// URLOptions - optional parameters for URL shortening
+type URLOptions struct {
+	Alias     string    // Alias - custom short URL, short URL is built from ID if empty
+	ExpiresAt time.Time // ExpiresAt - time after which URL stops working, zero if URL never expires
+
+	RedirectType int // RedirectType - redirect status code for URL, 0 if default one is used
+
+	Title               string // Title - title of URL for preview page
+	RequireInterstitial bool   // RequireInterstitial - true if redirect always goes through preview page
+	PasswordHash        string // PasswordHash - salted hash of URL password, see HashPassword
+
+	MaxClicks int // MaxClicks - number of clicks after which URL stops working, 0 if URL isn't limited
+}
+
+// newURL - create URL with given value and options
+func (options URLOptions) newURL(value string) URL {
+	return URL{
+		Value:               value,
+		Alias:               options.Alias,
+		ExpiresAt:           options.ExpiresAt,
+		RedirectType:        options.RedirectType,
+		Title:               options.Title,
+		RequireInterstitial: options.RequireInterstitial,
+		PasswordHash:        options.PasswordHash,
+		MaxClicks:           options.MaxClicks,
+	}
+}
+
+// matchesExisting - check whether existing URL with the same value could be returned for request with options,
+// so that caller never gets URL with other clicks limit. Password hashes are salted and can't be compared,
+// so protected URL is never returned for other request and not protected URL is never returned for request with password.
+func (options URLOptions) matchesExisting(existing URL) bool {
+	return options.PasswordHash == "" && !existing.IsProtected() && options.MaxClicks == existing.MaxClicks
+}
+
+// ExpirationTime - get absolute expiration time by expires_at or ttl_seconds request fields, zero time means no expiration
+func ExpirationTime(expiresAt *time.Time, ttlSeconds int64, now time.Time) time.Time {
+	if expiresAt != nil {
+		return *expiresAt
+	}
+	if ttlSeconds > 0 {
+		return now.Add(time.Duration(ttlSeconds) * time.Second)
+	}
+	return time.Time{}
+}
+
+// nullTime - convert time to sql.NullTime, zero time is stored as NULL
+func nullTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value.UTC(), Valid: !value.IsZero()}
+}
+
+// Storage - struct for in-memory storage with optional file log, safe for concurrent use.
+// URLs, URL values and users are split into shards with their own locks, next index is allocated atomically.
 type Storage struct {
-	InternalStorage map[uint]URL    // InternalStorage - URLID map to URL struct
-	UserIDToURLID   map[uint][]uint // UserIDToURLID - relationships between UserID and URLID
-	NextIndex       uint            // NextIndex - next index to insert
-	Encoder         *json.Encoder   // Encoder - object to encode URLs
-	Decoder         *json.Decoder   // Decoder - object to decode encoded URLs
+	urlShards   [ShardsCount]urlShard   // urlShards - URLID map to URL struct
+	valueShards [ShardsCount]valueShard // valueShards - URL value map to URLID
+	userShards  [ShardsCount]userShard  // userShards - relationships between UserID and URLID
+	nextIndex   atomic.Uint64           // nextIndex - next index to insert
+	aliases     map[string]uint         // aliases - custom alias map to URLID
+	aliasLock   sync.RWMutex            // aliasLock - guards aliases, is taken after value shard lock
+
+	Encoder *json.Encoder // Encoder - object to encode URLs
+	Decoder *json.Decoder // Decoder - object to decode encoded URLs
+
+	file             *os.File      // file - log file, nil for in-memory Storage
+	compactThreshold int64         // compactThreshold - log size in bytes which triggers compaction, 0 disables it
+	compactChannel   chan struct{} // compactChannel - channel for compaction requests to CompactionDaemon
+	compactionLock   sync.RWMutex  // compactionLock - held for reading by modifications and for writing by compaction
+	logMutex         sync.Mutex    // logMutex - guards Encoder and logSeq
+	logSeq           uint64        // logSeq - sequence number of the last record written into log or covered by snapshot
+
+	clickStats   map[uint]map[string]int // clickStats - URLID map to number of clicks per day
+	clickFile    *os.File                // clickFile - clicks file, nil for in-memory Storage
+	clickEncoder *json.Encoder           // clickEncoder - object to encode clicks into clickFile
+	clickMutex   sync.Mutex              // clickMutex - guards click fields
+
+	apiKeys      map[string]APIKey // apiKeys - API key ID map to API key
+	apiKeyHashes map[string]string // apiKeyHashes - API key hash map to its ID
+	apiKeyLock   sync.RWMutex      // apiKeyLock - guards API key fields
+
+	accounts         map[string]Account // accounts - username map to account
+	accountUsernames map[uint]string    // accountUsernames - account ID map to its username
+	accountLock      sync.RWMutex       // accountLock - guards account fields
+}
+
+// NewMemoryStorage - create in-memory Storage with given URLs, users and next index
+func NewMemoryStorage(internalStorage map[uint]URL, userIDToURLID map[uint][]uint, nextInd uint) *Storage {
+	strg := &Storage{}
+	strg.initShards()
+	strg.restore(internalStorage, userIDToURLID, nextInd)
+	return strg
 }
 
 // BatchURLRequest request type for batch URLs
@@ -73,6 +246,10 @@ type BatchURLRequest struct {
 	CorrelationID string `json:"correlation_id"`
 	// OriginalURL - URL to shorten
 	OriginalURL string `json:"original_url"`
+	// ExpiresAt - optional absolute expiration time
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// TTLSeconds - optional time to live in seconds, is used if ExpiresAt isn't set
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
 }
 
 // BatchURLResponse response type for batch URLs
@@ -81,6 +258,8 @@ type BatchURLResponse struct {
 	CorrelationID string `json:"correlation_id"`
 	// ShortURL - result shorten URL
 	ShortURL string `json:"short_url"`
+	// Conflict - true if URL was already shortened before or earlier in the same batch, ShortURL is the existing one
+	Conflict bool `json:"conflict,omitempty"`
 }
 
 // DBStorage - struct for database storage
@@ -93,9 +272,18 @@ func (strg *DBStorage) Shutdown() error {
 	return strg.db.Close()
 }
 
-// Shutdown - in case Storage do nothing
+// Shutdown - in case Storage stop compaction and close log file
 func (strg *Storage) Shutdown() error {
-	return nil
+	if strg.file == nil {
+		return nil
+	}
+	strg.compactionLock.Lock()
+	defer strg.compactionLock.Unlock()
+	close(strg.compactChannel)
+	if err := strg.closeClicks(); err != nil {
+		return err
+	}
+	return strg.file.Close()
 }
 
 // CreateShortURL - get short URL from its ID
@@ -111,7 +299,24 @@ func CreateShortURL(currInd uint) string {
 	return sb.String()
 }
 
-// MapItem - struct for Storage getting-URLs usage
+// ConvertShortURLToID - get ID from short URL, it is inverse of CreateShortURL which writes the lowest digit first
+func ConvertShortURLToID(shortURL string) uint {
+	var id uint = 0
+	for index := len(shortURL) - 1; index >= 0; index-- {
+		id = id*62 + charToIndex[rune(shortURL[index])]
+	}
+	return id
+}
+
+// GetShortURL - get short URL for URL with given ID and custom alias, alias has priority over ID
+func GetShortURL(ID uint, alias string) string {
+	if alias != "" {
+		return alias
+	}
+	return CreateShortURL(ID)
+}
+
+// MapItem - struct for Storage getting-URLs usage, legacy format of Storage file log lines
 type MapItem struct {
 	Key   uint   // Key - key for URL
 	Value string // Value - value for URL
@@ -127,6 +332,9 @@ func Max(x, y uint) uint {
 
 // NewStorage - create Storage instance with given parameters
 func NewStorage(internalStorage map[uint]URL, nextInd uint, filename string, dbDSN string) (IRepository, error) {
+	if db.IsSQLiteDSN(dbDSN) {
+		return NewSQLiteStorage(db.SQLitePath(dbDSN))
+	}
 	if dbDSN != "" {
 		database, err := sql.Open("pgx", dbDSN)
 		if err != nil {
@@ -135,25 +343,35 @@ func NewStorage(internalStorage map[uint]URL, nextInd uint, filename string, dbD
 		return &DBStorage{database}, nil
 	}
 	if filename == "" {
-		return &Storage{internalStorage, make(map[uint][]uint), nextInd, nil, nil}, nil
+		return NewMemoryStorage(internalStorage, make(map[uint][]uint), nextInd), nil
 	} else {
 		file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0777)
 		if err != nil {
 			return nil, err
 		}
-		internalStorage := make(map[uint]URL)
-		decoder := json.NewDecoder(file)
-		encoder := json.NewEncoder(file)
-		nextInd := uint(0)
-		for {
-			var mapItem MapItem
-			err := decoder.Decode(&mapItem)
-			if err != nil {
-				return &Storage{internalStorage, make(map[uint][]uint), nextInd + 1, encoder, decoder}, nil
+		strg := NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1)
+		strg.Decoder = json.NewDecoder(file)
+		strg.Encoder = json.NewEncoder(file)
+		strg.file = file
+		strg.compactThreshold = varprs.CompactionThreshold
+		strg.compactChannel = make(chan struct{}, 1)
+		if err := strg.loadSnapshot(filename + SnapshotSuffix); err != nil {
+			file.Close()
+			return nil, err
+		}
+		if offset, err := strg.replayRecords(strg.Decoder, strg.logSeq); err != nil {
+			log.Printf("Got broken record in %s at offset %d, truncate it: %s", filename, offset, err.Error())
+			if err := truncatePartialRecord(file, offset); err != nil {
+				file.Close()
+				return nil, err
 			}
-			internalStorage[mapItem.Key] = URL{mapItem.Value, false}
-			nextInd = Max(nextInd, mapItem.Key)
 		}
+		if err := strg.loadClicks(filename + ClicksSuffix); err != nil {
+			file.Close()
+			return nil, err
+		}
+		go strg.CompactionDaemon()
+		return strg, nil
 	}
 }
 
@@ -164,303 +382,390 @@ func (strg *Storage) Ping() error {
 
 // GetAllURLsByUserID - get all URLs by userID from Storage
 func (strg *Storage) GetAllURLsByUserID(userID uint, baseURL string) ([]FullInfoURLResponse, int) {
-	userURLs, ok := strg.UserIDToURLID[userID]
+	userURLs, ok := strg.getUserURLIDs(userID)
 	if !ok {
 		return nil, http.StatusNoContent
 	}
 	responseList := make([]FullInfoURLResponse, 0)
 	// URLID - URL ID
 	for _, URLID := range userURLs {
-		shortURL := CreateShortURL(URLID)
-		shortURL = baseURL + shortURL
-		originalURL, ok := strg.InternalStorage[URLID]
+		originalURL, ok := strg.getURL(URLID)
 		if !ok {
 			return nil, http.StatusInternalServerError
 		}
+		shortURL := baseURL + GetShortURL(URLID, originalURL.Alias)
 		responseList = append(responseList, FullInfoURLResponse{ShortURL: shortURL, OriginalURL: originalURL.Value})
 	}
 	return responseList, 200
 }
 
-// GetNextIndex - get next index for insertion into Storage
-func (strg *Storage) GetNextIndex() (uint, error) {
-	return strg.NextIndex, nil
-}
-
 // InsertValue - insert value for userID into IRepository
 func (strg *Storage) InsertValue(value string, userID uint) error {
-	_, ok := strg.InternalStorage[strg.NextIndex]
-	if ok {
-		return errors.New("got same key already in storage")
-	}
-	for i := uint(0); i < strg.NextIndex; i++ {
-		URLval, ok := strg.InternalStorage[strg.NextIndex]
-		if ok && URLval.Value == value {
-			log.Printf("Got same URL in storage %s", value)
-			return &ExistError{ID: i, Err: "Got same URL in storage"}
-		}
+	_, err := strg.insertValue(URL{Value: value}, userID)
+	return err
+}
+
+// insertValue - insert URL for userID into Storage, returns ID of inserted URL
+func (strg *Storage) insertValue(url URL, userID uint) (uint, error) {
+	if url.CreatedAt.IsZero() {
+		url.CreatedAt = time.Now().UTC()
 	}
-	strg.InternalStorage[strg.NextIndex] = URL{value, false}
-	_, ok = strg.UserIDToURLID[userID]
-	if !ok {
-		strg.UserIDToURLID[userID] = make([]uint, 0)
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	shard := strg.valueShardFor(url.Value)
+	shard.mutex.Lock()
+	if ID, ok := shard.ids[url.Value]; ok {
+		shard.mutex.Unlock()
+		log.Printf("Got same URL in storage %s", url.Value)
+		return 0, &ExistError{ID: ID, Err: "Got same URL in storage"}
 	}
-	strg.UserIDToURLID[userID] = append(strg.UserIDToURLID[userID][:], strg.NextIndex)
-	if strg.Encoder != nil {
-		mapItem := MapItem{Key: strg.NextIndex, Value: value}
-		if err := strg.Encoder.Encode(mapItem); err != nil {
-			return err
-		}
+	ID, err := strg.claimNewURL(url)
+	if err != nil {
+		shard.mutex.Unlock()
+		return 0, err
 	}
-	strg.NextIndex++
-	return nil
+	shard.ids[url.Value] = ID
+	shard.mutex.Unlock()
+	// Owner is assigned only after records are written, so deletion record can't precede creation one
+	if err := strg.writeRecords(NewCreatedRecord(ID, url), NewOwnerAssignedRecord(ID, userID)); err != nil {
+		return 0, err
+	}
+	strg.addUserURLID(userID, ID)
+	strg.checkLogSize()
+	return ID, nil
 }
 
 // GetValueByKeyAndUserID - get value by key and userID from IRepository
 func (strg *Storage) GetValueByKeyAndUserID(key uint, userID uint) (string, int) {
-	value, ok := strg.InternalStorage[key]
+	value, errCode := strg.GetURLByKeyAndUserID(key, userID)
+	return value.Value, errCode
+}
+
+// GetURLByKeyAndUserID - get not deleted and not expired URL by key and userID from Storage
+func (strg *Storage) GetURLByKeyAndUserID(key uint, userID uint) (URL, int) {
+	value, ok := strg.getURL(key)
+	if !ok && strg.isPurged(key) {
+		return URL{}, http.StatusGone
+	}
 	if !ok {
 		log.Printf("got key %d not presented in storage", key)
-		return "", http.StatusBadRequest
+		return URL{}, http.StatusBadRequest
 	}
-	if value.Deleted {
-		return "", http.StatusGone
+	if value.Deleted || value.IsExpired(time.Now()) || value.IsExhausted() {
+		return URL{}, http.StatusGone
 	}
-	return value.Value, 0
+	return value, 0
+}
+
+// ConsumeClick - atomically count click of URL limited with max clicks under its shard lock, nothing is counted for not limited URL
+func (strg *Storage) ConsumeClick(key uint) int {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	errCode := strg.consumeURLClick(key, time.Now(), func(usedClicks int) error {
+		return strg.writeRecords(NewClickedRecord(key, usedClicks))
+	})
+	strg.checkLogSize()
+	return errCode
 }
 
 // MarkBatchAsDeleted - set deleted=true for rows by its IDs and userID in IRepository
 func (strg *Storage) MarkBatchAsDeleted(IDs []uint, userID uint) error {
-	userURLs, ok := strg.UserIDToURLID[userID]
-	if !ok {
-		return errors.New("couldn't get userURLs")
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	// User without URLs has nothing to delete
+	userURLs, _ := strg.getUserURLIDs(userID)
+	userURLsSet := make(map[uint]bool, len(userURLs))
+	for _, userURLID := range userURLs {
+		userURLsSet[userURLID] = true
 	}
+	now := time.Now().UTC()
 	for _, ID := range IDs {
-		for _, userURLID := range userURLs {
-			if ID == userURLID {
-				value := strg.InternalStorage[ID]
+		if !userURLsSet[ID] {
+			continue
+		}
+		deleted := false
+		strg.updateURL(ID, func(value *URL) {
+			if !value.Deleted {
 				value.Deleted = true
-				strg.InternalStorage[ID] = value
+				value.DeletedAt = now
+				deleted = true
 			}
+		})
+		if !deleted {
+			continue
 		}
-	}
-	return nil
-}
-
-// InsertBatchValues - insert values batch for userID into IRepository
-func (strg *Storage) InsertBatchValues(values []string, startIndex uint, userID uint) error {
-	for index, value := range values {
-		indexToInsert := startIndex + uint(index)
-		_, ok := strg.InternalStorage[indexToInsert]
-		if ok {
-			return &ExistError{indexToInsert, "Got used index"}
-		}
-		strg.InternalStorage[indexToInsert] = URL{value, false}
-		_, ok = strg.UserIDToURLID[userID]
-		if !ok {
-			strg.UserIDToURLID[userID] = make([]uint, 0)
-		}
-		strg.UserIDToURLID[userID] = append(strg.UserIDToURLID[userID][:], indexToInsert)
-		if strg.Encoder != nil {
-			mapItem := MapItem{Key: indexToInsert, Value: value}
-			if err := strg.Encoder.Encode(mapItem); err != nil {
-				return err
-			}
+		if err := strg.writeRecords(NewDeletedRecord(ID, userID, now)); err != nil {
+			return err
 		}
-		strg.NextIndex++
 	}
+	strg.checkLogSize()
 	return nil
 }
 
 // GetStats - get stats from database
 func (strg *Storage) GetStats() (response StatsResponse, errCode int) {
 	// URLsCount - number of URLs in Storage
-	URLsCount := int(strg.NextIndex) - 1
+	URLsCount := int(strg.nextIndex.Load()) - 1
 	// UsersCount - number of users in Storage
-	UsersCount := len(strg.UserIDToURLID)
-	return StatsResponse{URLs: URLsCount, Users: UsersCount}, 200
+	UsersCount := strg.usersCount()
+	return StatsResponse{URLs: URLsCount, Users: UsersCount, Purged: strg.purgedCount()}, 200
 }
 
 // CreateShortURLByURL creates short URL by given URL and inserts it into storage.
 func (strg *Storage) CreateShortURLByURL(url string, userID uint) (shortURLResult string, errMsg string, errCode int) {
-	currInd, indErr := strg.GetNextIndex()
-	if indErr != nil {
-		return "", "Bad next index", http.StatusInternalServerError
-	}
-	strgErr := strg.InsertValue(url, userID)
+	return strg.CreateShortURLWithOptions(url, URLOptions{}, userID)
+}
+
+// CreateShortURLWithOptions creates short URL by given URL and options and inserts it into storage.
+// Existing short URL of the same URL is returned with http.StatusConflict only if its password protection
+// and clicks limit match options.
+func (strg *Storage) CreateShortURLWithOptions(url string, options URLOptions, userID uint) (shortURLResult string, errMsg string, errCode int) {
+	currInd, strgErr := strg.insertValue(options.newURL(url), userID)
 	var exErr *ExistError
-	log.Println(strgErr)
 	if errors.As(strgErr, &exErr) {
-		return CreateShortURL(exErr.ID), "", http.StatusConflict
+		existingURL, _ := strg.getURL(exErr.ID)
+		if !options.matchesExisting(existingURL) {
+			return "", ErrOptionsMismatch.Error(), http.StatusConflict
+		}
+		return GetShortURL(exErr.ID, existingURL.Alias), "", http.StatusConflict
+	}
+	if errors.Is(strgErr, ErrAliasTaken) {
+		return "", "Alias " + options.Alias + " is already taken", http.StatusConflict
 	}
 	if strgErr != nil {
 		return "", strgErr.Error(), http.StatusInternalServerError
 	}
-	shortURL := CreateShortURL(currInd)
-	return shortURL, "", 0
+	return GetShortURL(currInd, options.Alias), "", 0
 }
 
-// CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage.
-func (strg *Storage) CreateShortURLBatch(batchURLs []BatchURLRequest, userID uint, baseURL string) ([]BatchURLResponse, string, int) {
-	currInd, indErr := strg.GetNextIndex()
-	if indErr != nil {
-		return make([]BatchURLResponse, 0), "Bad next index", http.StatusInternalServerError
-	}
-	var resultURLs []BatchURLResponse
-	var insertURLs []string
-	for index, URLrequest := range batchURLs {
-		shortURL := CreateShortURL(currInd + uint(index))
-		insertURLs = append(insertURLs, URLrequest.OriginalURL)
-		resultURL := BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + shortURL}
-		resultURLs = append(resultURLs, resultURL)
+// MarkExpiredAsDeleted - set deleted=true for URLs expired by now in Storage, returns number of marked URLs
+func (strg *Storage) MarkExpiredAsDeleted(now time.Time) (int, error) {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	expired := make(map[uint]time.Time)
+	for i := range strg.urlShards {
+		shard := &strg.urlShards[i]
+		shard.mutex.Lock()
+		for key, value := range shard.urls {
+			if !value.Deleted && value.IsExpired(now) {
+				value.Deleted = true
+				value.DeletedAt = value.ExpiresAt
+				shard.urls[key] = value
+				expired[key] = value.ExpiresAt
+			}
+		}
+		shard.mutex.Unlock()
 	}
-	err := strg.InsertBatchValues(insertURLs, currInd, userID)
-	if err != nil {
-		return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+	for ID, expiresAt := range expired {
+		if err := strg.writeRecords(NewDeletedRecord(ID, 0, expiresAt)); err != nil {
+			return 0, err
+		}
 	}
-	return resultURLs, "", 0
+	strg.checkLogSize()
+	return len(expired), nil
 }
 
-// GetNextIndex - get next index for insertion into DBStorage
-func (strg *DBStorage) GetNextIndex() (uint, error) {
-	row := strg.db.QueryRow("Select last_value from url_id_seq")
-	var currInd sql.NullInt64
-	err := row.Scan(&currInd)
-	if err != nil {
-		return 0, err
+// GetIDByAlias - get URL ID by its custom alias from Storage
+func (strg *Storage) GetIDByAlias(alias string) (uint, int) {
+	strg.aliasLock.RLock()
+	defer strg.aliasLock.RUnlock()
+	ID, ok := strg.aliases[alias]
+	if !ok {
+		return 0, http.StatusNotFound
 	}
-	if currInd.Valid {
-		val := currInd.Int64
-		return uint(val) + 1, nil
-	} else {
-		return 1, nil
+	return ID, 0
+}
+
+// CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage.
+// URLs which are already stored or repeat earlier batch items aren't inserted, their existing short URLs are returned with Conflict flag.
+func (strg *Storage) CreateShortURLBatch(batchURLs []BatchURLRequest, userID uint, baseURL string) ([]BatchURLResponse, string, int) {
+	now := time.Now()
+	var resultURLs []BatchURLResponse
+	for _, URLrequest := range batchURLs {
+		currInd, err := strg.insertValue(URL{Value: URLrequest.OriginalURL, ExpiresAt: ExpirationTime(URLrequest.ExpiresAt, URLrequest.TTLSeconds, now)}, userID)
+		var exErr *ExistError
+		if errors.As(err, &exErr) {
+			existingURL, _ := strg.getURL(exErr.ID)
+			resultURLs = append(resultURLs, BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + GetShortURL(exErr.ID, existingURL.Alias), Conflict: true})
+			continue
+		}
+		if err != nil {
+			return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+		}
+		resultURLs = append(resultURLs, BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + CreateShortURL(currInd)})
 	}
+	return resultURLs, "", 0
 }
 
 // InsertValue - insert value for userID into DBStorage
 func (strg *DBStorage) InsertValue(value string, userID uint) error {
+	_, err := strg.insertValue(URL{Value: value}, userID)
+	return err
+}
+
+// insertValue - insert URL for userID into DBStorage, returns ID allocated by the INSERT itself
+func (strg *DBStorage) insertValue(url URL, userID uint) (uint, error) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
 	// URLID - URL ID
 	var URLID uint
-	row := strg.db.QueryRow("SELECT id from url where value = $1", value)
-	err := row.Scan(&URLID)
-	if err != nil && err != sql.ErrNoRows {
-		log.Println(err)
-		return err
-	}
-	if err == nil {
-		return &ExistError{uint(URLID), "Got existing URL"}
-	}
-	log.Printf("Insert value %s into url table", value)
-	row = strg.db.QueryRow("INSERT INTO url (value) values ($1) returning id", value)
+	log.Printf("Insert value %s into url table", url.Value)
+	// Concurrent insertion of the same value or alias doesn't fail on unique_url or unique_alias, it just returns no rows
+	row := tx.QueryRow(
+		"INSERT INTO url (value, alias, expires_at, redirect_type, title, require_interstitial, password_hash, max_clicks) "+
+			"VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING RETURNING id",
+		url.Value, url.Alias, nullTime(url.ExpiresAt), url.RedirectType, url.Title, url.RequireInterstitial, url.PasswordHash, url.MaxClicks,
+	)
 	err = row.Scan(&URLID)
+	if err == sql.ErrNoRows {
+		row = tx.QueryRow("SELECT id FROM url WHERE value = $1", url.Value)
+		if err = row.Scan(&URLID); err == sql.ErrNoRows {
+			return 0, ErrAliasTaken
+		}
+		if err != nil {
+			return 0, err
+		}
+		return 0, &ExistError{URLID, "Got existing URL"}
+	}
 	if err != nil {
-		log.Fatal(err)
-		return err
+		return 0, err
 	}
-	row = strg.db.QueryRow("INSERT INTO user_url (user_id, url_id) values ($1, $2)", userID, URLID)
-	err = row.Scan()
-	if err != nil && err != sql.ErrNoRows {
-		log.Fatal(err)
-		return err
+	if _, err = tx.Exec("INSERT INTO user_url (user_id, url_id) VALUES ($1, $2)", userID, URLID); err != nil {
+		return 0, err
 	}
-	return nil
+	return URLID, tx.Commit()
 }
 
 // GetValueByKeyAndUserID - get value by key and userID from DBStorage
 func (strg *DBStorage) GetValueByKeyAndUserID(key uint, userID uint) (string, int) {
-	row := strg.db.QueryRow("SELECT value, deleted from url where id = $1", key)
-	var value string
-	var deleted bool
-	err := row.Scan(&value, &deleted)
+	value, errCode := strg.GetURLByKeyAndUserID(key, userID)
+	return value.Value, errCode
+}
+
+// GetURLByKeyAndUserID - get not deleted and not expired URL by key and userID from DBStorage
+func (strg *DBStorage) GetURLByKeyAndUserID(key uint, userID uint) (URL, int) {
+	row := strg.db.QueryRow(
+		"SELECT value, COALESCE(alias, ''), deleted, expires_at, redirect_type, title, require_interstitial, created_at, password_hash, max_clicks, used_clicks "+
+			"from url where id = $1", key,
+	)
+	var value URL
+	var expiresAt, createdAt sql.NullTime
+	err := row.Scan(
+		&value.Value, &value.Alias, &value.Deleted, &expiresAt, &value.RedirectType, &value.Title, &value.RequireInterstitial, &createdAt, &value.PasswordHash,
+		&value.MaxClicks, &value.UsedClicks,
+	)
+	if err == sql.ErrNoRows {
+		var purged bool
+		if err := strg.db.QueryRow("SELECT EXISTS (SELECT 1 FROM purged_url WHERE id = $1)", key).Scan(&purged); err == nil && purged {
+			return URL{}, http.StatusGone
+		}
+	}
 	if err != nil {
 		log.Printf("got key %d not presented in storage", key)
-		return "", http.StatusBadRequest
+		return URL{}, http.StatusBadRequest
 	}
-	if deleted {
-		return "", http.StatusGone
+	value.ExpiresAt = expiresAt.Time
+	value.CreatedAt = createdAt.Time
+	if value.Deleted || value.IsExpired(time.Now()) || value.IsExhausted() {
+		return URL{}, http.StatusGone
 	}
 	return value, 0
 }
 
-// GetAllURLsByUserID - get all URLs by userID from DBStorage
+// ConsumeClick - atomically count click of URL limited with max clicks with a single UPDATE ... RETURNING, nothing is counted for not limited URL
+func (strg *DBStorage) ConsumeClick(key uint) int {
+	var ID uint
+	err := strg.db.QueryRow(
+		"UPDATE url SET used_clicks = used_clicks + 1 "+
+			"WHERE id = $1 AND max_clicks > 0 AND used_clicks < max_clicks AND deleted = false AND (expires_at IS NULL OR expires_at > $2) RETURNING id",
+		key, time.Now().UTC(),
+	).Scan(&ID)
+	if err == nil {
+		return 0
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("Couldn't count click of %d, %s", key, err.Error())
+		return http.StatusInternalServerError
+	}
+	// Nothing is updated for not limited URL as well as for unavailable one
+	var limited bool
+	err = strg.db.QueryRow(
+		"SELECT max_clicks > 0 FROM url WHERE id = $1 AND deleted = false AND (expires_at IS NULL OR expires_at > $2)", key, time.Now().UTC(),
+	).Scan(&limited)
+	if err != nil || limited {
+		return http.StatusGone
+	}
+	return 0
+}
+
+// GetAllURLsByUserID - get all URLs by userID from DBStorage with a single query
 func (strg *DBStorage) GetAllURLsByUserID(userID uint, baseURL string) ([]FullInfoURLResponse, int) {
-	userURLs := make([]uint, 0)
-	rows, err := strg.db.Query("SELECT url_id from user_url where user_id = $1", userID)
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, '') FROM user_url JOIN url ON url.id = user_url.url_id WHERE user_url.user_id = $1 ORDER BY user_url.id",
+		userID,
+	)
 	if err != nil {
-		return nil, http.StatusNoContent
+		return nil, http.StatusInternalServerError
 	}
 	defer rows.Close()
+	responseList := make([]FullInfoURLResponse, 0)
 	for rows.Next() {
 		// URLID - URL ID
 		var URLID uint
-		err = rows.Scan(&URLID)
-		if err != nil {
-			return nil, http.StatusNoContent
+		var originalURL, alias string
+		if err = rows.Scan(&URLID, &originalURL, &alias); err != nil {
+			return nil, http.StatusInternalServerError
 		}
-		userURLs = append(userURLs, URLID)
+		responseList = append(responseList, FullInfoURLResponse{ShortURL: baseURL + GetShortURL(URLID, alias), OriginalURL: originalURL})
 	}
-	err = rows.Err()
-	if err != nil {
-		return nil, http.StatusNoContent
+	if err = rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError
 	}
-
-	responseList := make([]FullInfoURLResponse, 0)
-	// URLID - URL ID
-	for _, URLID := range userURLs {
-		shortURL := CreateShortURL(URLID)
-		shortURL = baseURL + shortURL
-		originalURL, errCode := strg.GetValueByKeyAndUserID(URLID, userID)
-		if errCode != 0 {
-			return nil, http.StatusInternalServerError
-		}
-		responseList = append(responseList, FullInfoURLResponse{ShortURL: shortURL, OriginalURL: originalURL})
+	if len(responseList) == 0 {
+		return nil, http.StatusNoContent
 	}
 	return responseList, 200
 }
 
-// Ping - check that connection to DBStorage is alive
-func (strg *DBStorage) Ping() error {
-	err := strg.db.Ping()
-	return err
-}
-
-// InsertBatchValues - insert values batch for userID into DBStorage
-func (strg *DBStorage) InsertBatchValues(values []string, startIndex uint, userID uint) error {
-	tx, err := strg.db.Begin()
-	if err != nil {
-		return err
-	}
-	URLstmt, err := tx.Prepare("INSERT INTO url (value) VALUES ($1)")
+// GetURLsPageByUserID - get page of not deleted URLs by userID from DBStorage with a single query
+func (strg *DBStorage) GetURLsPageByUserID(userID uint, query URLsPageQuery, baseURL string) (URLsPage, int) {
+	order, comparison, after := query.sqlBounds()
+	limit := query.limit()
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, ''), "+
+			"CASE WHEN url.max_clicks > 0 THEN GREATEST(url.max_clicks - url.used_clicks, 0) END FROM user_url JOIN url ON url.id = user_url.url_id "+
+			"WHERE user_url.user_id = $1 AND url.deleted = false AND url.id "+comparison+" $2 AND strpos(url.value, $3) > 0 "+
+			"ORDER BY url.id "+order+" LIMIT $4",
+		userID, after, query.Filter, limit+1,
+	)
 	if err != nil {
-		return err
+		return URLsPage{}, http.StatusInternalServerError
 	}
-	UserURLstmt, err := tx.Prepare("INSERT INTO user_url (user_id, url_id) VALUES ($1, $2)")
+	defer rows.Close()
+	return scanURLsPage(rows, limit, baseURL)
+}
+
+// IterateURLsByUserID - pass all URLs of userID including deleted ones to yield in creation order, rows are read from DBStorage one by one
+func (strg *DBStorage) IterateURLsByUserID(userID uint, baseURL string, yield func(url ExportedURL) error) error {
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, ''), url.deleted, url.expires_at FROM user_url JOIN url ON url.id = user_url.url_id "+
+			"WHERE user_url.user_id = $1 ORDER BY url.id",
+		userID,
+	)
 	if err != nil {
 		return err
 	}
-	defer URLstmt.Close()
-	defer UserURLstmt.Close()
-	for index, value := range values {
-		if _, err := URLstmt.Exec(value); err != nil {
-			if err = tx.Rollback(); err != nil {
-				log.Fatalf("Insert to url, need rollback, %v", err)
-				return err
-			}
-			return err
-		}
-		if _, err = UserURLstmt.Exec(userID, startIndex+uint(index)); err != nil {
-			if err = tx.Rollback(); err != nil {
-				log.Fatalf("Insert to user_url, need rollback, %v", err)
-				return err
-			}
-			return err
-		}
-	}
-	if err := tx.Commit(); err != nil {
-		log.Fatalf("Unable to commit: %v", err)
-		return err
-	}
-	return nil
+	defer rows.Close()
+	return iterateURLRows(rows, baseURL, yield)
+}
+
+// Ping - check that connection to DBStorage is alive
+func (strg *DBStorage) Ping() error {
+	err := strg.db.Ping()
+	return err
 }
 
 // MarkBatchAsDeleted - set deleted=true for rows by its IDs and userID in DBStorage
@@ -471,13 +776,13 @@ func (strg *DBStorage) MarkBatchAsDeleted(IDs []uint, userID uint) error {
 	}
 	log.Printf("Delete urls %v for user_id %d", IDs, userID)
 	updateStmt, err := tx.Prepare(
-		"UPDATE url SET deleted = true WHERE id IN (SELECT url_id FROM user_url where user_id = ($1) AND url_id = ANY($2::integer[]))",
+		"UPDATE url SET deleted = true, deleted_at = $3 WHERE deleted = false AND id IN (SELECT url_id FROM user_url where user_id = ($1) AND url_id = ANY($2::integer[]))",
 	)
 	if err != nil {
 		return err
 	}
 	defer updateStmt.Close()
-	if _, err := updateStmt.Exec(userID, IDs); err != nil {
+	if _, err := updateStmt.Exec(userID, IDs, time.Now().UTC()); err != nil {
 		if err1 := tx.Rollback(); err1 != nil {
 			log.Printf("Update stmt failed, %s", err1.Error())
 			return err1
@@ -492,6 +797,35 @@ func (strg *DBStorage) MarkBatchAsDeleted(IDs []uint, userID uint) error {
 	return nil
 }
 
+// GetDeletedURLsByUserID - get deleted URLs by userID from DBStorage, the most recently deleted go first
+func (strg *DBStorage) GetDeletedURLsByUserID(userID uint, baseURL string) ([]DeletedURLResponse, int) {
+	rows, err := strg.db.Query(
+		"SELECT url.id, url.value, COALESCE(url.alias, ''), url.deleted_at FROM user_url JOIN url ON url.id = user_url.url_id "+
+			"WHERE user_url.user_id = $1 AND url.deleted = true ORDER BY url.deleted_at DESC NULLS LAST, url.id",
+		userID,
+	)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	return scanDeletedURLs(rows, baseURL)
+}
+
+// RestoreBatch - set deleted=false for deleted and not expired URLs by IDs which belong to userID in DBStorage, returns number of restored URLs
+func (strg *DBStorage) RestoreBatch(IDs []uint, userID uint) (int, error) {
+	log.Printf("Restore urls %v for user_id %d", IDs, userID)
+	result, err := strg.db.Exec(
+		"UPDATE url SET deleted = false, deleted_at = NULL WHERE deleted = true AND (expires_at IS NULL OR expires_at > $3) "+
+			"AND id IN (SELECT url_id FROM user_url WHERE user_id = $1 AND url_id = ANY($2::integer[]))",
+		userID, IDs, time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
 // GetStats - get stats from database
 func (strg *DBStorage) GetStats() (response StatsResponse, errCode int) {
 	row := strg.db.QueryRow("SELECT count(*) from url where deleted = false")
@@ -510,45 +844,383 @@ func (strg *DBStorage) GetStats() (response StatsResponse, errCode int) {
 		log.Print("Couldn't get Users count")
 		return StatsResponse{}, http.StatusBadRequest
 	}
-	return StatsResponse{URLs: URLsCount, Users: UsersCount}, 200
+	// PurgedCount - number of purged URLs in DBStorage
+	var PurgedCount int
+	if err := strg.db.QueryRow("SELECT count(*) FROM purged_url").Scan(&PurgedCount); err != nil {
+		log.Print("Couldn't get purged URLs count")
+		return StatsResponse{}, http.StatusBadRequest
+	}
+	return StatsResponse{URLs: URLsCount, Users: UsersCount, Purged: PurgedCount}, 200
 }
 
 // CreateShortURLByURL creates short URL by given URL and inserts it into storage.
 func (strg *DBStorage) CreateShortURLByURL(url string, userID uint) (shortURLResult string, errMsg string, errCode int) {
-	currInd, indErr := strg.GetNextIndex()
-	if indErr != nil {
-		return "", "Bad next index", http.StatusInternalServerError
-	}
-	strgErr := strg.InsertValue(url, userID)
+	return strg.CreateShortURLWithOptions(url, URLOptions{}, userID)
+}
+
+// CreateShortURLWithOptions creates short URL by given URL and options and inserts it into storage.
+func (strg *DBStorage) CreateShortURLWithOptions(url string, options URLOptions, userID uint) (shortURLResult string, errMsg string, errCode int) {
+	URLID, strgErr := strg.insertValue(options.newURL(url), userID)
 	var exErr *ExistError
-	log.Println(strgErr)
 	if errors.As(strgErr, &exErr) {
-		return CreateShortURL(exErr.ID), "", http.StatusConflict
+		var existingURL URL
+		if err := strg.db.QueryRow(
+			"SELECT COALESCE(alias, ''), password_hash, max_clicks FROM url WHERE id = $1", exErr.ID,
+		).Scan(&existingURL.Alias, &existingURL.PasswordHash, &existingURL.MaxClicks); err != nil {
+			return "", err.Error(), http.StatusInternalServerError
+		}
+		if !options.matchesExisting(existingURL) {
+			return "", ErrOptionsMismatch.Error(), http.StatusConflict
+		}
+		return GetShortURL(exErr.ID, existingURL.Alias), "", http.StatusConflict
+	}
+	if errors.Is(strgErr, ErrAliasTaken) {
+		return "", "Alias " + options.Alias + " is already taken", http.StatusConflict
 	}
 	if strgErr != nil {
+		log.Println(strgErr)
 		return "", strgErr.Error(), http.StatusInternalServerError
 	}
-	shortURL := CreateShortURL(currInd)
-	return shortURL, "", 0
+	return GetShortURL(URLID, options.Alias), "", 0
 }
 
-// CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage.
+// MarkExpiredAsDeleted - set deleted=true for URLs expired by now in DBStorage, returns number of marked URLs
+func (strg *DBStorage) MarkExpiredAsDeleted(now time.Time) (int, error) {
+	result, err := strg.db.Exec("UPDATE url SET deleted = true, deleted_at = expires_at WHERE deleted = false AND expires_at <= $1", now.UTC())
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+// PurgeDeleted - remove URLs deleted before deletedBefore with their owners and clicks from DBStorage in one transaction.
+// Purged IDs are saved into purged_url table, so they are never reissued, while their values could be shortened again.
+func (strg *DBStorage) PurgeDeleted(deletedBefore time.Time) (int, error) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	// Rows are locked, so concurrent restore waits for purge and doesn't find them afterwards
+	rows, err := tx.Query("SELECT id FROM url WHERE deleted = true AND deleted_at < $1 FOR UPDATE", deletedBefore.UTC())
+	if err != nil {
+		return 0, err
+	}
+	IDs := make([]uint, 0)
+	for rows.Next() {
+		// URLID - URL ID
+		var URLID uint
+		if err := rows.Scan(&URLID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		IDs = append(IDs, URLID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(IDs) == 0 {
+		return 0, nil
+	}
+	for _, query := range []string{
+		"DELETE FROM click WHERE url_id = ANY($1::integer[])",
+		"DELETE FROM url_history WHERE url_id = ANY($1::integer[])",
+		"DELETE FROM user_url WHERE url_id = ANY($1::integer[])",
+		"DELETE FROM url WHERE id = ANY($1::integer[])",
+	} {
+		if _, err := tx.Exec(query, IDs); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.Exec("INSERT INTO purged_url (id, purged_at) SELECT unnest($1::integer[]), $2", IDs, time.Now().UTC()); err != nil {
+		return 0, err
+	}
+	return len(IDs), tx.Commit()
+}
+
+// UpdateURLValue - set new value for not deleted URL by key which belongs to userID in DBStorage and save the change into url_history table.
+// http.StatusConflict is returned if value is already used by another URL.
+func (strg *DBStorage) UpdateURLValue(key uint, value string, userID uint) (errMsg string, errCode int) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	defer tx.Rollback()
+	var oldValue string
+	var deleted bool
+	var expiresAt sql.NullTime
+	// Row is locked, so concurrent changes of the same URL are saved into history one after another
+	row := tx.QueryRow(
+		"SELECT value, deleted, expires_at FROM url WHERE id = $1 AND EXISTS (SELECT 1 FROM user_url WHERE user_id = $2 AND url_id = $1) FOR UPDATE",
+		key, userID,
+	)
+	err = row.Scan(&oldValue, &deleted, &expiresAt)
+	if err == sql.ErrNoRows {
+		return "URL not found", http.StatusNotFound
+	}
+	if err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	now := time.Now().UTC()
+	if deleted || (URL{ExpiresAt: expiresAt.Time}).IsExpired(now) {
+		return "URL is deleted", http.StatusGone
+	}
+	if oldValue == value {
+		return "", 0
+	}
+	// Value is checked against unique_url by the UPDATE itself, concurrent insertion of the same value fails it too
+	if _, err := tx.Exec("UPDATE url SET value = $2 WHERE id = $1", key, value); err != nil {
+		var existingID uint
+		if strg.db.QueryRow("SELECT id FROM url WHERE value = $1", value).Scan(&existingID) == nil {
+			return "URL is already shortened", http.StatusConflict
+		}
+		return err.Error(), http.StatusInternalServerError
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO url_history (url_id, old_value, new_value, user_id, changed_at) VALUES ($1, $2, $3, $4, $5)", key, oldValue, value, userID, now,
+	); err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	if err := tx.Commit(); err != nil {
+		return err.Error(), http.StatusInternalServerError
+	}
+	return "", 0
+}
+
+// GetURLHistory - get changes of URL value by key which belongs to userID from DBStorage, the oldest go first
+func (strg *DBStorage) GetURLHistory(key uint, userID uint) ([]URLChange, int) {
+	var owned bool
+	if err := strg.db.QueryRow("SELECT EXISTS (SELECT 1 FROM user_url WHERE user_id = $1 AND url_id = $2)", userID, key).Scan(&owned); err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	if !owned {
+		return nil, http.StatusNotFound
+	}
+	rows, err := strg.db.Query("SELECT old_value, new_value, user_id, changed_at FROM url_history WHERE url_id = $1 ORDER BY id", key)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	return scanURLHistory(rows)
+}
+
+// GetIDByAlias - get URL ID by its custom alias from DBStorage
+func (strg *DBStorage) GetIDByAlias(alias string) (uint, int) {
+	// URLID - URL ID
+	var URLID uint
+	err := strg.db.QueryRow("SELECT id FROM url WHERE alias = $1", alias).Scan(&URLID)
+	if err == sql.ErrNoRows {
+		return 0, http.StatusNotFound
+	}
+	if err != nil {
+		return 0, http.StatusInternalServerError
+	}
+	return URLID, 0
+}
+
+// CreateShortURLBatch creates short URLs by given URLs batch and inserts them into storage in one transaction.
+// Short URLs are built from IDs returned by the INSERTs, URLs which are already stored or repeat earlier batch items
+// don't fail on unique_url index, their existing short URLs are returned with Conflict flag.
 func (strg *DBStorage) CreateShortURLBatch(batchURLs []BatchURLRequest, userID uint, baseURL string) ([]BatchURLResponse, string, int) {
-	currInd, indErr := strg.GetNextIndex()
-	if indErr != nil {
-		return make([]BatchURLResponse, 0), "Bad next index", http.StatusInternalServerError
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
 	}
+	defer tx.Rollback()
+	now := time.Now()
 	var resultURLs []BatchURLResponse
-	var insertURLs []string
-	for index, URLrequest := range batchURLs {
-		shortURL := CreateShortURL(currInd + uint(index))
-		insertURLs = append(insertURLs, URLrequest.OriginalURL)
-		resultURL := BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + shortURL}
-		resultURLs = append(resultURLs, resultURL)
+	for _, URLrequest := range batchURLs {
+		// URLID - URL ID
+		var URLID uint
+		expiresAt := nullTime(ExpirationTime(URLrequest.ExpiresAt, URLrequest.TTLSeconds, now))
+		err := tx.QueryRow("INSERT INTO url (value, expires_at) VALUES ($1, $2) ON CONFLICT DO NOTHING RETURNING id", URLrequest.OriginalURL, expiresAt).Scan(&URLID)
+		if err == sql.ErrNoRows {
+			var alias string
+			if err := tx.QueryRow("SELECT id, COALESCE(alias, '') FROM url WHERE value = $1", URLrequest.OriginalURL).Scan(&URLID, &alias); err != nil {
+				return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+			}
+			resultURLs = append(resultURLs, BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + GetShortURL(URLID, alias), Conflict: true})
+			continue
+		}
+		if err != nil {
+			log.Printf("Couldn't insert batch, %s", err.Error())
+			return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+		}
+		if _, err := tx.Exec("INSERT INTO user_url (user_id, url_id) VALUES ($1, $2)", userID, URLID); err != nil {
+			return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
+		}
+		resultURLs = append(resultURLs, BatchURLResponse{CorrelationID: URLrequest.CorrelationID, ShortURL: baseURL + CreateShortURL(URLID)})
 	}
-	err := strg.InsertBatchValues(insertURLs, currInd, userID)
-	if err != nil {
+	if err := tx.Commit(); err != nil {
 		return make([]BatchURLResponse, 0), "Error while inserting into storage", http.StatusInternalServerError
 	}
 	return resultURLs, "", 0
 }
+
+// InsertClicks - save clicks batch into DBStorage
+func (strg *DBStorage) InsertClicks(clicks []Click) error {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	clickStmt, err := tx.Prepare("INSERT INTO click (url_id, clicked_at, referrer, user_agent, client_subnet) VALUES ($1, $2, $3, $4, $5)")
+	if err != nil {
+		return err
+	}
+	defer clickStmt.Close()
+	for _, click := range clicks {
+		if _, err := clickStmt.Exec(click.URLID, click.Time.UTC(), click.Referrer, click.UserAgent, click.ClientSubnet); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetClickStats - get click statistics for URL by key if it belongs to userID from DBStorage
+func (strg *DBStorage) GetClickStats(key uint, userID uint) (ClickStatsResponse, int) {
+	var owned bool
+	row := strg.db.QueryRow("SELECT EXISTS (SELECT 1 FROM user_url WHERE user_id = $1 AND url_id = $2)", userID, key)
+	if err := row.Scan(&owned); err != nil {
+		return ClickStatsResponse{}, http.StatusInternalServerError
+	}
+	if !owned {
+		return ClickStatsResponse{}, http.StatusNotFound
+	}
+	rows, err := strg.db.Query(
+		"SELECT to_char(clicked_at AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day, count(*) FROM click WHERE url_id = $1 GROUP BY day ORDER BY day",
+		key,
+	)
+	if err != nil {
+		return ClickStatsResponse{}, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	response := ClickStatsResponse{Days: make([]DayClicks, 0)}
+	for rows.Next() {
+		var dayClicks DayClicks
+		if err := rows.Scan(&dayClicks.Day, &dayClicks.Clicks); err != nil {
+			return ClickStatsResponse{}, http.StatusInternalServerError
+		}
+		response.Total += dayClicks.Clicks
+		response.Days = append(response.Days, dayClicks)
+	}
+	if err := rows.Err(); err != nil {
+		return ClickStatsResponse{}, http.StatusInternalServerError
+	}
+	return response, http.StatusOK
+}
+
+// CreateAPIKey - save API key into DBStorage, http.StatusConflict is returned if its ID or hash is already used
+func (strg *DBStorage) CreateAPIKey(key APIKey) int {
+	var ID string
+	err := strg.db.QueryRow(
+		"INSERT INTO api_key (id, user_id, name, hash, scopes, created_at) VALUES ($1, $2, $3, $4, $5, $6) ON CONFLICT DO NOTHING RETURNING id",
+		key.ID, key.UserID, key.Name, key.Hash, strings.Join(key.Scopes, ","), key.CreatedAt.UTC(),
+	).Scan(&ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusConflict
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return 0
+}
+
+// GetAPIKeysByUserID - get API keys of userID including revoked ones from DBStorage, the oldest go first
+func (strg *DBStorage) GetAPIKeysByUserID(userID uint) ([]APIKey, int) {
+	rows, err := strg.db.Query("SELECT id, user_id, name, hash, scopes, created_at, revoked_at FROM api_key WHERE user_id = $1 ORDER BY created_at, id", userID)
+	if err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	return scanAPIKeys(rows)
+}
+
+// GetAPIKeyByHash - get not revoked API key by its hash from DBStorage
+func (strg *DBStorage) GetAPIKeyByHash(hash string) (APIKey, int) {
+	rows, err := strg.db.Query("SELECT id, user_id, name, hash, scopes, created_at, revoked_at FROM api_key WHERE hash = $1 AND revoked_at IS NULL", hash)
+	if err != nil {
+		return APIKey{}, http.StatusInternalServerError
+	}
+	defer rows.Close()
+	keys, errCode := scanAPIKeys(rows)
+	if errCode == http.StatusNoContent {
+		return APIKey{}, http.StatusNotFound
+	}
+	if errCode != http.StatusOK {
+		return APIKey{}, errCode
+	}
+	return keys[0], 0
+}
+
+// RevokeAPIKey - revoke API key by its ID if it belongs to userID in DBStorage, revocation of revoked key does nothing
+func (strg *DBStorage) RevokeAPIKey(ID string, userID uint) int {
+	var found bool
+	err := strg.db.QueryRow(
+		"WITH revoked AS (UPDATE api_key SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL RETURNING id) "+
+			"SELECT EXISTS (SELECT 1 FROM api_key WHERE id = $1 AND user_id = $2)",
+		ID, userID, time.Now().UTC(),
+	).Scan(&found)
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	if !found {
+		return http.StatusNotFound
+	}
+	return 0
+}
+
+// CreateAccount - save account into DBStorage, http.StatusConflict is returned if its username or ID is already used
+func (strg *DBStorage) CreateAccount(account Account) int {
+	var ID uint
+	err := strg.db.QueryRow(
+		"INSERT INTO account (id, username, password_hash, created_at) VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING RETURNING id",
+		account.ID, account.Username, account.PasswordHash, account.CreatedAt.UTC(),
+	).Scan(&ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return http.StatusConflict
+	}
+	if err != nil {
+		return http.StatusInternalServerError
+	}
+	return 0
+}
+
+// GetAccountByUsername - get account by its username from DBStorage
+func (strg *DBStorage) GetAccountByUsername(username string) (Account, int) {
+	return scanAccount(strg.db.QueryRow("SELECT id, username, password_hash, created_at FROM account WHERE username = $1", username))
+}
+
+// GetAccountByUserID - get account by its UserID from DBStorage, http.StatusNotFound is returned for anonymous user
+func (strg *DBStorage) GetAccountByUserID(userID uint) (Account, int) {
+	return scanAccount(strg.db.QueryRow("SELECT id, username, password_hash, created_at FROM account WHERE id = $1", userID))
+}
+
+// MergeUser - move URLs and API keys of fromUserID to toUserID in DBStorage, returns number of moved URLs.
+// URLs which already belong to toUserID are dropped from fromUserID so that they aren't listed twice.
+func (strg *DBStorage) MergeUser(fromUserID uint, toUserID uint) (int, error) {
+	tx, err := strg.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	deleted, err := tx.Exec("DELETE FROM user_url WHERE user_id = $1 AND url_id IN (SELECT url_id FROM user_url WHERE user_id = $2)", fromUserID, toUserID)
+	if err != nil {
+		return 0, err
+	}
+	updated, err := tx.Exec("UPDATE user_url SET user_id = $2 WHERE user_id = $1", fromUserID, toUserID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.Exec("UPDATE api_key SET user_id = $2 WHERE user_id = $1", fromUserID, toUserID); err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	deletedCount, _ := deleted.RowsAffected()
+	updatedCount, _ := updated.RowsAffected()
+	return int(deletedCount + updatedCount), nil
+}
@@ -1,32 +1,38 @@
 package storage
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tank4gun/gourlshortener/internal/app/db"
 )
 
 func TestStorage_GetValueByKeyAndUserID(t *testing.T) {
 	tests := []struct {
 		name          string
-		startStorage  Storage
+		startStorage  *Storage
 		key           uint
 		expectedValue string
 	}{
 		{
 			"one_value",
-			Storage{
-				InternalStorage: map[uint]URL{1: {"aaa", false}}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{1: {Value: "aaa", Deleted: false}}, map[uint][]uint{1: {1}}, 2),
 			1,
 			"aaa",
 		},
 		{
 			"two_values",
-			Storage{
-				InternalStorage: map[uint]URL{1: {"aaa", false}, 2: {"bbb", false}}, UserIDToURLID: map[uint][]uint{1: {2}}, NextIndex: 3, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{1: {Value: "aaa", Deleted: false}, 2: {Value: "bbb", Deleted: false}}, map[uint][]uint{1: {2}}, 3),
 			2,
 			"bbb",
 		},
@@ -43,137 +49,28 @@ func TestStorage_GetValueByKeyAndUserID(t *testing.T) {
 func TestStorage_InsertValue(t *testing.T) {
 	tests := []struct {
 		name            string
-		startStorage    Storage
+		startStorage    *Storage
 		value           string
-		expectedStorage Storage
+		expectedStorage *Storage
 	}{
 		{
 			"empty_storage",
-			Storage{
-				InternalStorage: map[uint]URL{}, UserIDToURLID: make(map[uint][]uint), NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{}, make(map[uint][]uint), 1),
 			"aaa",
-			Storage{
-				InternalStorage: map[uint]URL{1: {"aaa", false}}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{1: {Value: "aaa", Deleted: false}}, map[uint][]uint{1: {1}}, 2),
 		},
 		{
 			"one_value",
-			Storage{
-				InternalStorage: map[uint]URL{1: {"aaa", false}}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{1: {Value: "aaa", Deleted: false}}, map[uint][]uint{1: {1}}, 2),
 			"bbb",
-			Storage{
-				InternalStorage: map[uint]URL{1: {"aaa", false}, 2: {"bbb", false}}, UserIDToURLID: map[uint][]uint{1: {1, 2}}, NextIndex: 3, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{1: {Value: "aaa", Deleted: false}, 2: {Value: "bbb", Deleted: false}}, map[uint][]uint{1: {1, 2}}, 3),
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.startStorage.InsertValue(tt.value, 1)
 			assert.Nil(t, err)
-			assert.Equal(t, tt.expectedStorage, tt.startStorage)
-		})
-	}
-}
-
-func TestStorage_InsertBatchValues(t *testing.T) {
-	tests := []struct {
-		name            string
-		startStorage    Storage
-		values          []string
-		expectedStorage Storage
-		expectedErr     error
-	}{
-		{
-			"empty_storage",
-			Storage{
-				InternalStorage: map[uint]URL{}, UserIDToURLID: map[uint][]uint{}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			[]string{"aaaa"},
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-				}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
-			nil,
-		},
-		{
-			"not_empty_storage",
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-				}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
-			[]string{"bbbb", "cccc"},
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-					2: {"bbbb", false},
-					3: {"cccc", false},
-				}, UserIDToURLID: map[uint][]uint{1: {1, 2, 3}}, NextIndex: 4, Encoder: nil, Decoder: nil,
-			},
-			nil,
-		},
-		{
-			"already_used_index",
-			Storage{
-				InternalStorage: map[uint]URL{1: {"aaaa", false}}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			[]string{"bbbb", "cccc"},
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-					2: {"bbbb", false},
-					3: {"cccc", false},
-				}, UserIDToURLID: map[uint][]uint{1: {1, 2, 3}}, NextIndex: 4, Encoder: nil, Decoder: nil,
-			},
-			&ExistError{},
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			err := tt.startStorage.InsertBatchValues(tt.values, tt.startStorage.NextIndex, 1)
-			if tt.expectedErr == nil {
-				assert.Nil(t, err)
-				assert.Equal(t, tt.expectedStorage, tt.startStorage)
-			} else {
-				assert.NotNil(t, err)
-			}
-		})
-	}
-}
-
-func TestStorage_GetNextIndex(t *testing.T) {
-	tests := []struct {
-		name            string
-		storage         Storage
-		expectedNextInd uint
-	}{
-		{
-			"init_next_index",
-			Storage{
-				InternalStorage: map[uint]URL{}, UserIDToURLID: make(map[uint][]uint), NextIndex: 1, Encoder: nil, Decoder: nil,
-			},
-			1,
-		},
-		{
-			"10th_next_index",
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"a", false}, 2: {"b", false}, 3: {"c", false}, 4: {"aa", false}, 5: {"r", false}, 6: {"1", false}, 7: {"qwe", false}, 8: {"d", false}, 9: {"tt", false},
-				},
-				UserIDToURLID: make(map[uint][]uint),
-				NextIndex:     10, Encoder: nil, Decoder: nil,
-			},
-			10,
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			resultNextIndex, err := tt.storage.GetNextIndex()
-			assert.Nil(t, err)
-			assert.Equal(t, tt.expectedNextInd, resultNextIndex)
+			assert.Equal(t, tt.expectedStorage.Snapshot(), withoutCreationTime(tt.startStorage.Snapshot()))
 		})
 	}
 }
@@ -215,7 +112,7 @@ func TestMax(t *testing.T) {
 func TestStorage_GetAllURLsByUserID(t *testing.T) {
 	tests := []struct {
 		name            string
-		startStorage    Storage
+		startStorage    *Storage
 		userID          uint
 		baseURL         string
 		expectedList    []FullInfoURLResponse
@@ -223,11 +120,9 @@ func TestStorage_GetAllURLsByUserID(t *testing.T) {
 	}{
 		{
 			"one_url",
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-				}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{
+				1: {Value: "aaaa", Deleted: false},
+			}, map[uint][]uint{1: {1}}, 2),
 			1,
 			"localhost:8080/",
 			[]FullInfoURLResponse{{ShortURL: "localhost:8080/b", OriginalURL: "aaaa"}},
@@ -235,12 +130,10 @@ func TestStorage_GetAllURLsByUserID(t *testing.T) {
 		},
 		{
 			"two_urls",
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-					2: {"bbbb", false},
-				}, UserIDToURLID: map[uint][]uint{1: {1, 2}}, NextIndex: 3, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{
+				1: {Value: "aaaa", Deleted: false},
+				2: {Value: "bbbb", Deleted: false},
+			}, map[uint][]uint{1: {1, 2}}, 3),
 			1,
 			"localhost:8080/",
 			[]FullInfoURLResponse{{ShortURL: "localhost:8080/b", OriginalURL: "aaaa"}, {ShortURL: "localhost:8080/c", OriginalURL: "bbbb"}},
@@ -248,11 +141,9 @@ func TestStorage_GetAllURLsByUserID(t *testing.T) {
 		},
 		{
 			"no_user",
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-				}, UserIDToURLID: map[uint][]uint{1: {1}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{
+				1: {Value: "aaaa", Deleted: false},
+			}, map[uint][]uint{1: {1}}, 2),
 			2,
 			"localhost:8080/",
 			nil,
@@ -260,12 +151,10 @@ func TestStorage_GetAllURLsByUserID(t *testing.T) {
 		},
 		{
 			"no_url_for_user",
-			Storage{
-				InternalStorage: map[uint]URL{
-					1: {"aaaa", false},
-					2: {"bbbb", false},
-				}, UserIDToURLID: map[uint][]uint{1: {3}}, NextIndex: 2, Encoder: nil, Decoder: nil,
-			},
+			NewMemoryStorage(map[uint]URL{
+				1: {Value: "aaaa", Deleted: false},
+				2: {Value: "bbbb", Deleted: false},
+			}, map[uint][]uint{1: {3}}, 2),
 			1,
 			"localhost:8080/",
 			nil,
@@ -284,12 +173,10 @@ func TestStorage_GetAllURLsByUserID(t *testing.T) {
 func TestStorage_Ping(t *testing.T) {
 	tests := []struct {
 		name    string
-		storage Storage
+		storage *Storage
 	}{{
 		"just_ping_test",
-		Storage{
-			InternalStorage: map[uint]URL{}, UserIDToURLID: make(map[uint][]uint), NextIndex: 1, Encoder: nil, Decoder: nil,
-		},
+		NewMemoryStorage(map[uint]URL{}, make(map[uint][]uint), 1),
 	},
 	}
 	for _, tt := range tests {
@@ -299,8 +186,1199 @@ func TestStorage_Ping(t *testing.T) {
 	}
 }
 
+func TestNewStorage_ReplayFileLog(t *testing.T) {
+	tests := []struct {
+		name              string
+		fileContent       string
+		expectedURLs      map[uint]URL
+		expectedUserURLs  map[uint][]uint
+		expectedNextIndex uint
+	}{
+		{
+			"empty_file",
+			"",
+			map[uint]URL{},
+			map[uint][]uint{},
+			1,
+		},
+		{
+			"legacy_map_items",
+			`{"Key":1,"Value":"aaaa"}` + "\n" + `{"Key":2,"Value":"bbbb"}` + "\n",
+			map[uint]URL{1: {Value: "aaaa", Deleted: false}, 2: {Value: "bbbb", Deleted: false}},
+			map[uint][]uint{},
+			3,
+		},
+		{
+			"legacy_and_versioned_records",
+			`{"Key":1,"Value":"aaaa"}` + "\n" +
+				`{"version":1,"type":"created","key":2,"value":"bbbb"}` + "\n" +
+				`{"version":1,"type":"owner","key":2,"user_id":5}` + "\n" +
+				`{"version":1,"type":"created","key":3,"value":"cccc"}` + "\n" +
+				`{"version":1,"type":"owner","key":3,"user_id":5}` + "\n" +
+				`{"version":1,"type":"deleted","key":2,"user_id":5}` + "\n",
+			map[uint]URL{1: {Value: "aaaa", Deleted: false}, 2: {Value: "bbbb", Deleted: true}, 3: {Value: "cccc", Deleted: false}},
+			map[uint][]uint{5: {2, 3}},
+			4,
+		},
+		{
+			"broken_tail",
+			`{"version":1,"type":"created","key":1,"value":"aaaa"}` + "\n" + `{"version":1,"type":"cre`,
+			map[uint]URL{1: {Value: "aaaa", Deleted: false}},
+			map[uint][]uint{},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "storage.txt")
+			assert.Nil(t, os.WriteFile(filename, []byte(tt.fileContent), 0644))
+			repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+			assert.Nil(t, err)
+			strg := repository.(*Storage)
+			assert.Equal(t, tt.expectedURLs, strg.Snapshot().URLs)
+			assert.Equal(t, tt.expectedUserURLs, strg.Snapshot().UserIDToURLID)
+			assert.Equal(t, tt.expectedNextIndex, strg.Snapshot().NextIndex)
+		})
+	}
+}
+
+// withoutDeletionTime - check that deleted URLs have deletion time and reset it for comparison
+func withoutDeletionTime(t *testing.T, urls map[uint]URL) map[uint]URL {
+	for key, url := range urls {
+		assert.Equal(t, url.Deleted, !url.DeletedAt.IsZero(), "deletion time of URL %d", key)
+		url.DeletedAt = time.Time{}
+		urls[key] = url
+	}
+	return urls
+}
+
+func withoutCreationTime(snapshot Snapshot) Snapshot {
+	for key, url := range snapshot.URLs {
+		url.CreatedAt = time.Time{}
+		snapshot.URLs[key] = url
+	}
+	return snapshot
+}
+
+func TestNewStorage_RestoreStateAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	assert.Nil(t, err)
+	assert.Nil(t, repository.InsertValue("aaaa", 1))
+	assert.Nil(t, repository.InsertValue("bbbb", 2))
+	assert.Nil(t, repository.InsertValue("cccc", 2))
+	assert.Nil(t, repository.MarkBatchAsDeleted([]uint{3}, 2))
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	assert.Nil(t, err)
+	strg := restored.(*Storage)
+	assert.Equal(t, map[uint]URL{1: {Value: "aaaa", Deleted: false}, 2: {Value: "bbbb", Deleted: false}, 3: {Value: "cccc", Deleted: true}}, withoutDeletionTime(t, withoutCreationTime(strg.Snapshot()).URLs))
+	assert.Equal(t, map[uint][]uint{1: {1}, 2: {2, 3}}, strg.Snapshot().UserIDToURLID)
+	assert.Equal(t, uint(4), strg.Snapshot().NextIndex)
+
+	assert.Nil(t, restored.InsertValue("dddd", 1))
+	value, errCode := restored.GetValueByKeyAndUserID(4, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "dddd", value)
+}
+
+func TestStorage_CreateShortURLWithOptions(t *testing.T) {
+	tests := []struct {
+		name             string
+		url              string
+		options          URLOptions
+		expectedShortURL string
+		expectedErrCode  int
+	}{
+		{
+			"without_alias",
+			"http://google.com",
+			URLOptions{},
+			"c",
+			0,
+		},
+		{
+			"with_alias",
+			"http://google.com",
+			URLOptions{Alias: "spring-sale"},
+			"spring-sale",
+			0,
+		},
+		{
+			"with_redirect_type",
+			"http://google.com",
+			URLOptions{Alias: "spring-sale", RedirectType: http.StatusPermanentRedirect},
+			"spring-sale",
+			0,
+		},
+		{
+			"alias_taken",
+			"http://google.com",
+			URLOptions{Alias: "ya-alias"},
+			"",
+			http.StatusConflict,
+		},
+		{
+			"url_exists",
+			"http://ya.ru",
+			URLOptions{Alias: "spring-sale"},
+			"ya-alias",
+			http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strg := NewMemoryStorage(map[uint]URL{1: {Value: "http://ya.ru", Alias: "ya-alias"}}, map[uint][]uint{1: {1}}, 2)
+			shortURL, _, errCode := strg.CreateShortURLWithOptions(tt.url, tt.options, 1)
+			assert.Equal(t, tt.expectedShortURL, shortURL)
+			assert.Equal(t, tt.expectedErrCode, errCode)
+			if errCode != 0 || tt.options.Alias == "" {
+				return
+			}
+			ID, errCode := strg.GetIDByAlias(tt.options.Alias)
+			assert.Equal(t, 0, errCode)
+			assert.Equal(t, uint(2), ID)
+			url, errCode := strg.GetURLByKeyAndUserID(ID, 1)
+			assert.Equal(t, 0, errCode)
+			assert.Equal(t, tt.options.RedirectType, url.RedirectType)
+		})
+	}
+}
+
+// checkOptionsConflicts - check that existing short URL is returned only for request with matching options
+func checkOptionsConflicts(t *testing.T, strg IRepository) {
+	passwordHash, err := HashPassword("secret")
+	require.NoError(t, err)
+	shortURL, _, errCode := strg.CreateShortURLWithOptions("http://ya.ru", URLOptions{}, 1)
+	require.Equal(t, 0, errCode)
+	protectedShortURL, _, errCode := strg.CreateShortURLWithOptions("http://google.com", URLOptions{PasswordHash: passwordHash}, 1)
+	require.Equal(t, 0, errCode)
+	limitedShortURL, _, errCode := strg.CreateShortURLWithOptions("http://yandex.ru", URLOptions{MaxClicks: 3}, 1)
+	require.Equal(t, 0, errCode)
+
+	tests := []struct {
+		name             string
+		url              string
+		options          URLOptions
+		expectedShortURL string
+	}{
+		{"same_options", "http://ya.ru", URLOptions{}, shortURL},
+		{"password_for_not_protected", "http://ya.ru", URLOptions{PasswordHash: passwordHash}, ""},
+		{"no_password_for_protected", "http://google.com", URLOptions{}, ""},
+		{"password_for_protected", "http://google.com", URLOptions{PasswordHash: passwordHash}, ""},
+		{"same_clicks_limit", "http://yandex.ru", URLOptions{MaxClicks: 3}, limitedShortURL},
+		{"clicks_limit_for_not_limited", "http://ya.ru", URLOptions{MaxClicks: 3}, ""},
+		{"no_clicks_limit_for_limited", "http://yandex.ru", URLOptions{}, ""},
+		{"other_clicks_limit", "http://yandex.ru", URLOptions{MaxClicks: 5}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, errMsg, errCode := strg.CreateShortURLWithOptions(tt.url, tt.options, 2)
+			assert.Equal(t, http.StatusConflict, errCode)
+			assert.Equal(t, tt.expectedShortURL, result)
+			if tt.expectedShortURL == "" {
+				assert.Equal(t, ErrOptionsMismatch.Error(), errMsg)
+			}
+		})
+	}
+	result, _, errCode := strg.CreateShortURLByURL("http://google.com", 2)
+	assert.Equal(t, http.StatusConflict, errCode)
+	assert.Empty(t, result)
+	url, errCode := strg.GetURLByKeyAndUserID(ConvertShortURLToID(protectedShortURL), 1)
+	require.Equal(t, 0, errCode)
+	assert.True(t, url.IsProtected())
+}
+
+func TestStorage_OptionsConflicts(t *testing.T) {
+	checkOptionsConflicts(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_OptionsConflicts(t *testing.T) {
+	checkOptionsConflicts(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_OptionsConflicts(t *testing.T) {
+	checkOptionsConflicts(t, newTestDBStorage(t))
+}
+
+func TestNewStorage_RestoreAliasAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	_, _, errCode := repository.CreateShortURLWithOptions("http://ya.ru", URLOptions{Alias: "spring-sale", RedirectType: http.StatusMovedPermanently}, 1)
+	assert.Equal(t, 0, errCode)
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	ID, errCode := restored.GetIDByAlias("spring-sale")
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, uint(1), ID)
+	url, errCode := restored.GetURLByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	url.CreatedAt = time.Time{}
+	assert.Equal(t, URL{Value: "http://ya.ru", Alias: "spring-sale", RedirectType: http.StatusMovedPermanently}, url)
+	response, _ := restored.GetAllURLsByUserID(1, "localhost:8080/")
+	assert.Equal(t, []FullInfoURLResponse{{ShortURL: "localhost:8080/spring-sale", OriginalURL: "http://ya.ru"}}, response)
+}
+
+func TestNewStorage_RestorePreviewAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	passwordHash, err := HashPassword("secret")
+	require.NoError(t, err)
+	_, _, errCode := repository.CreateShortURLWithOptions("http://ya.ru", URLOptions{Title: "Spring sale", RequireInterstitial: true, PasswordHash: passwordHash}, 1)
+	assert.Equal(t, 0, errCode)
+	created, errCode := repository.GetURLByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	url, errCode := restored.GetURLByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, created, url)
+	assert.Equal(t, "Spring sale", url.Title)
+	assert.True(t, url.RequireInterstitial)
+	assert.True(t, url.IsProtected())
+	assert.True(t, url.CheckPassword("secret"))
+	assert.False(t, url.CheckPassword("wrong"))
+}
+
+func TestStorage_MarkExpiredAsDeleted(t *testing.T) {
+	now := time.Now()
+	strg := NewMemoryStorage(
+		map[uint]URL{
+			1: {Value: "http://ya.ru", ExpiresAt: now.Add(-time.Minute)},
+			2: {Value: "http://google.com", ExpiresAt: now.Add(time.Hour)},
+			3: {Value: "http://yandex.ru"},
+		},
+		map[uint][]uint{1: {1, 2, 3}},
+		4,
+	)
+	_, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+
+	count, err := strg.MarkExpiredAsDeleted(now)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	assert.True(t, strg.Snapshot().URLs[1].Deleted)
+	assert.False(t, strg.Snapshot().URLs[2].Deleted)
+	assert.False(t, strg.Snapshot().URLs[3].Deleted)
+
+	count, err = strg.MarkExpiredAsDeleted(now.Add(2 * time.Hour))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+	_, errCode = strg.GetValueByKeyAndUserID(2, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+	value, errCode := strg.GetValueByKeyAndUserID(3, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://yandex.ru", value)
+}
+
+func TestNewStorage_RestoreExpirationAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _, errCode := repository.CreateShortURLWithOptions("http://ya.ru", URLOptions{ExpiresAt: expiresAt}, 1)
+	assert.Equal(t, 0, errCode)
+	count, err := repository.MarkExpiredAsDeleted(expiresAt)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	url := restored.(*Storage).Snapshot().URLs[1]
+	assert.True(t, expiresAt.Equal(url.ExpiresAt))
+	assert.True(t, url.Deleted)
+}
+
+func TestStorage_GetClickStats(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	assert.Nil(t, repository.InsertValue("http://ya.ru", 1))
+	assert.Nil(t, repository.InsertValue("http://google.com", 1))
+	firstDay := time.Date(2022, 10, 1, 10, 0, 0, 0, time.UTC)
+	secondDay := time.Date(2022, 10, 2, 23, 59, 0, 0, time.UTC)
+	assert.Nil(t, repository.InsertClicks([]Click{
+		{URLID: 1, Time: firstDay, Referrer: "http://ya.ru/search"},
+		{URLID: 1, Time: secondDay},
+		{URLID: 1, Time: secondDay.Add(-time.Hour)},
+		{URLID: 2, Time: firstDay},
+	}))
+	assert.Nil(t, repository.Shutdown())
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	stats, errCode := restored.GetClickStats(1, 1)
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, ClickStatsResponse{Total: 3, Days: []DayClicks{{Day: "2022-10-01", Clicks: 1}, {Day: "2022-10-02", Clicks: 2}}}, stats)
+
+	_, errCode = restored.GetClickStats(1, 2)
+	assert.Equal(t, http.StatusNotFound, errCode)
+}
+
+func TestStorage_Compact(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	assert.Nil(t, err)
+	assert.Nil(t, repository.InsertValue("aaaa", 1))
+	assert.Nil(t, repository.InsertValue("bbbb", 1))
+	assert.Nil(t, repository.MarkBatchAsDeleted([]uint{2}, 1))
+
+	assert.Nil(t, repository.(ICompactor).Compact())
+	info, err := os.Stat(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), info.Size())
+	_, err = os.Stat(filename + SnapshotSuffix)
+	assert.Nil(t, err)
+
+	assert.Nil(t, repository.InsertValue("cccc", 2))
+	assert.Nil(t, repository.Shutdown())
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	assert.Nil(t, err)
+	strg := restored.(*Storage)
+	assert.Equal(t, map[uint]URL{1: {Value: "aaaa", Deleted: false}, 2: {Value: "bbbb", Deleted: true}, 3: {Value: "cccc", Deleted: false}}, withoutDeletionTime(t, withoutCreationTime(strg.Snapshot()).URLs))
+	assert.Equal(t, map[uint][]uint{1: {1, 2}, 2: {3}}, strg.Snapshot().UserIDToURLID)
+	assert.Equal(t, uint(4), strg.Snapshot().NextIndex)
+}
+
+func TestStorage_ReplayAfterInterruptedCompaction(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	require.Nil(t, repository.InsertValue("http://ya.ru", 1))
+	_, errCode := repository.UpdateURLValue(1, "http://ya.ru/new", 1)
+	require.Equal(t, 0, errCode)
+	_, errCode = repository.UpdateURLValue(1, "http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, errCode = repository.UpdateURLValue(1, "http://ya.ru/newest", 1)
+	require.Equal(t, 0, errCode)
+	// Crash after snapshot rename and before log truncation leaves all records in the log
+	records, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Nil(t, repository.(ICompactor).Compact())
+	require.Nil(t, repository.InsertValue("http://google.com", 1))
+	expected := repository.(*Storage).Snapshot()
+	require.Nil(t, repository.Shutdown())
+	tail, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Nil(t, os.WriteFile(filename, append(records, tail...), 0644))
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	assert.Equal(t, expected, restored.(*Storage).Snapshot())
+	value, errCode := restored.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://ya.ru/newest", value)
+}
+
+func TestNewStorage_CorruptedFileLog(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	content := `{"version":1,"type":"created","key":1,"value":"aaaa"}` + "\n" + `{"version":1,"type":"cre` + "\n" +
+		`{"version":1,"type":"created","key":2,"value":"bbbb"}` + "\n"
+	require.Nil(t, os.WriteFile(filename, []byte(content), 0644))
+	_, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	assert.Error(t, err)
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestStorage_CompactOnThreshold(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	assert.Nil(t, err)
+	strg := repository.(*Storage)
+	strg.compactThreshold = 1
+	assert.Nil(t, strg.InsertValue("aaaa", 1))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(filename + SnapshotSuffix)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Nil(t, strg.Shutdown())
+}
+
+func TestStorage_ConcurrentAccess(t *testing.T) {
+	strg := NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1)
+	var wg sync.WaitGroup
+	for userID := uint(1); userID <= 8; userID++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_, _, errCode := strg.CreateShortURLByURL(fmt.Sprintf("http://%d.ru/%d", userID, i), userID)
+				assert.Equal(t, 0, errCode)
+				strg.GetValueByKeyAndUserID(uint(i), userID)
+			}
+		}(userID)
+	}
+	wg.Wait()
+	stats, _ := strg.GetStats()
+	assert.Equal(t, StatsResponse{URLs: 800, Users: 8}, stats)
+	snapshot := strg.Snapshot()
+	assert.Len(t, snapshot.URLs, 800)
+	for userID := uint(1); userID <= 8; userID++ {
+		assert.Len(t, snapshot.UserIDToURLID[userID], 100)
+		for _, URLID := range snapshot.UserIDToURLID[userID] {
+			assert.Contains(t, snapshot.URLs[URLID].Value, fmt.Sprintf("http://%d.ru/", userID))
+		}
+	}
+}
+
+func BenchmarkStorage_Parallel(b *testing.B) {
+	strg := NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1)
+	var counter atomic.Uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := counter.Add(1)
+			userID := uint(i % 16)
+			strg.CreateShortURLByURL(fmt.Sprintf("http://ya.ru/%d", i), userID)
+			strg.GetValueByKeyAndUserID(uint(i), userID)
+			if i%4 == 0 {
+				strg.MarkBatchAsDeleted([]uint{uint(i)}, userID)
+			}
+		}
+	})
+}
+
 func BenchmarkCreateShortURL(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		CreateShortURL(1000)
 	}
 }
+
+// checkConcurrentShortening - shorten URLs from several goroutines and check that every returned short URL
+// points at the row with the same original URL
+func checkConcurrentShortening(t *testing.T, strg IRepository) {
+	const workers = 8
+	const perWorker = 20
+	var wg sync.WaitGroup
+	shortURLs := make([]map[string]string, workers)
+	for worker := 0; worker < workers; worker++ {
+		shortURLs[worker] = make(map[string]string)
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			userID := uint(worker + 1)
+			for i := 0; i < perWorker; i++ {
+				url := fmt.Sprintf("http://%d.ru/%d", worker, i)
+				shortURL, _, errCode := strg.CreateShortURLByURL(url, userID)
+				assert.Equal(t, 0, errCode)
+				shortURLs[worker]["localhost:8080/"+shortURL] = url
+				batch := []BatchURLRequest{
+					{CorrelationID: "1", OriginalURL: url + "/batch/1"},
+					{CorrelationID: "2", OriginalURL: url + "/batch/2"},
+				}
+				response, _, errCode := strg.CreateShortURLBatch(batch, userID, "localhost:8080/")
+				assert.Equal(t, 0, errCode)
+				for index, item := range response {
+					shortURLs[worker][item.ShortURL] = batch[index].OriginalURL
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	for worker := 0; worker < workers; worker++ {
+		userID := uint(worker + 1)
+		response, errCode := strg.GetAllURLsByUserID(userID, "localhost:8080/")
+		assert.Equal(t, http.StatusOK, errCode)
+		assert.Len(t, response, 3*perWorker)
+		for _, item := range response {
+			assert.Equal(t, item.OriginalURL, shortURLs[worker][item.ShortURL])
+		}
+		assert.Len(t, shortURLs[worker], 3*perWorker)
+		for shortURL, url := range shortURLs[worker] {
+			value, errCode := strg.GetValueByKeyAndUserID(ConvertShortURLToID(strings.TrimPrefix(shortURL, "localhost:8080/")), userID)
+			assert.Equal(t, 0, errCode, shortURL)
+			assert.Equal(t, url, value, shortURL)
+		}
+	}
+}
+
+func TestStorage_ConcurrentShortening(t *testing.T) {
+	checkConcurrentShortening(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_ConcurrentShortening(t *testing.T) {
+	checkConcurrentShortening(t, newTestSQLiteStorage(t))
+}
+
+// newTestDBStorage - open DBStorage on PostgreSQL database from TEST_DATABASE_DSN with all tables emptied, test is skipped if it isn't set
+func newTestDBStorage(t *testing.T) *DBStorage {
+	dbDSN := os.Getenv("TEST_DATABASE_DSN")
+	if dbDSN == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	require.NoError(t, db.RunMigrations(dbDSN))
+	repository, err := NewStorage(map[uint]URL{}, 1, "", dbDSN)
+	require.NoError(t, err)
+	strg := repository.(*DBStorage)
+	t.Cleanup(func() { strg.Shutdown() })
+	_, err = strg.db.Exec("TRUNCATE url, user_url, click, purged_url, url_history, api_key, account RESTART IDENTITY")
+	require.NoError(t, err)
+	return strg
+}
+
+func TestDBStorage_ConcurrentShortening(t *testing.T) {
+	checkConcurrentShortening(t, newTestDBStorage(t))
+}
+
+// checkURLsPages - walk through user URLs pages in both orders with filter and check that deleted URLs are skipped
+func checkURLsPages(t *testing.T, strg IRepository) {
+	for _, URL := range []string{"http://ya.ru", "http://google.com", "http://yandex.ru", "http://go.dev", "http://ya.ru/maps"} {
+		_, _, errCode := strg.CreateShortURLByURL(URL, 1)
+		require.Equal(t, 0, errCode)
+	}
+	_, _, errCode := strg.CreateShortURLByURL("http://ya.ru/other", 2)
+	require.Equal(t, 0, errCode)
+	require.Nil(t, strg.MarkBatchAsDeleted([]uint{4}, 1))
+
+	page, errCode := strg.GetURLsPageByUserID(1, URLsPageQuery{Limit: 2}, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []FullInfoURLResponse{{ShortURL: "localhost:8080/b", OriginalURL: "http://ya.ru"}, {ShortURL: "localhost:8080/c", OriginalURL: "http://google.com"}}, page.URLs)
+	assert.Equal(t, uint(2), page.NextAfter)
+
+	page, errCode = strg.GetURLsPageByUserID(1, URLsPageQuery{Limit: 2, After: page.NextAfter}, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []FullInfoURLResponse{{ShortURL: "localhost:8080/d", OriginalURL: "http://yandex.ru"}, {ShortURL: "localhost:8080/f", OriginalURL: "http://ya.ru/maps"}}, page.URLs)
+	assert.Equal(t, uint(0), page.NextAfter)
+
+	page, errCode = strg.GetURLsPageByUserID(1, URLsPageQuery{Limit: 1, Desc: true, Filter: "ya"}, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []FullInfoURLResponse{{ShortURL: "localhost:8080/f", OriginalURL: "http://ya.ru/maps"}}, page.URLs)
+	assert.Equal(t, uint(5), page.NextAfter)
+
+	page, errCode = strg.GetURLsPageByUserID(1, URLsPageQuery{Desc: true, After: page.NextAfter, Filter: "ya"}, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []FullInfoURLResponse{{ShortURL: "localhost:8080/d", OriginalURL: "http://yandex.ru"}, {ShortURL: "localhost:8080/b", OriginalURL: "http://ya.ru"}}, page.URLs)
+	assert.Equal(t, uint(0), page.NextAfter)
+
+	_, errCode = strg.GetURLsPageByUserID(1, URLsPageQuery{Filter: "bing"}, "localhost:8080/")
+	assert.Equal(t, http.StatusNoContent, errCode)
+	_, errCode = strg.GetURLsPageByUserID(3, URLsPageQuery{}, "localhost:8080/")
+	assert.Equal(t, http.StatusNoContent, errCode)
+}
+
+func TestStorage_GetURLsPageByUserID(t *testing.T) {
+	checkURLsPages(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_GetURLsPageByUserID(t *testing.T) {
+	checkURLsPages(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_GetURLsPageByUserID(t *testing.T) {
+	checkURLsPages(t, newTestDBStorage(t))
+}
+
+// checkExportURLs - iterate over user URLs and check that deleted ones are included and yield error stops iteration
+func checkExportURLs(t *testing.T, strg IRepository) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, _, errCode := strg.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLWithOptions("http://google.com", URLOptions{Alias: "google", ExpiresAt: expiresAt}, 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://yandex.ru", 2)
+	require.Equal(t, 0, errCode)
+	require.Nil(t, strg.MarkBatchAsDeleted([]uint{1}, 1))
+
+	exported := make([]ExportedURL, 0)
+	err := strg.IterateURLsByUserID(1, "localhost:8080/", func(url ExportedURL) error {
+		exported = append(exported, url)
+		return nil
+	})
+	assert.Nil(t, err)
+	require.Equal(t, 2, len(exported))
+	assert.Equal(t, ExportedURL{ShortURL: "localhost:8080/b", OriginalURL: "http://ya.ru", Deleted: true}, exported[0])
+	assert.Equal(t, "localhost:8080/google", exported[1].ShortURL)
+	require.NotNil(t, exported[1].ExpiresAt)
+	assert.True(t, expiresAt.Equal(*exported[1].ExpiresAt))
+
+	stopErr := fmt.Errorf("stop")
+	calls := 0
+	err = strg.IterateURLsByUserID(1, "localhost:8080/", func(url ExportedURL) error {
+		calls++
+		return stopErr
+	})
+	assert.Equal(t, stopErr, err)
+	assert.Equal(t, 1, calls)
+
+	err = strg.IterateURLsByUserID(3, "localhost:8080/", func(url ExportedURL) error {
+		calls++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestStorage_IterateURLsByUserID(t *testing.T) {
+	checkExportURLs(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_IterateURLsByUserID(t *testing.T) {
+	checkExportURLs(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_IterateURLsByUserID(t *testing.T) {
+	checkExportURLs(t, newTestDBStorage(t))
+}
+
+func TestSQLiteStorage_IterateURLsByUserIDDoesNotBlockQueries(t *testing.T) {
+	strg := newTestSQLiteStorage(t)
+	for i := 0; i < ExportPageSize+10; i++ {
+		_, _, errCode := strg.CreateShortURLByURL(fmt.Sprintf("http://ya.ru/%d", i), 1)
+		require.Equal(t, 0, errCode)
+	}
+	done := make(chan error, 1)
+	exported := 0
+	go func() {
+		// Every URL is read back while export is in progress, as other requests do while client reads export slowly
+		done <- strg.IterateURLsByUserID(1, "localhost:8080/", func(url ExportedURL) error {
+			exported++
+			_, errCode := strg.GetValueByKeyAndUserID(ConvertShortURLToID(strings.TrimPrefix(url.ShortURL, "localhost:8080/")), 1)
+			if errCode != 0 {
+				return fmt.Errorf("got %d for %s", errCode, url.ShortURL)
+			}
+			return nil
+		})
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		assert.Equal(t, ExportPageSize+10, exported)
+	case <-time.After(5 * time.Second):
+		t.Fatal("queries are blocked by export")
+	}
+}
+
+// checkBatchConflicts - check that batch items which are already stored or repeated in batch are returned with conflict flag
+func checkBatchConflicts(t *testing.T, strg IRepository) {
+	_, _, errCode := strg.CreateShortURLByURL("http://ya.ru", 2)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLWithOptions("http://go.dev", URLOptions{Alias: "golang"}, 2)
+	require.Equal(t, 0, errCode)
+
+	response, _, errCode := strg.CreateShortURLBatch([]BatchURLRequest{
+		{CorrelationID: "1", OriginalURL: "http://ya.ru"},
+		{CorrelationID: "2", OriginalURL: "http://google.com"},
+		{CorrelationID: "3", OriginalURL: "http://google.com"},
+		{CorrelationID: "4", OriginalURL: "http://go.dev"},
+	}, 1, "localhost:8080/")
+	assert.Equal(t, 0, errCode)
+	require.Equal(t, 4, len(response))
+	newShortURL := response[1].ShortURL
+	assert.Equal(t, []BatchURLResponse{
+		{CorrelationID: "1", ShortURL: "localhost:8080/b", Conflict: true},
+		{CorrelationID: "2", ShortURL: newShortURL},
+		{CorrelationID: "3", ShortURL: newShortURL, Conflict: true},
+		{CorrelationID: "4", ShortURL: "localhost:8080/golang", Conflict: true},
+	}, response)
+	stats, _ := strg.GetStats()
+	assert.Equal(t, 3, stats.URLs)
+	URLs, errCode := strg.GetAllURLsByUserID(1, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []FullInfoURLResponse{{ShortURL: newShortURL, OriginalURL: "http://google.com"}}, URLs)
+}
+
+func TestStorage_CreateShortURLBatchConflicts(t *testing.T) {
+	checkBatchConflicts(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_CreateShortURLBatchConflicts(t *testing.T) {
+	checkBatchConflicts(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_CreateShortURLBatchConflicts(t *testing.T) {
+	checkBatchConflicts(t, newTestDBStorage(t))
+}
+
+// checkTrashAndRestore - check that deleted URLs are listed with deletion time and only owned not expired ones are restored
+func checkTrashAndRestore(t *testing.T, strg IRepository) {
+	// PostgreSQL keeps timestamps with microsecond precision
+	before := time.Now().Add(-time.Second).Truncate(time.Microsecond)
+	_, _, errCode := strg.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLWithOptions("http://google.com", URLOptions{Alias: "google", ExpiresAt: before.Add(-time.Hour)}, 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://yandex.ru", 2)
+	require.Equal(t, 0, errCode)
+
+	_, errCode = strg.GetDeletedURLsByUserID(1, "localhost:8080/")
+	assert.Equal(t, http.StatusNoContent, errCode)
+	require.Nil(t, strg.MarkBatchAsDeleted([]uint{1}, 1))
+	require.Nil(t, strg.MarkBatchAsDeleted([]uint{3}, 2))
+	_, err := strg.MarkExpiredAsDeleted(time.Now())
+	require.Nil(t, err)
+
+	trash, errCode := strg.GetDeletedURLsByUserID(1, "localhost:8080/")
+	require.Equal(t, http.StatusOK, errCode)
+	require.Equal(t, 2, len(trash))
+	assert.Equal(t, "localhost:8080/b", trash[0].ShortURL)
+	assert.Equal(t, "http://ya.ru", trash[0].OriginalURL)
+	require.NotNil(t, trash[0].DeletedAt)
+	assert.True(t, trash[0].DeletedAt.After(before))
+	assert.Equal(t, "localhost:8080/google", trash[1].ShortURL)
+	require.NotNil(t, trash[1].DeletedAt)
+	assert.True(t, before.Add(-time.Hour).Equal(*trash[1].DeletedAt))
+
+	restored, err := strg.RestoreBatch([]uint{1, 2, 3}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, restored)
+	value, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://ya.ru", value)
+	_, errCode = strg.GetValueByKeyAndUserID(3, 2)
+	assert.Equal(t, http.StatusGone, errCode)
+	restored, err = strg.RestoreBatch([]uint{1}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, restored)
+
+	trash, errCode = strg.GetDeletedURLsByUserID(1, "localhost:8080/")
+	require.Equal(t, http.StatusOK, errCode)
+	require.Equal(t, 1, len(trash))
+	assert.Equal(t, "localhost:8080/google", trash[0].ShortURL)
+}
+
+func TestStorage_TrashAndRestore(t *testing.T) {
+	checkTrashAndRestore(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_TrashAndRestore(t *testing.T) {
+	checkTrashAndRestore(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_TrashAndRestore(t *testing.T) {
+	checkTrashAndRestore(t, newTestDBStorage(t))
+}
+
+func TestNewStorage_RestoreTrashAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	_, _, errCode := repository.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = repository.CreateShortURLByURL("http://google.com", 1)
+	require.Equal(t, 0, errCode)
+	require.Nil(t, repository.MarkBatchAsDeleted([]uint{1, 2}, 1))
+	deletedAt := repository.(*Storage).Snapshot().URLs[2].DeletedAt
+	restored, err := repository.RestoreBatch([]uint{1}, 1)
+	require.Nil(t, err)
+	require.Equal(t, 1, restored)
+
+	repository, err = NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	URLs := repository.(*Storage).Snapshot().URLs
+	assert.False(t, URLs[1].Deleted)
+	assert.True(t, URLs[1].DeletedAt.IsZero())
+	assert.True(t, URLs[2].Deleted)
+	assert.True(t, deletedAt.Equal(URLs[2].DeletedAt))
+}
+
+// checkPurgeDeleted - check that URLs deleted before retention boundary are purged, their IDs aren't reissued and values could be shortened again
+func checkPurgeDeleted(t *testing.T, strg IRepository) {
+	_, _, errCode := strg.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLWithOptions("http://google.com", URLOptions{Alias: "google"}, 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://yandex.ru", 2)
+	require.Equal(t, 0, errCode)
+	beforeDeletion := time.Now().Add(-time.Second)
+	require.Nil(t, strg.MarkBatchAsDeleted([]uint{1, 2}, 1))
+
+	count, err := strg.PurgeDeleted(beforeDeletion)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+	count, err = strg.PurgeDeleted(time.Now().Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, count)
+	count, err = strg.PurgeDeleted(time.Now().Add(time.Second))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	_, errCode = strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+	_, errCode = strg.GetIDByAlias("google")
+	assert.Equal(t, http.StatusNotFound, errCode)
+	_, errCode = strg.GetDeletedURLsByUserID(1, "localhost:8080/")
+	assert.Equal(t, http.StatusNoContent, errCode)
+	value, errCode := strg.GetValueByKeyAndUserID(3, 2)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://yandex.ru", value)
+	stats, _ := strg.GetStats()
+	assert.Equal(t, 2, stats.Purged)
+	assert.Equal(t, 1, stats.Users)
+
+	shortURL, _, errCode := strg.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	assert.NotEqual(t, "b", shortURL)
+	shortURL, _, errCode = strg.CreateShortURLWithOptions("http://google.com", URLOptions{Alias: "google"}, 1)
+	require.Equal(t, 0, errCode)
+	assert.Equal(t, "google", shortURL)
+	URLs, errCode := strg.GetAllURLsByUserID(1, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, 2, len(URLs))
+}
+
+func TestStorage_PurgeDeleted(t *testing.T) {
+	checkPurgeDeleted(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_PurgeDeleted(t *testing.T) {
+	checkPurgeDeleted(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_PurgeDeleted(t *testing.T) {
+	checkPurgeDeleted(t, newTestDBStorage(t))
+}
+
+func TestNewStorage_RestorePurgedAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	_, _, errCode := repository.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = repository.CreateShortURLByURL("http://google.com", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = repository.CreateShortURLByURL("http://yandex.ru", 1)
+	require.Equal(t, 0, errCode)
+	require.Nil(t, repository.MarkBatchAsDeleted([]uint{1}, 1))
+	count, err := repository.PurgeDeleted(time.Now().Add(time.Second))
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	require.Nil(t, repository.(*Storage).Compact())
+	require.Nil(t, repository.MarkBatchAsDeleted([]uint{2}, 1))
+	count, err = repository.PurgeDeleted(time.Now().Add(time.Second))
+	require.Nil(t, err)
+	require.Equal(t, 1, count)
+	expected := repository.(*Storage).Snapshot()
+	require.Nil(t, repository.Shutdown())
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	snapshot := restored.(*Storage).Snapshot()
+	assert.Equal(t, []uint{1, 2}, snapshot.Purged)
+	assert.Equal(t, expected, snapshot)
+	assert.Equal(t, map[uint][]uint{1: {3}}, snapshot.UserIDToURLID)
+	_, errCode = restored.GetValueByKeyAndUserID(2, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+	shortURL, _, errCode := restored.CreateShortURLByURL("http://google.com", 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, CreateShortURL(4), shortURL)
+}
+
+// checkUpdateURLValue - check that only owner could retarget not deleted URL to not used value and every change is kept in history
+func checkUpdateURLValue(t *testing.T, strg IRepository) {
+	_, _, errCode := strg.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://google.com", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://yandex.ru", 2)
+	require.Equal(t, 0, errCode)
+
+	_, errCode = strg.UpdateURLValue(1, "http://ya.ru/new", 2)
+	assert.Equal(t, http.StatusNotFound, errCode)
+	_, errCode = strg.UpdateURLValue(10, "http://ya.ru/new", 1)
+	assert.Equal(t, http.StatusNotFound, errCode)
+	_, errCode = strg.UpdateURLValue(1, "http://yandex.ru", 1)
+	assert.Equal(t, http.StatusConflict, errCode)
+	_, errCode = strg.UpdateURLValue(1, "http://ya.ru", 1)
+	assert.Equal(t, 0, errCode)
+	_, errCode = strg.GetURLHistory(1, 1)
+	assert.Equal(t, http.StatusNoContent, errCode)
+
+	_, errCode = strg.UpdateURLValue(1, "http://ya.ru/new", 1)
+	require.Equal(t, 0, errCode)
+	_, errCode = strg.UpdateURLValue(1, "http://ya.ru/newest", 1)
+	require.Equal(t, 0, errCode)
+	value, errCode := strg.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://ya.ru/newest", value)
+	history, errCode := strg.GetURLHistory(1, 1)
+	require.Equal(t, http.StatusOK, errCode)
+	require.Equal(t, 2, len(history))
+	assert.Equal(t, "http://ya.ru", history[0].OldValue)
+	assert.Equal(t, "http://ya.ru/new", history[0].NewValue)
+	assert.Equal(t, uint(1), history[0].UserID)
+	assert.Equal(t, "http://ya.ru/new", history[1].OldValue)
+	assert.Equal(t, "http://ya.ru/newest", history[1].NewValue)
+	assert.False(t, history[1].ChangedAt.Before(history[0].ChangedAt))
+	_, errCode = strg.GetURLHistory(1, 2)
+	assert.Equal(t, http.StatusNotFound, errCode)
+
+	// Old value is free for shortening again, new one is taken
+	shortURL, _, errCode := strg.CreateShortURLByURL("http://ya.ru/newest", 2)
+	assert.Equal(t, http.StatusConflict, errCode)
+	assert.Equal(t, "b", shortURL)
+	_, _, errCode = strg.CreateShortURLByURL("http://ya.ru", 2)
+	assert.Equal(t, 0, errCode)
+
+	require.Nil(t, strg.MarkBatchAsDeleted([]uint{2}, 1))
+	_, errCode = strg.UpdateURLValue(2, "http://google.com/new", 1)
+	assert.Equal(t, http.StatusGone, errCode)
+}
+
+func TestStorage_UpdateURLValue(t *testing.T) {
+	checkUpdateURLValue(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_UpdateURLValue(t *testing.T) {
+	checkUpdateURLValue(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_UpdateURLValue(t *testing.T) {
+	checkUpdateURLValue(t, newTestDBStorage(t))
+}
+
+// checkConsumeClick - check that URL limited with max clicks gives exactly max clicks under concurrent requests and is gone after them
+func checkConsumeClick(t *testing.T, strg IRepository) {
+	_, _, errCode := strg.CreateShortURLWithOptions("http://ya.ru", URLOptions{MaxClicks: 5}, 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://google.com", 1)
+	require.Equal(t, 0, errCode)
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	consumed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if strg.ConsumeClick(1) == 0 {
+				mutex.Lock()
+				consumed++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 5, consumed)
+	assert.Equal(t, http.StatusGone, strg.ConsumeClick(1))
+	_, errCode = strg.GetURLByKeyAndUserID(1, 1)
+	assert.Equal(t, http.StatusGone, errCode)
+
+	assert.Equal(t, 0, strg.ConsumeClick(2))
+	url, errCode := strg.GetURLByKeyAndUserID(2, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Nil(t, url.RemainingClicks())
+
+	page, errCode := strg.GetURLsPageByUserID(1, URLsPageQuery{Limit: 10}, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	noneLeft := 0
+	assert.Equal(t, []FullInfoURLResponse{
+		{ShortURL: "localhost:8080/b", OriginalURL: "http://ya.ru", RemainingClicks: &noneLeft},
+		{ShortURL: "localhost:8080/c", OriginalURL: "http://google.com"},
+	}, page.URLs)
+}
+
+func TestStorage_ConsumeClick(t *testing.T) {
+	checkConsumeClick(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_ConsumeClick(t *testing.T) {
+	checkConsumeClick(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_ConsumeClick(t *testing.T) {
+	checkConsumeClick(t, newTestDBStorage(t))
+}
+
+func TestNewStorage_RestoreClicksAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	_, _, errCode := repository.CreateShortURLWithOptions("http://ya.ru", URLOptions{MaxClicks: 2}, 1)
+	require.Equal(t, 0, errCode)
+	assert.Equal(t, 0, repository.ConsumeClick(1))
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	url, errCode := restored.GetURLByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, 1, *url.RemainingClicks())
+	assert.Equal(t, 0, restored.ConsumeClick(1))
+	assert.Equal(t, http.StatusGone, restored.ConsumeClick(1))
+}
+
+func TestNewStorage_RestoreHistoryAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	_, _, errCode := repository.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, errCode = repository.UpdateURLValue(1, "http://ya.ru/new", 1)
+	require.Equal(t, 0, errCode)
+	require.Nil(t, repository.(*Storage).Compact())
+	_, errCode = repository.UpdateURLValue(1, "http://ya.ru/newest", 1)
+	require.Equal(t, 0, errCode)
+	expected := repository.(*Storage).Snapshot()
+	require.Nil(t, repository.Shutdown())
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	snapshot := restored.(*Storage).Snapshot()
+	assert.Equal(t, expected, snapshot)
+	assert.Equal(t, 2, len(snapshot.History[1]))
+	value, errCode := restored.GetValueByKeyAndUserID(1, 1)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://ya.ru/newest", value)
+	_, _, errCode = restored.CreateShortURLByURL("http://ya.ru/newest", 1)
+	assert.Equal(t, http.StatusConflict, errCode)
+	_, _, errCode = restored.CreateShortURLByURL("http://ya.ru/new", 1)
+	assert.Equal(t, 0, errCode)
+}
+
+func checkAPIKeys(t *testing.T, strg IRepository) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := APIKey{ID: "first", UserID: 1, Name: "backend", Hash: "hash1", Scopes: []string{"shorten", "read"}, CreatedAt: createdAt}
+	second := APIKey{ID: "second", UserID: 1, Hash: "hash2", Scopes: []string{"delete"}, CreatedAt: createdAt.Add(time.Hour)}
+	assert.Equal(t, 0, strg.CreateAPIKey(second))
+	assert.Equal(t, 0, strg.CreateAPIKey(first))
+	assert.Equal(t, 0, strg.CreateAPIKey(APIKey{ID: "other", UserID: 2, Hash: "hash3", Scopes: []string{"read"}, CreatedAt: createdAt}))
+	assert.Equal(t, http.StatusConflict, strg.CreateAPIKey(APIKey{ID: "first", UserID: 1, Hash: "hash4", Scopes: []string{"read"}, CreatedAt: createdAt}))
+	assert.Equal(t, http.StatusConflict, strg.CreateAPIKey(APIKey{ID: "third", UserID: 1, Hash: "hash1", Scopes: []string{"read"}, CreatedAt: createdAt}))
+
+	keys, errCode := strg.GetAPIKeysByUserID(1)
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, []APIKey{first, second}, keys)
+	_, errCode = strg.GetAPIKeysByUserID(3)
+	assert.Equal(t, http.StatusNoContent, errCode)
+
+	key, errCode := strg.GetAPIKeyByHash("hash1")
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, first, key)
+	_, errCode = strg.GetAPIKeyByHash("unknown")
+	assert.Equal(t, http.StatusNotFound, errCode)
+
+	assert.Equal(t, http.StatusNotFound, strg.RevokeAPIKey("first", 2))
+	assert.Equal(t, http.StatusNotFound, strg.RevokeAPIKey("unknown", 1))
+	assert.Equal(t, 0, strg.RevokeAPIKey("first", 1))
+	assert.Equal(t, 0, strg.RevokeAPIKey("first", 1))
+	_, errCode = strg.GetAPIKeyByHash("hash1")
+	assert.Equal(t, http.StatusNotFound, errCode)
+	keys, errCode = strg.GetAPIKeysByUserID(1)
+	assert.Equal(t, http.StatusOK, errCode)
+	require.Len(t, keys, 2)
+	assert.True(t, keys[0].IsRevoked())
+	assert.False(t, keys[1].IsRevoked())
+}
+
+func TestStorage_APIKeys(t *testing.T) {
+	checkAPIKeys(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_APIKeys(t *testing.T) {
+	checkAPIKeys(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_APIKeys(t *testing.T) {
+	checkAPIKeys(t, newTestDBStorage(t))
+}
+
+func TestNewStorage_RestoreAPIKeysAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Equal(t, 0, repository.CreateAPIKey(APIKey{ID: "first", UserID: 1, Hash: "hash1", Scopes: []string{"read"}, CreatedAt: createdAt}))
+	require.Nil(t, repository.(*Storage).Compact())
+	require.Equal(t, 0, repository.CreateAPIKey(APIKey{ID: "second", UserID: 1, Hash: "hash2", Scopes: []string{"shorten"}, CreatedAt: createdAt}))
+	require.Equal(t, 0, repository.RevokeAPIKey("first", 1))
+	expected := repository.(*Storage).Snapshot()
+	require.Nil(t, repository.Shutdown())
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	assert.Equal(t, expected, restored.(*Storage).Snapshot())
+	_, errCode := restored.GetAPIKeyByHash("hash1")
+	assert.Equal(t, http.StatusNotFound, errCode)
+	key, errCode := restored.GetAPIKeyByHash("hash2")
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "second", key.ID)
+}
+
+func checkAccounts(t *testing.T, strg IRepository) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	account := Account{ID: 100, Username: "alice", PasswordHash: "hash", CreatedAt: createdAt}
+	assert.Equal(t, 0, strg.CreateAccount(account))
+	assert.Equal(t, http.StatusConflict, strg.CreateAccount(Account{ID: 101, Username: "alice", PasswordHash: "hash", CreatedAt: createdAt}))
+	assert.Equal(t, http.StatusConflict, strg.CreateAccount(Account{ID: 100, Username: "bob", PasswordHash: "hash", CreatedAt: createdAt}))
+
+	found, errCode := strg.GetAccountByUsername("alice")
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, account, found)
+	_, errCode = strg.GetAccountByUsername("bob")
+	assert.Equal(t, http.StatusNotFound, errCode)
+	found, errCode = strg.GetAccountByUserID(100)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, account, found)
+	_, errCode = strg.GetAccountByUserID(1)
+	assert.Equal(t, http.StatusNotFound, errCode)
+
+	_, _, errCode = strg.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://google.com", 1)
+	require.Equal(t, 0, errCode)
+	_, _, errCode = strg.CreateShortURLByURL("http://yandex.ru", 100)
+	require.Equal(t, 0, errCode)
+	require.Equal(t, 0, strg.CreateAPIKey(APIKey{ID: "first", UserID: 1, Hash: "hash1", Scopes: []string{"read"}, CreatedAt: createdAt}))
+
+	merged, err := strg.MergeUser(1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, merged)
+	urls, errCode := strg.GetAllURLsByUserID(100, "localhost:8080/")
+	assert.Equal(t, http.StatusOK, errCode)
+	assert.Equal(t, 3, len(urls))
+	_, errCode = strg.GetAllURLsByUserID(1, "localhost:8080/")
+	assert.Equal(t, http.StatusNoContent, errCode)
+	key, errCode := strg.GetAPIKeyByHash("hash1")
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, uint(100), key.UserID)
+
+	merged, err = strg.MergeUser(1, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 0, merged)
+}
+
+func TestStorage_Accounts(t *testing.T) {
+	checkAccounts(t, NewMemoryStorage(map[uint]URL{}, map[uint][]uint{}, 1))
+}
+
+func TestSQLiteStorage_Accounts(t *testing.T) {
+	checkAccounts(t, newTestSQLiteStorage(t))
+}
+
+func TestDBStorage_Accounts(t *testing.T) {
+	checkAccounts(t, newTestDBStorage(t))
+}
+
+func TestNewStorage_RestoreAccountsAfterRestart(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "storage.txt")
+	repository, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.Equal(t, 0, repository.CreateAccount(Account{ID: 100, Username: "alice", PasswordHash: "hash", CreatedAt: createdAt}))
+	_, _, errCode := repository.CreateShortURLByURL("http://ya.ru", 1)
+	require.Equal(t, 0, errCode)
+	require.Nil(t, repository.(*Storage).Compact())
+	require.Equal(t, 0, repository.CreateAccount(Account{ID: 200, Username: "bob", PasswordHash: "hash", CreatedAt: createdAt}))
+	_, err = repository.MergeUser(1, 100)
+	require.NoError(t, err)
+	expected := repository.(*Storage).Snapshot()
+	require.Nil(t, repository.Shutdown())
+
+	restored, err := NewStorage(map[uint]URL{}, 1, filename, "")
+	require.NoError(t, err)
+	assert.Equal(t, expected, restored.(*Storage).Snapshot())
+	account, errCode := restored.GetAccountByUserID(200)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "bob", account.Username)
+	value, errCode := restored.GetValueByKeyAndUserID(1, 100)
+	assert.Equal(t, 0, errCode)
+	assert.Equal(t, "http://ya.ru", value)
+}
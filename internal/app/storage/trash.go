@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DeletedURLResponse - response object for deleted URL in user trash
+type DeletedURLResponse struct {
+	ShortURL    string     `json:"short_url"`    // ShortURL - result shorten URL
+	OriginalURL string     `json:"original_url"` // OriginalURL - original URL
+	DeletedAt   *time.Time `json:"deleted_at"`   // DeletedAt - time when URL was deleted, null if URL was deleted before deletion time was stored
+}
+
+// newDeletedURLResponse - create DeletedURLResponse for URL with given ID
+func newDeletedURLResponse(ID uint, url URL, baseURL string) DeletedURLResponse {
+	response := DeletedURLResponse{ShortURL: baseURL + GetShortURL(ID, url.Alias), OriginalURL: url.Value}
+	if !url.DeletedAt.IsZero() {
+		deletedAt := url.DeletedAt.UTC()
+		response.DeletedAt = &deletedAt
+	}
+	return response
+}
+
+// scanDeletedURLs - read deleted URLs from rows with id, value, alias and deleted_at columns
+func scanDeletedURLs(rows *sql.Rows, baseURL string) ([]DeletedURLResponse, int) {
+	responseList := make([]DeletedURLResponse, 0)
+	for rows.Next() {
+		// URLID - URL ID
+		var URLID uint
+		var url URL
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&URLID, &url.Value, &url.Alias, &deletedAt); err != nil {
+			return nil, http.StatusInternalServerError
+		}
+		url.DeletedAt = deletedAt.Time
+		responseList = append(responseList, newDeletedURLResponse(URLID, url, baseURL))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	if len(responseList) == 0 {
+		return nil, http.StatusNoContent
+	}
+	return responseList, http.StatusOK
+}
+
+// GetDeletedURLsByUserID - get deleted URLs by userID from Storage, the most recently deleted go first
+func (strg *Storage) GetDeletedURLsByUserID(userID uint, baseURL string) ([]DeletedURLResponse, int) {
+	userURLs, _ := strg.getUserURLIDs(userID)
+	deletedIDs := make([]uint, 0)
+	deletedURLs := make(map[uint]URL)
+	// URLID - URL ID
+	for _, URLID := range userURLs {
+		url, ok := strg.getURL(URLID)
+		if ok && url.Deleted {
+			deletedIDs = append(deletedIDs, URLID)
+			deletedURLs[URLID] = url
+		}
+	}
+	if len(deletedIDs) == 0 {
+		return nil, http.StatusNoContent
+	}
+	sort.Slice(deletedIDs, func(i, j int) bool {
+		left, right := deletedURLs[deletedIDs[i]].DeletedAt, deletedURLs[deletedIDs[j]].DeletedAt
+		if !left.Equal(right) {
+			return left.After(right)
+		}
+		return deletedIDs[i] < deletedIDs[j]
+	})
+	responseList := make([]DeletedURLResponse, 0, len(deletedIDs))
+	for _, URLID := range deletedIDs {
+		responseList = append(responseList, newDeletedURLResponse(URLID, deletedURLs[URLID], baseURL))
+	}
+	return responseList, http.StatusOK
+}
+
+// RestoreBatch - set deleted=false for deleted and not expired URLs by IDs which belong to userID in Storage, returns number of restored URLs
+func (strg *Storage) RestoreBatch(IDs []uint, userID uint) (int, error) {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	userURLs, _ := strg.getUserURLIDs(userID)
+	userURLsSet := make(map[uint]bool, len(userURLs))
+	for _, userURLID := range userURLs {
+		userURLsSet[userURLID] = true
+	}
+	now := time.Now()
+	restored := 0
+	for _, ID := range IDs {
+		if !userURLsSet[ID] {
+			continue
+		}
+		changed := false
+		strg.updateURL(ID, func(value *URL) {
+			if value.Deleted && !value.IsExpired(now) {
+				value.Deleted = false
+				value.DeletedAt = time.Time{}
+				changed = true
+			}
+		})
+		if !changed {
+			continue
+		}
+		if err := strg.writeRecords(NewRestoredRecord(ID, userID)); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	strg.checkLogSize()
+	return restored, nil
+}
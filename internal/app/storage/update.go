@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// URLChange - change of URL value made by its owner
+type URLChange struct {
+	OldValue  string    `json:"old_value"`  // OldValue - URL value before change
+	NewValue  string    `json:"new_value"`  // NewValue - URL value after change
+	UserID    uint      `json:"user_id"`    // UserID - user who made the change
+	ChangedAt time.Time `json:"changed_at"` // ChangedAt - time of the change
+}
+
+// scanURLHistory - read URL changes from rows with old_value, new_value, user_id and changed_at columns
+func scanURLHistory(rows *sql.Rows) ([]URLChange, int) {
+	changes := make([]URLChange, 0)
+	for rows.Next() {
+		var change URLChange
+		if err := rows.Scan(&change.OldValue, &change.NewValue, &change.UserID, &change.ChangedAt); err != nil {
+			return nil, http.StatusInternalServerError
+		}
+		change.ChangedAt = change.ChangedAt.UTC()
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, http.StatusInternalServerError
+	}
+	if len(changes) == 0 {
+		return nil, http.StatusNoContent
+	}
+	return changes, http.StatusOK
+}
+
+// UpdateURLValue - set new value for not deleted URL by key which belongs to userID in Storage and save the change into URL history.
+// http.StatusConflict is returned if value is already used by another URL.
+func (strg *Storage) UpdateURLValue(key uint, value string, userID uint) (errMsg string, errCode int) {
+	strg.compactionLock.RLock()
+	defer strg.compactionLock.RUnlock()
+	if !strg.hasUserURLID(userID, key) {
+		return "URL not found", http.StatusNotFound
+	}
+	change := URLChange{NewValue: value, UserID: userID, ChangedAt: time.Now().UTC()}
+	errMsg, errCode = strg.retargetURL(key, &change, func() error {
+		return strg.writeRecords(NewUpdatedRecord(key, change))
+	})
+	strg.checkLogSize()
+	return errMsg, errCode
+}
+
+// GetURLHistory - get changes of URL value by key which belongs to userID from Storage, the oldest go first
+func (strg *Storage) GetURLHistory(key uint, userID uint) ([]URLChange, int) {
+	if !strg.hasUserURLID(userID, key) {
+		return nil, http.StatusNotFound
+	}
+	changes := strg.getURLHistory(key)
+	if len(changes) == 0 {
+		return nil, http.StatusNoContent
+	}
+	return changes, http.StatusOK
+}
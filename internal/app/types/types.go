@@ -1,27 +1,73 @@
 package types
 
+import "time"
+
 // userCtxName - type string
 type userCtxName string
 
 // UserIDCtxName - context key for userID
 var UserIDCtxName = userCtxName("UserID")
 
-// CookieKey - key for cookie generator
+// ScopesCtxName - context key for scopes of API key, it is absent for requests authorized with identity token
+var ScopesCtxName = userCtxName("Scopes")
+
+// CookieKey - key for legacy cookie generator, such cookies are only accepted and replaced with identity tokens
 var CookieKey = []byte("URL-Shortener-Key")
 
 // URLShortenderCookieName - cookie name
 var URLShortenderCookieName = "URL-Shortener"
 
-// RequestToDelete - message type for URL deletion
-type RequestToDelete struct {
-	URLs   []string // URLs - list with URLs to delete
-	UserID uint     // UserID - user ID for URLs to delete
+// Statuses of URLs deletion jobs
+const (
+	DeleteJobQueued = "queued" // DeleteJobQueued - job waits for processing or for retry after failed attempt
+	DeleteJobDone   = "done"   // DeleteJobDone - URLs were marked as deleted
+	DeleteJobFailed = "failed" // DeleteJobFailed - all attempts failed, Error contains the last error
+)
+
+// DeleteJobResponse - status of URLs deletion job
+type DeleteJobResponse struct {
+	// ID - job ID
+	ID string `json:"id"`
+	// Status - one of DeleteJobQueued, DeleteJobDone or DeleteJobFailed
+	Status string `json:"status"`
+	// URLs - number of URLs in deletion request
+	URLs int `json:"urls"`
+	// Attempts - number of made attempts
+	Attempts int `json:"attempts"`
+	// Error - error of the last failed attempt
+	Error string `json:"error,omitempty"`
+	// CreatedAt - time when job was queued
+	CreatedAt time.Time `json:"created_at"`
+	// FinishedAt - time when job was done or failed, nil for queued job
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
 }
 
 // URLBodyRequest is a base structure for request
 type URLBodyRequest struct {
 	// URL to shorten
 	URL string `json:"url"`
+	// CustomAlias - optional custom short URL
+	CustomAlias string `json:"custom_alias,omitempty"`
+	// ExpiresAt - optional absolute expiration time
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// TTLSeconds - optional time to live in seconds
+	TTLSeconds int64 `json:"ttl_seconds,omitempty"`
+	// RedirectType - optional redirect status code, one of 301, 302, 307 or 308, default one is used if it isn't set
+	RedirectType int `json:"redirect_type,omitempty"`
+	// Title - optional title shown on preview page
+	Title string `json:"title,omitempty"`
+	// RequireInterstitial - optional flag, redirect always goes through preview page if it is set
+	RequireInterstitial bool `json:"require_interstitial,omitempty"`
+	// Password - optional password, redirect is done only after it is entered
+	Password string `json:"password,omitempty"`
+	// MaxClicks - optional number of redirects after which URL stops working
+	MaxClicks int `json:"max_clicks,omitempty"`
+}
+
+// UpdateURLRequest - request for changing original URL of short URL
+type UpdateURLRequest struct {
+	// URL - new original URL
+	URL string `json:"url"`
 }
 
 // ShortenURLResponse response for shorten URL creation
@@ -29,3 +75,82 @@ type ShortenURLResponse struct {
 	// URL result
 	URL string `json:"result"`
 }
+
+// APIKeyRequest - request for API key creation
+type APIKeyRequest struct {
+	// Name - optional name of key
+	Name string `json:"name"`
+	// Scopes - operations allowed for key, at least one of shorten, read or delete
+	Scopes []string `json:"scopes"`
+}
+
+// APIKeyResponse - API key info
+type APIKeyResponse struct {
+	// ID - public ID of key
+	ID string `json:"id"`
+	// Key - the key itself, returned only once on creation
+	Key string `json:"key,omitempty"`
+	// Name - name of key
+	Name string `json:"name"`
+	// Scopes - operations allowed for key
+	Scopes []string `json:"scopes"`
+	// CreatedAt - creation time of key
+	CreatedAt time.Time `json:"created_at"`
+	// RevokedAt - revocation time of key, nil for active key
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// AccountRequest - request for account registration or login
+type AccountRequest struct {
+	// Username - name of account, case insensitive
+	Username string `json:"username"`
+	// Password - password of account
+	Password string `json:"password"`
+}
+
+// AccountResponse - response for account registration or login
+type AccountResponse struct {
+	// Username - normalized name of account
+	Username string `json:"username"`
+	// Merged - number of URLs moved to account from anonymous user of request
+	Merged int `json:"merged"`
+}
+
+// RestoreURLsResponse - response for deleted URLs restoration
+type RestoreURLsResponse struct {
+	// Restored - number of restored URLs
+	Restored int `json:"restored"`
+}
+
+// Statuses of imported rows
+const (
+	ImportStatusCreated   = "created"   // ImportStatusCreated - short URL was created for row
+	ImportStatusDuplicate = "duplicate" // ImportStatusDuplicate - URL is already shortened, existing short URL is returned
+	ImportStatusInvalid   = "invalid"   // ImportStatusInvalid - row couldn't be parsed or URL isn't valid
+)
+
+// ImportRowResult - result of single row import
+type ImportRowResult struct {
+	// Row - number of data row in uploaded file starting from 1
+	Row int `json:"row"`
+	// OriginalURL - URL from row
+	OriginalURL string `json:"original_url"`
+	// Status - one of ImportStatusCreated, ImportStatusDuplicate or ImportStatusInvalid
+	Status string `json:"status"`
+	// ShortURL - created or existing short URL, empty for invalid row
+	ShortURL string `json:"short_url,omitempty"`
+	// Error - reason why row is invalid
+	Error string `json:"error,omitempty"`
+}
+
+// ImportResponse - response for URLs import with per-row results
+type ImportResponse struct {
+	// Created - number of created short URLs
+	Created int `json:"created"`
+	// Duplicates - number of already shortened URLs
+	Duplicates int `json:"duplicates"`
+	// Invalid - number of invalid rows
+	Invalid int `json:"invalid"`
+	// Rows - results for all rows in upload order
+	Rows []ImportRowResult `json:"rows"`
+}
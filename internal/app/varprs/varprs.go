@@ -4,6 +4,11 @@ import (
 	"encoding/json"
 	"flag"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/tank4gun/gourlshortener/internal/app/auth"
+	"github.com/tank4gun/gourlshortener/internal/app/db"
 )
 
 // FileStoragePath - path to the file storage
@@ -18,7 +23,7 @@ var ServerAddress string
 // GRPCServerAddress - address for running URLShortener app in GRPC mode
 var GRPCServerAddress string
 
-// DatabaseDSN - database connection address
+// DatabaseDSN - database connection address, use sqlite://path.db for SQLite database
 var DatabaseDSN string
 
 // UseHTTPS - flag for HTTPS enabling
@@ -30,15 +35,70 @@ var ConfigPath string
 // TrustedSubnet - subnet mask
 var TrustedSubnet string
 
+// TrustedProxies - comma separated subnets of proxies which X-Real-IP header is trusted from, i.e. 10.0.0.0/8,127.0.0.1/32
+var TrustedProxies string
+
+// CompactionThreshold - file storage log size in bytes which triggers its compaction, negative value disables compaction
+var CompactionThreshold int64
+
+// SweepInterval - interval between runs of expired URLs sweeper and deleted URLs purger
+var SweepInterval time.Duration
+
+// DeleteQueuePath - path to the file with URLs deletion jobs, jobs are kept in memory only if empty.
+// It is next to the storage file for file and SQLite storages by default and should be set explicitly for PostgreSQL.
+var DeleteQueuePath string
+
+// DeletedRetention - time after deletion for which deleted URLs are kept before purge, zero or negative value disables purge
+var DeletedRetention time.Duration
+
+// CacheSize - max number of URLs in lookup cache, negative value disables cache
+var CacheSize int
+
+// CacheTTL - time to live of URL in lookup cache
+var CacheTTL time.Duration
+
+// RedirectType - default redirect status code for URLs created without redirect type, one of 301, 302, 307 or 308
+var RedirectType int
+
+// RedirectMaxAge - time for which permanent redirects of never expiring URLs could be cached by clients and CDN
+var RedirectMaxAge time.Duration
+
+// AuthKeys - comma separated keyID:key pairs for signing user identity tokens, i.e. 2024-01:secret,2024-06:secret2
+var AuthKeys string
+
+// AuthKeyID - ID of key from AuthKeys for signing new identity tokens, the last key is used if empty
+var AuthKeyID string
+
+// AuthTokenTTL - time for which user identity token is valid
+var AuthTokenTTL time.Duration
+
+// AuthLegacyUntil - date or RFC 3339 time until which legacy cookies are accepted and replaced with identity tokens
+// keeping user IDs, auth.DefaultLegacyCutoff is used if empty and "off" disables legacy cookies.
+// Legacy cookie key is public, so cutoff shouldn't be moved far away.
+var AuthLegacyUntil string
+
 // ConfigStruct - struct to parse config file
 type ConfigStruct struct {
-	ServerAddress     string `json:"server_address"`      // ServerAddress - server address for urlshortener app
-	GRPCServerAddress string `json:"grpc_server_address"` // GRPCServerAddress - server address for urlshortener app in GRPC mode
-	BaseURL           string `json:"base_url"`            // BaseURL - base route for URLs
-	FileStoragePath   string `json:"file_storage_path"`   // FileStoragePath - path to file with data in case no db storage allowed
-	DatabaseDSN       string `json:"database_dsn"`        // DatabaseDSN - connection string to database
-	EnableHTTPS       bool   `json:"enable_https"`        // EnableHTTPS - flag in order to enable https
-	TrustedSubnet     string `json:"trusted_subnet"`      // TrustedSubnet - flag for trusted subnet for handle GET /api/internal/stats
+	ServerAddress       string `json:"server_address"`       // ServerAddress - server address for urlshortener app
+	GRPCServerAddress   string `json:"grpc_server_address"`  // GRPCServerAddress - server address for urlshortener app in GRPC mode
+	BaseURL             string `json:"base_url"`             // BaseURL - base route for URLs
+	FileStoragePath     string `json:"file_storage_path"`    // FileStoragePath - path to file with data in case no db storage allowed
+	DatabaseDSN         string `json:"database_dsn"`         // DatabaseDSN - connection string to database, sqlite://path.db for SQLite
+	EnableHTTPS         bool   `json:"enable_https"`         // EnableHTTPS - flag in order to enable https
+	TrustedSubnet       string `json:"trusted_subnet"`       // TrustedSubnet - flag for trusted subnet for handle GET /api/internal/stats
+	TrustedProxies      string `json:"trusted_proxies"`      // TrustedProxies - comma separated subnets of proxies which X-Real-IP header is trusted from
+	CompactionThreshold int64  `json:"compaction_threshold"` // CompactionThreshold - file storage log size in bytes which triggers its compaction
+	SweepInterval       string `json:"sweep_interval"`       // SweepInterval - interval between runs of expired URLs sweeper and deleted URLs purger, i.e. 1m
+	DeleteQueuePath     string `json:"delete_queue_path"`    // DeleteQueuePath - path to file with URLs deletion jobs
+	DeletedRetention    string `json:"deleted_retention"`    // DeletedRetention - time after deletion for which deleted URLs are kept before purge, i.e. 720h
+	CacheSize           int    `json:"cache_size"`           // CacheSize - max number of URLs in lookup cache, negative value disables cache
+	CacheTTL            string `json:"cache_ttl"`            // CacheTTL - time to live of URL in lookup cache, i.e. 30s
+	RedirectType        int    `json:"redirect_type"`        // RedirectType - default redirect status code, one of 301, 302, 307 or 308
+	RedirectMaxAge      string `json:"redirect_max_age"`     // RedirectMaxAge - time for which permanent redirects could be cached, i.e. 24h
+	AuthKeys            string `json:"auth_keys"`            // AuthKeys - comma separated keyID:key pairs for signing user identity tokens
+	AuthKeyID           string `json:"auth_key_id"`          // AuthKeyID - ID of key for signing new identity tokens
+	AuthTokenTTL        string `json:"auth_token_ttl"`       // AuthTokenTTL - time for which user identity token is valid, i.e. 720h
+	AuthLegacyUntil     string `json:"auth_legacy_until"`    // AuthLegacyUntil - time until which legacy cookies are accepted, i.e. 2024-06-01, or "off"
 }
 
 // ParseConfigFile - function got parsing conflict file
@@ -68,11 +128,24 @@ func Init() {
 	flag.StringVar(&GRPCServerAddress, "gprc_addr", "", "GRPC server address")
 	flag.StringVar(&BaseURL, "b", "", "Base URL for shorten URLs")
 	flag.StringVar(&FileStoragePath, "f", "", "File path for storage")
-	flag.StringVar(&DatabaseDSN, "d", "", "Database connection address")
+	flag.StringVar(&DatabaseDSN, "d", "", "Database connection address, sqlite://path.db for SQLite")
 	flag.BoolVar(&UseHTTPS, "s", false, "Use HTTPS for server")
 	flag.StringVar(&ConfigPath, "config", "", "Config file path")
 	flag.StringVar(&ConfigPath, "c", "", "Config file path")
 	flag.StringVar(&TrustedSubnet, "t", "192.168.1.1/24", "Subnet mask")
+	flag.StringVar(&TrustedProxies, "trusted_proxies", "", "Comma separated subnets of proxies which X-Real-IP header is trusted from")
+	flag.Int64Var(&CompactionThreshold, "compaction_threshold", 0, "File storage log size in bytes for compaction")
+	flag.DurationVar(&SweepInterval, "sweep_interval", 0, "Interval between runs of expired URLs sweeper and deleted URLs purger")
+	flag.StringVar(&DeleteQueuePath, "delete_queue_path", "", "File path for URLs deletion jobs")
+	flag.DurationVar(&DeletedRetention, "deleted_retention", 0, "Time after deletion for which deleted URLs are kept before purge")
+	flag.IntVar(&CacheSize, "cache_size", 0, "Max number of URLs in lookup cache, negative value disables cache")
+	flag.DurationVar(&CacheTTL, "cache_ttl", 0, "Time to live of URL in lookup cache")
+	flag.IntVar(&RedirectType, "redirect_type", 0, "Default redirect status code, one of 301, 302, 307 or 308")
+	flag.DurationVar(&RedirectMaxAge, "redirect_max_age", 0, "Time for which permanent redirects could be cached")
+	flag.StringVar(&AuthKeys, "auth_keys", "", "Comma separated keyID:key pairs for signing user identity tokens")
+	flag.StringVar(&AuthKeyID, "auth_key_id", "", "ID of key for signing new identity tokens")
+	flag.DurationVar(&AuthTokenTTL, "auth_token_ttl", 0, "Time for which user identity token is valid")
+	flag.StringVar(&AuthLegacyUntil, "auth_legacy_until", "", "Date or RFC 3339 time until which legacy cookies are accepted, \"off\" disables them")
 	flag.Parse()
 
 	config := ParseConfigFile()
@@ -143,4 +216,125 @@ func Init() {
 	if TrustedSubnet == "" {
 		TrustedSubnet = config.TrustedSubnet
 	}
+	trustedProxies := os.Getenv("TRUSTED_PROXIES")
+	if trustedProxies != "" {
+		TrustedProxies = trustedProxies
+	}
+	if TrustedProxies == "" {
+		TrustedProxies = config.TrustedProxies
+	}
+	compactionThreshold, err := strconv.ParseInt(os.Getenv("COMPACTION_THRESHOLD"), 10, 64)
+	if err == nil {
+		CompactionThreshold = compactionThreshold
+	}
+	if CompactionThreshold == 0 {
+		CompactionThreshold = config.CompactionThreshold
+	}
+	if CompactionThreshold == 0 {
+		CompactionThreshold = 64 << 20
+	}
+	sweepInterval, err := time.ParseDuration(os.Getenv("SWEEP_INTERVAL"))
+	if err == nil {
+		SweepInterval = sweepInterval
+	}
+	if SweepInterval == 0 {
+		SweepInterval, _ = time.ParseDuration(config.SweepInterval)
+	}
+	if SweepInterval <= 0 {
+		SweepInterval = time.Minute
+	}
+	deleteQueuePathEnv := os.Getenv("DELETE_QUEUE_PATH")
+	if deleteQueuePathEnv != "" {
+		DeleteQueuePath = deleteQueuePathEnv
+	}
+	if DeleteQueuePath == "" {
+		DeleteQueuePath = config.DeleteQueuePath
+	}
+	// Deletion jobs are durable by default for persistent storages only
+	if DeleteQueuePath == "" && FileStoragePath != "" {
+		DeleteQueuePath = FileStoragePath + ".deletions"
+	}
+	if DeleteQueuePath == "" && db.IsSQLiteDSN(DatabaseDSN) {
+		DeleteQueuePath = db.SQLitePath(DatabaseDSN) + ".deletions"
+	}
+	deletedRetention, err := time.ParseDuration(os.Getenv("DELETED_RETENTION"))
+	if err == nil {
+		DeletedRetention = deletedRetention
+	}
+	if DeletedRetention == 0 {
+		DeletedRetention, _ = time.ParseDuration(config.DeletedRetention)
+	}
+	cacheSize, err := strconv.Atoi(os.Getenv("CACHE_SIZE"))
+	if err == nil {
+		CacheSize = cacheSize
+	}
+	if CacheSize == 0 {
+		CacheSize = config.CacheSize
+	}
+	if CacheSize == 0 {
+		CacheSize = 10000
+	}
+	cacheTTL, err := time.ParseDuration(os.Getenv("CACHE_TTL"))
+	if err == nil {
+		CacheTTL = cacheTTL
+	}
+	if CacheTTL == 0 {
+		CacheTTL, _ = time.ParseDuration(config.CacheTTL)
+	}
+	if CacheTTL <= 0 {
+		CacheTTL = 30 * time.Second
+	}
+	redirectType, err := strconv.Atoi(os.Getenv("REDIRECT_TYPE"))
+	if err == nil {
+		RedirectType = redirectType
+	}
+	if RedirectType == 0 {
+		RedirectType = config.RedirectType
+	}
+	switch RedirectType {
+	case 301, 302, 307, 308:
+	default:
+		RedirectType = 307
+	}
+	redirectMaxAge, err := time.ParseDuration(os.Getenv("REDIRECT_MAX_AGE"))
+	if err == nil {
+		RedirectMaxAge = redirectMaxAge
+	}
+	if RedirectMaxAge == 0 {
+		RedirectMaxAge, _ = time.ParseDuration(config.RedirectMaxAge)
+	}
+	if RedirectMaxAge <= 0 {
+		RedirectMaxAge = 24 * time.Hour
+	}
+	authKeys := os.Getenv("AUTH_KEYS")
+	if authKeys != "" {
+		AuthKeys = authKeys
+	}
+	if AuthKeys == "" {
+		AuthKeys = config.AuthKeys
+	}
+	authKeyID := os.Getenv("AUTH_KEY_ID")
+	if authKeyID != "" {
+		AuthKeyID = authKeyID
+	}
+	if AuthKeyID == "" {
+		AuthKeyID = config.AuthKeyID
+	}
+	authTokenTTL, err := time.ParseDuration(os.Getenv("AUTH_TOKEN_TTL"))
+	if err == nil {
+		AuthTokenTTL = authTokenTTL
+	}
+	if AuthTokenTTL == 0 {
+		AuthTokenTTL, _ = time.ParseDuration(config.AuthTokenTTL)
+	}
+	if AuthTokenTTL <= 0 {
+		AuthTokenTTL = auth.DefaultTokenTTL
+	}
+	authLegacyUntil := os.Getenv("AUTH_LEGACY_UNTIL")
+	if authLegacyUntil != "" {
+		AuthLegacyUntil = authLegacyUntil
+	}
+	if AuthLegacyUntil == "" {
+		AuthLegacyUntil = config.AuthLegacyUntil
+	}
 }
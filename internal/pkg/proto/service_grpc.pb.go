@@ -25,9 +25,16 @@ const (
 	Shortender_GetURLByID_FullMethodName            = "/service.Shortender/GetURLByID"
 	Shortender_CreateShortenURLBatch_FullMethodName = "/service.Shortender/CreateShortenURLBatch"
 	Shortender_GetAllURLs_FullMethodName            = "/service.Shortender/GetAllURLs"
+	Shortender_ExportURLs_FullMethodName            = "/service.Shortender/ExportURLs"
+	Shortender_UpdateURL_FullMethodName             = "/service.Shortender/UpdateURL"
+	Shortender_GetURLHistory_FullMethodName         = "/service.Shortender/GetURLHistory"
 	Shortender_DeleteURLs_FullMethodName            = "/service.Shortender/DeleteURLs"
+	Shortender_GetDeleteJob_FullMethodName          = "/service.Shortender/GetDeleteJob"
+	Shortender_GetDeletedURLs_FullMethodName        = "/service.Shortender/GetDeletedURLs"
+	Shortender_RestoreURLs_FullMethodName           = "/service.Shortender/RestoreURLs"
 	Shortender_Ping_FullMethodName                  = "/service.Shortender/Ping"
 	Shortender_GetStats_FullMethodName              = "/service.Shortender/GetStats"
+	Shortender_IssueToken_FullMethodName            = "/service.Shortender/IssueToken"
 )
 
 // ShortenderClient is the client API for Shortender service.
@@ -37,10 +44,17 @@ type ShortenderClient interface {
 	CreateShortURL(ctx context.Context, in *UrlToShortenRequest, opts ...grpc.CallOption) (*ShortenUrlResponse, error)
 	GetURLByID(ctx context.Context, in *UrlByIdRequest, opts ...grpc.CallOption) (*UrlByIdResponse, error)
 	CreateShortenURLBatch(ctx context.Context, in *BatchUrlRequest, opts ...grpc.CallOption) (*BatchUrlResponse, error)
-	GetAllURLs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FullInfoUrlBatchResponse, error)
-	DeleteURLs(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetAllURLs(ctx context.Context, in *GetAllUrlsRequest, opts ...grpc.CallOption) (*FullInfoUrlBatchResponse, error)
+	ExportURLs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Shortender_ExportURLsClient, error)
+	UpdateURL(ctx context.Context, in *UpdateUrlRequest, opts ...grpc.CallOption) (*UpdateUrlResponse, error)
+	GetURLHistory(ctx context.Context, in *UrlByIdRequest, opts ...grpc.CallOption) (*UrlHistoryResponse, error)
+	DeleteURLs(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error)
+	GetDeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error)
+	GetDeletedURLs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DeletedUrlBatchResponse, error)
+	RestoreURLs(ctx context.Context, in *RestoreUrlsRequest, opts ...grpc.CallOption) (*RestoreUrlsResponse, error)
 	Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*StatsResponse, error)
+	IssueToken(ctx context.Context, in *IssueTokenRequest, opts ...grpc.CallOption) (*IssueTokenResponse, error)
 }
 
 type shortenderClient struct {
@@ -78,7 +92,7 @@ func (c *shortenderClient) CreateShortenURLBatch(ctx context.Context, in *BatchU
 	return out, nil
 }
 
-func (c *shortenderClient) GetAllURLs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*FullInfoUrlBatchResponse, error) {
+func (c *shortenderClient) GetAllURLs(ctx context.Context, in *GetAllUrlsRequest, opts ...grpc.CallOption) (*FullInfoUrlBatchResponse, error) {
 	out := new(FullInfoUrlBatchResponse)
 	err := c.cc.Invoke(ctx, Shortender_GetAllURLs_FullMethodName, in, out, opts...)
 	if err != nil {
@@ -87,8 +101,58 @@ func (c *shortenderClient) GetAllURLs(ctx context.Context, in *emptypb.Empty, op
 	return out, nil
 }
 
-func (c *shortenderClient) DeleteURLs(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
-	out := new(emptypb.Empty)
+func (c *shortenderClient) ExportURLs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Shortender_ExportURLsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Shortender_ServiceDesc.Streams[0], Shortender_ExportURLs_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &shortenderExportURLsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Shortender_ExportURLsClient interface {
+	Recv() (*ExportUrlResponse, error)
+	grpc.ClientStream
+}
+
+type shortenderExportURLsClient struct {
+	grpc.ClientStream
+}
+
+func (x *shortenderExportURLsClient) Recv() (*ExportUrlResponse, error) {
+	m := new(ExportUrlResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *shortenderClient) UpdateURL(ctx context.Context, in *UpdateUrlRequest, opts ...grpc.CallOption) (*UpdateUrlResponse, error) {
+	out := new(UpdateUrlResponse)
+	err := c.cc.Invoke(ctx, Shortender_UpdateURL_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenderClient) GetURLHistory(ctx context.Context, in *UrlByIdRequest, opts ...grpc.CallOption) (*UrlHistoryResponse, error) {
+	out := new(UrlHistoryResponse)
+	err := c.cc.Invoke(ctx, Shortender_GetURLHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenderClient) DeleteURLs(ctx context.Context, in *DeleteUrlsRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error) {
+	out := new(DeleteJobResponse)
 	err := c.cc.Invoke(ctx, Shortender_DeleteURLs_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -96,6 +160,33 @@ func (c *shortenderClient) DeleteURLs(ctx context.Context, in *DeleteUrlsRequest
 	return out, nil
 }
 
+func (c *shortenderClient) GetDeleteJob(ctx context.Context, in *DeleteJobRequest, opts ...grpc.CallOption) (*DeleteJobResponse, error) {
+	out := new(DeleteJobResponse)
+	err := c.cc.Invoke(ctx, Shortender_GetDeleteJob_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenderClient) GetDeletedURLs(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DeletedUrlBatchResponse, error) {
+	out := new(DeletedUrlBatchResponse)
+	err := c.cc.Invoke(ctx, Shortender_GetDeletedURLs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *shortenderClient) RestoreURLs(ctx context.Context, in *RestoreUrlsRequest, opts ...grpc.CallOption) (*RestoreUrlsResponse, error) {
+	out := new(RestoreUrlsResponse)
+	err := c.cc.Invoke(ctx, Shortender_RestoreURLs_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *shortenderClient) Ping(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	out := new(emptypb.Empty)
 	err := c.cc.Invoke(ctx, Shortender_Ping_FullMethodName, in, out, opts...)
@@ -114,6 +205,15 @@ func (c *shortenderClient) GetStats(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *shortenderClient) IssueToken(ctx context.Context, in *IssueTokenRequest, opts ...grpc.CallOption) (*IssueTokenResponse, error) {
+	out := new(IssueTokenResponse)
+	err := c.cc.Invoke(ctx, Shortender_IssueToken_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ShortenderServer is the server API for Shortender service.
 // All implementations must embed UnimplementedShortenderServer
 // for forward compatibility
@@ -121,10 +221,17 @@ type ShortenderServer interface {
 	CreateShortURL(context.Context, *UrlToShortenRequest) (*ShortenUrlResponse, error)
 	GetURLByID(context.Context, *UrlByIdRequest) (*UrlByIdResponse, error)
 	CreateShortenURLBatch(context.Context, *BatchUrlRequest) (*BatchUrlResponse, error)
-	GetAllURLs(context.Context, *emptypb.Empty) (*FullInfoUrlBatchResponse, error)
-	DeleteURLs(context.Context, *DeleteUrlsRequest) (*emptypb.Empty, error)
+	GetAllURLs(context.Context, *GetAllUrlsRequest) (*FullInfoUrlBatchResponse, error)
+	ExportURLs(*emptypb.Empty, Shortender_ExportURLsServer) error
+	UpdateURL(context.Context, *UpdateUrlRequest) (*UpdateUrlResponse, error)
+	GetURLHistory(context.Context, *UrlByIdRequest) (*UrlHistoryResponse, error)
+	DeleteURLs(context.Context, *DeleteUrlsRequest) (*DeleteJobResponse, error)
+	GetDeleteJob(context.Context, *DeleteJobRequest) (*DeleteJobResponse, error)
+	GetDeletedURLs(context.Context, *emptypb.Empty) (*DeletedUrlBatchResponse, error)
+	RestoreURLs(context.Context, *RestoreUrlsRequest) (*RestoreUrlsResponse, error)
 	Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
 	GetStats(context.Context, *emptypb.Empty) (*StatsResponse, error)
+	IssueToken(context.Context, *IssueTokenRequest) (*IssueTokenResponse, error)
 	mustEmbedUnimplementedShortenderServer()
 }
 
@@ -141,18 +248,39 @@ func (UnimplementedShortenderServer) GetURLByID(context.Context, *UrlByIdRequest
 func (UnimplementedShortenderServer) CreateShortenURLBatch(context.Context, *BatchUrlRequest) (*BatchUrlResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateShortenURLBatch not implemented")
 }
-func (UnimplementedShortenderServer) GetAllURLs(context.Context, *emptypb.Empty) (*FullInfoUrlBatchResponse, error) {
+func (UnimplementedShortenderServer) GetAllURLs(context.Context, *GetAllUrlsRequest) (*FullInfoUrlBatchResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAllURLs not implemented")
 }
-func (UnimplementedShortenderServer) DeleteURLs(context.Context, *DeleteUrlsRequest) (*emptypb.Empty, error) {
+func (UnimplementedShortenderServer) ExportURLs(*emptypb.Empty, Shortender_ExportURLsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportURLs not implemented")
+}
+func (UnimplementedShortenderServer) UpdateURL(context.Context, *UpdateUrlRequest) (*UpdateUrlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateURL not implemented")
+}
+func (UnimplementedShortenderServer) GetURLHistory(context.Context, *UrlByIdRequest) (*UrlHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLHistory not implemented")
+}
+func (UnimplementedShortenderServer) DeleteURLs(context.Context, *DeleteUrlsRequest) (*DeleteJobResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteURLs not implemented")
 }
+func (UnimplementedShortenderServer) GetDeleteJob(context.Context, *DeleteJobRequest) (*DeleteJobResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeleteJob not implemented")
+}
+func (UnimplementedShortenderServer) GetDeletedURLs(context.Context, *emptypb.Empty) (*DeletedUrlBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeletedURLs not implemented")
+}
+func (UnimplementedShortenderServer) RestoreURLs(context.Context, *RestoreUrlsRequest) (*RestoreUrlsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreURLs not implemented")
+}
 func (UnimplementedShortenderServer) Ping(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
 func (UnimplementedShortenderServer) GetStats(context.Context, *emptypb.Empty) (*StatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
 }
+func (UnimplementedShortenderServer) IssueToken(context.Context, *IssueTokenRequest) (*IssueTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IssueToken not implemented")
+}
 func (UnimplementedShortenderServer) mustEmbedUnimplementedShortenderServer() {}
 
 // UnsafeShortenderServer may be embedded to opt out of forward compatibility for this service.
@@ -221,7 +349,7 @@ func _Shortender_CreateShortenURLBatch_Handler(srv interface{}, ctx context.Cont
 }
 
 func _Shortender_GetAllURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(emptypb.Empty)
+	in := new(GetAllUrlsRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -233,7 +361,64 @@ func _Shortender_GetAllURLs_Handler(srv interface{}, ctx context.Context, dec fu
 		FullMethod: Shortender_GetAllURLs_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ShortenderServer).GetAllURLs(ctx, req.(*emptypb.Empty))
+		return srv.(ShortenderServer).GetAllURLs(ctx, req.(*GetAllUrlsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortender_ExportURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ShortenderServer).ExportURLs(m, &shortenderExportURLsServer{stream})
+}
+
+type Shortender_ExportURLsServer interface {
+	Send(*ExportUrlResponse) error
+	grpc.ServerStream
+}
+
+type shortenderExportURLsServer struct {
+	grpc.ServerStream
+}
+
+func (x *shortenderExportURLsServer) Send(m *ExportUrlResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Shortender_UpdateURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUrlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenderServer).UpdateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortender_UpdateURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenderServer).UpdateURL(ctx, req.(*UpdateUrlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortender_GetURLHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UrlByIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenderServer).GetURLHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortender_GetURLHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenderServer).GetURLHistory(ctx, req.(*UrlByIdRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -256,6 +441,60 @@ func _Shortender_DeleteURLs_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortender_GetDeleteJob_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteJobRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenderServer).GetDeleteJob(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortender_GetDeleteJob_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenderServer).GetDeleteJob(ctx, req.(*DeleteJobRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortender_GetDeletedURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenderServer).GetDeletedURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortender_GetDeletedURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenderServer).GetDeletedURLs(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Shortender_RestoreURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreUrlsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenderServer).RestoreURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortender_RestoreURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenderServer).RestoreURLs(ctx, req.(*RestoreUrlsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Shortender_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -292,6 +531,24 @@ func _Shortender_GetStats_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Shortender_IssueToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ShortenderServer).IssueToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Shortender_IssueToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ShortenderServer).IssueToken(ctx, req.(*IssueTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Shortender_ServiceDesc is the grpc.ServiceDesc for Shortender service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -315,10 +572,30 @@ var Shortender_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetAllURLs",
 			Handler:    _Shortender_GetAllURLs_Handler,
 		},
+		{
+			MethodName: "UpdateURL",
+			Handler:    _Shortender_UpdateURL_Handler,
+		},
+		{
+			MethodName: "GetURLHistory",
+			Handler:    _Shortender_GetURLHistory_Handler,
+		},
 		{
 			MethodName: "DeleteURLs",
 			Handler:    _Shortender_DeleteURLs_Handler,
 		},
+		{
+			MethodName: "GetDeleteJob",
+			Handler:    _Shortender_GetDeleteJob_Handler,
+		},
+		{
+			MethodName: "GetDeletedURLs",
+			Handler:    _Shortender_GetDeletedURLs_Handler,
+		},
+		{
+			MethodName: "RestoreURLs",
+			Handler:    _Shortender_RestoreURLs_Handler,
+		},
 		{
 			MethodName: "Ping",
 			Handler:    _Shortender_Ping_Handler,
@@ -327,7 +604,17 @@ var Shortender_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetStats",
 			Handler:    _Shortender_GetStats_Handler,
 		},
+		{
+			MethodName: "IssueToken",
+			Handler:    _Shortender_IssueToken_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportURLs",
+			Handler:       _Shortender_ExportURLs_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/service.proto",
 }